@@ -43,6 +43,7 @@ class Action(BaseModel):
     risk_level: RiskLevel
     reason: Optional[str] = ""
     state: Optional[str] = ""
+    urgent: Optional[bool] = False
 
 
 class DecideRequest(BaseModel):
@@ -59,6 +59,7 @@ class OllamaPolicy(Policy):
                 risk_level=action_data.get("risk_level", "LOW"),
                 reason=reason,
                 state=state,
+                urgent=bool(action_data.get("urgent", False)),
             )
             return action, self.name, model
             
@@ -83,8 +84,11 @@ class OllamaPolicy(Policy):
         if not switch_count:
             switch_count = str(context.switch_count or 0)
         no_progress_minutes = context.signals.get("no_progress_minutes", "0")
+        title_switch_count = context.signals.get("title_switch_count", "0")
         focus_state = context.focus_state or context.signals.get("focus_state", "UNKNOWN")
+        focus_score = context.signals.get("focus_score", "unknown")
         hour_of_day = context.signals.get("hour_of_day", "")
+        hour_tolerance = context.signals.get("hour_tolerance", "unknown")
         user_text = context.user_text
         mode = context.mode
         
@@ -96,6 +100,11 @@ class OllamaPolicy(Policy):
         if context.memory_summary:
             memory_section = f"\nRecent Memory Events:\n{context.memory_summary}\n"
 
+        disabled_section = ""
+        disabled_actions = context.signals.get("disabled_actions", "")
+        if disabled_actions:
+            disabled_section = f"\nNever choose these action types (disabled by the user): {disabled_actions}\n"
+
         return f"""
 You are Always, an intelligent desktop companion.
 Your goal is to offer gentle, non-intrusive support without judging or commanding the user.
@@ -103,12 +112,15 @@ Your goal is to offer gentle, non-intrusive support without judging or commandin
 Current Context:
 - Mode: {mode} (SILENT: minimize disturbance, LIGHT: gentle reminders, ACTIVE: proactive)
 - Focus State: {focus_state}
+- Focus Score (0-100, higher is more focused): {focus_score}
 - App Switch Count: {switch_count}
+- Window Title Switches (same app): {title_switch_count}
 - No-Progress Minutes: {no_progress_minutes}
 - Focus Duration Minutes: {focus_minutes}
 - Current App: {app_name}
 - Window Title: {window_title}
 - Hour of Day: {hour_of_day}
+- Learned Tolerance This Hour: {hour_tolerance}
 - User Input: "{user_text}"
 
 Task:
@@ -119,7 +131,7 @@ Use non-judgmental language; avoid commands and absolute judgments. Use gentle s
 Keep interventions low-frequency; if unsure, choose DO_NOT_DISTURB.
 If late night (hour 23-5), you may offer quiet companionship or a short reflection prompt, but do not push tasks.
 Use the User Profile and Recent Memory to personalize without sounding like monitoring.
-
+{disabled_section}
 Output Format (JSON only):
 {{
   "action_type": "DO_NOT_DISTURB" | "ENCOURAGE" | "TASK_BREAKDOWN" | "REST_REMINDER" | "REFRAME",
@@ -128,7 +140,8 @@ Output Format (JSON only):
   "cost": 0.0 to 1.0 (interruption cost),
   "risk_level": "LOW" | "MEDIUM" | "HIGH",
   "reason": "One short sentence citing concrete signals (e.g., focus_state=FOCUSED, switch_count=1)",
-  "state": "FOCUSED" | "LIGHT" | "DISTRACTED" | "NO_PROGRESS" | "UNKNOWN"
+  "state": "FOCUSED" | "LIGHT" | "DISTRACTED" | "NO_PROGRESS" | "MEETING" | "UNKNOWN",
+  "urgent": true | false (true only when the user is clearly stuck and help cannot wait)
 }}
 """
 
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"always/core/internal/models"
+)
+
+const (
+	BackendOllama = "ollama"
+	BackendOpenAI = "openai-compat"
+)
+
+// PolicyVariantSignal names the A/B policy variant a context is decided
+// under. Backends may use it to pick a prompt variant.
+const PolicyVariantSignal = "policy_variant"
+
+// AIBackend is the decision model behind the gateway. Decide returns the raw
+// action along with the policy and model versions that produced it.
+type AIBackend interface {
+	Decide(ctx models.Context, requestID string) (models.Action, string, string, error)
+	Feedback(reqID, feedback string) error
+}
+
+// PromptRenderer is implemented by backends that assemble the model prompt
+// in-process, so the exact text a context turns into can be inspected.
+type PromptRenderer interface {
+	RenderPrompt(ctx models.Context) (string, error)
+}
+
+type Config struct {
+	Backend string
+	BaseURL string
+	APIKey  string
+	Model   string
+	// PromptVariants maps a policy variant to extra system prompt
+	// instructions for backends that build the prompt in-process.
+	PromptVariants map[string]string
+	// Validation is the response validation level, ValidationStrict when
+	// empty.
+	Validation string
+}
+
+// NormalizeBackend maps accepted spellings onto a backend name, returning ""
+// when the value is not recognised.
+func NormalizeBackend(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", BackendOllama:
+		return BackendOllama
+	case BackendOpenAI, "openai", "openai_compat":
+		return BackendOpenAI
+	default:
+		return ""
+	}
+}
+
+func NewBackend(cfg Config) (AIBackend, error) {
+	validation := NormalizeValidation(cfg.Validation)
+	if validation == "" {
+		return nil, fmt.Errorf("unknown ai response validation %q", cfg.Validation)
+	}
+	strict := validation == ValidationStrict
+	switch NormalizeBackend(cfg.Backend) {
+	case BackendOllama:
+		client := NewClient(cfg.BaseURL)
+		client.strict = strict
+		return client, nil
+	case BackendOpenAI:
+		client, err := NewOpenAIClient(cfg.BaseURL, cfg.APIKey, cfg.Model)
+		if err != nil {
+			return nil, err
+		}
+		client.promptVariants = cfg.PromptVariants
+		client.strict = strict
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown ai backend %q", cfg.Backend)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -13,12 +14,14 @@ import (
 
 type Client struct {
 	baseURL string
+	strict  bool
 	http    *http.Client
 }
 
 func NewClient(baseURL string) *Client {
 	return &Client{
 		baseURL: strings.TrimRight(baseURL, "/"),
+		strict:  true,
 		http: &http.Client{
 			Timeout: 60 * time.Second,
 		},
@@ -57,25 +60,36 @@ func (c *Client) Decide(ctx models.Context, requestID string) (models.Action, st
 			backoff(attempt)
 			continue
 		}
+		raw, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("read ai response: %w", err)
+			backoff(attempt)
+			continue
+		}
 		var parsed struct {
-			Action        models.Action `json:"action"`
-			PolicyVersion string        `json:"policy_version"`
-			ModelVersion  string        `json:"model_version"`
+			Action        json.RawMessage `json:"action"`
+			PolicyVersion string          `json:"policy_version"`
+			ModelVersion  string          `json:"model_version"`
 		}
-		if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("decode ai response: %w", err)
+		if err := json.Unmarshal(raw, &parsed); err != nil {
+			lastErr = InvalidResponseError{Raw: string(raw), Err: fmt.Errorf("decode ai response: %w", err)}
+			backoff(attempt)
+			continue
+		}
+		action, err := parseAction(parsed.Action, c.strict)
+		if err != nil {
+			lastErr = err
 			backoff(attempt)
 			continue
 		}
-		resp.Body.Close()
 		if parsed.PolicyVersion == "" {
 			parsed.PolicyVersion = "policy_v0"
 		}
 		if parsed.ModelVersion == "" {
 			parsed.ModelVersion = "stub"
 		}
-		return parsed.Action, parsed.PolicyVersion, parsed.ModelVersion, nil
+		return action, parsed.PolicyVersion, parsed.ModelVersion, nil
 	}
 
 	return models.Action{}, "", "", fmt.Errorf("ai decide failed: %w", lastErr)
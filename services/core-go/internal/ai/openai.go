@@ -0,0 +1,187 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"always/core/internal/models"
+)
+
+const (
+	openAIPolicyVersion = "openai_compat_v0"
+	defaultOpenAIModel  = "gpt-4o-mini"
+)
+
+const openAISystemPrompt = `You are Always, an intelligent desktop companion.
+Offer gentle, non-intrusive support without judging or commanding the user.
+Only use the explicit signals you are given; do not infer screen or keyboard content.
+Prioritise the user's input text when present. If signals are weak, prefer DO_NOT_DISTURB.
+Never choose an action type listed in the disabled_actions signal.
+Reply with a single JSON object (message in Chinese):
+{"action_type": "DO_NOT_DISTURB" | "ENCOURAGE" | "TASK_BREAKDOWN" | "REST_REMINDER" | "REFRAME",
+ "message": string, "confidence": 0.0-1.0, "cost": 0.0-1.0,
+ "risk_level": "LOW" | "MEDIUM" | "HIGH", "reason": string,
+ "state": "FOCUSED" | "LIGHT" | "DISTRACTED" | "NO_PROGRESS" | "MEETING" | "UNKNOWN",
+ "urgent": boolean (true only when the user is clearly stuck and help cannot wait)}`
+
+// OpenAIClient talks to any OpenAI-compatible /v1/chat/completions endpoint
+// directly, without the Python policy service in between.
+type OpenAIClient struct {
+	endpoint       string
+	apiKey         string
+	model          string
+	promptVariants map[string]string
+	strict         bool
+	http           *http.Client
+}
+
+func NewOpenAIClient(baseURL, apiKey, model string) (*OpenAIClient, error) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return nil, errors.New("openai-compatible backend requires a base url")
+	}
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	return &OpenAIClient{
+		endpoint: chatCompletionsURL(baseURL),
+		apiKey:   apiKey,
+		model:    model,
+		strict:   true,
+		http: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}, nil
+}
+
+func chatCompletionsURL(baseURL string) string {
+	switch {
+	case strings.HasSuffix(baseURL, "/chat/completions"):
+		return baseURL
+	case strings.HasSuffix(baseURL, "/v1"):
+		return baseURL + "/chat/completions"
+	default:
+		return baseURL + "/v1/chat/completions"
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// promptMessages builds the chat for ctx. The system prompt gains the extra
+// instructions configured for the context's policy variant, if any.
+func (c *OpenAIClient) promptMessages(ctx models.Context) ([]chatMessage, error) {
+	contextJSON, err := json.Marshal(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("marshal context: %w", err)
+	}
+	system := openAISystemPrompt
+	if extra := c.promptVariants[ctx.Signals[PolicyVariantSignal]]; extra != "" {
+		system += "\n" + extra
+	}
+	return []chatMessage{
+		{Role: "system", Content: system},
+		{Role: "user", Content: string(contextJSON)},
+	}, nil
+}
+
+// RenderPrompt returns the chat messages Decide would send for ctx, one
+// "role:" block per message.
+func (c *OpenAIClient) RenderPrompt(ctx models.Context) (string, error) {
+	messages, err := c.promptMessages(ctx)
+	if err != nil {
+		return "", err
+	}
+	blocks := make([]string, 0, len(messages))
+	for _, message := range messages {
+		blocks = append(blocks, message.Role+":\n"+message.Content)
+	}
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+func (c *OpenAIClient) Decide(ctx models.Context, requestID string) (models.Action, string, string, error) {
+	messages, err := c.promptMessages(ctx)
+	if err != nil {
+		return models.Action{}, "", "", err
+	}
+	body, err := json.Marshal(map[string]any{
+		"model":           c.model,
+		"messages":        messages,
+		"response_format": map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return models.Action{}, "", "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < 3; attempt++ {
+		action, model, err := c.complete(body, requestID)
+		if err != nil {
+			lastErr = err
+			backoff(attempt)
+			continue
+		}
+		return action, openAIPolicyVersion, model, nil
+	}
+
+	return models.Action{}, "", "", fmt.Errorf("ai decide failed: %w", lastErr)
+}
+
+func (c *OpenAIClient) complete(body []byte, requestID string) (models.Action, string, error) {
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return models.Action{}, "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return models.Action{}, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return models.Action{}, "", fmt.Errorf("ai status: %s", resp.Status)
+	}
+
+	var parsed struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return models.Action{}, "", fmt.Errorf("decode ai response: %w", err)
+	}
+	if len(parsed.Choices) == 0 {
+		return models.Action{}, "", errors.New("ai response has no choices")
+	}
+
+	content := strings.TrimSpace(parsed.Choices[0].Message.Content)
+	action, err := parseAction([]byte(content), c.strict)
+	if err != nil {
+		return models.Action{}, "", err
+	}
+	model := parsed.Model
+	if model == "" {
+		model = c.model
+	}
+	return action, model, nil
+}
+
+// Feedback is recorded locally by the core service; OpenAI-compatible
+// endpoints have nothing to receive it.
+func (c *OpenAIClient) Feedback(reqID, feedback string) error {
+	return nil
+}
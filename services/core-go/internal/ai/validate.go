@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"always/core/internal/models"
+)
+
+// Response validation levels. Strict rejects actions whose values fall
+// outside the schema; lenient only rejects output that does not decode and
+// leaves the rest to the gateway.
+const (
+	ValidationStrict  = "strict"
+	ValidationLenient = "lenient"
+)
+
+// InvalidResponseError reports model output that cannot be used as an
+// action. Raw is the offending payload, kept for debug logging.
+type InvalidResponseError struct {
+	Raw string
+	Err error
+}
+
+func (e InvalidResponseError) Error() string { return "invalid ai response: " + e.Err.Error() }
+func (e InvalidResponseError) Unwrap() error { return e.Err }
+
+// NormalizeValidation maps a validation level onto its canonical name,
+// returning "" when the value is not recognised. Empty means strict.
+func NormalizeValidation(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", ValidationStrict:
+		return ValidationStrict
+	case ValidationLenient:
+		return ValidationLenient
+	default:
+		return ""
+	}
+}
+
+// parseAction decodes raw model output into an action, checking it against
+// the Action schema when strict is set.
+func parseAction(raw []byte, strict bool) (models.Action, error) {
+	var action models.Action
+	if err := json.Unmarshal(raw, &action); err != nil {
+		return models.Action{}, InvalidResponseError{Raw: string(raw), Err: fmt.Errorf("decode action: %w", err)}
+	}
+	if strict {
+		if err := validateAction(action); err != nil {
+			return models.Action{}, InvalidResponseError{Raw: string(raw), Err: err}
+		}
+	}
+	return action, nil
+}
+
+func validateAction(action models.Action) error {
+	switch action.ActionType {
+	case models.ActionDoNotDisturb, models.ActionEncourage, models.ActionTaskBreakdown, models.ActionRestReminder, models.ActionReframe:
+	case "":
+		return errors.New("action_type missing")
+	default:
+		return fmt.Errorf("unknown action_type %q", action.ActionType)
+	}
+	switch action.RiskLevel {
+	case models.RiskLow, models.RiskMedium, models.RiskHigh:
+	case "":
+		return errors.New("risk_level missing")
+	default:
+		return fmt.Errorf("unknown risk_level %q", action.RiskLevel)
+	}
+	if action.Confidence < 0 || action.Confidence > 1 {
+		return fmt.Errorf("confidence %v outside [0, 1]", action.Confidence)
+	}
+	if action.Cost < 0 {
+		return fmt.Errorf("negative cost %v", action.Cost)
+	}
+	return nil
+}
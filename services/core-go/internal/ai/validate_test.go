@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"always/core/internal/models"
+)
+
+func TestParseAction(t *testing.T) {
+	valid := `{"action_type":"ENCOURAGE","message":"keep going","confidence":0.8,"risk_level":"LOW"}`
+	tests := []struct {
+		name      string
+		raw       string
+		strictOK  bool
+		lenientOK bool
+	}{
+		{"valid", valid, true, true},
+		{"truncated", `{"action_type":"ENCOURAGE","mess`, false, false},
+		{"prose", `Sure! Here is the action you asked for.`, false, false},
+		{"wrong type", `{"action_type":"ENCOURAGE","confidence":"high","risk_level":"LOW"}`, false, false},
+		{"missing action_type", `{"message":"hi","confidence":0.5,"risk_level":"LOW"}`, false, true},
+		{"unknown action_type", `{"action_type":"DANCE","confidence":0.5,"risk_level":"LOW"}`, false, true},
+		{"missing risk_level", `{"action_type":"ENCOURAGE","confidence":0.5}`, false, true},
+		{"unknown risk_level", `{"action_type":"ENCOURAGE","confidence":0.5,"risk_level":"EXTREME"}`, false, true},
+		{"confidence above 1", `{"action_type":"ENCOURAGE","confidence":7,"risk_level":"LOW"}`, false, true},
+		{"negative cost", `{"action_type":"ENCOURAGE","confidence":0.5,"risk_level":"LOW","cost":-1}`, false, true},
+	}
+	for _, tt := range tests {
+		for _, strict := range []bool{true, false} {
+			want := tt.lenientOK
+			if strict {
+				want = tt.strictOK
+			}
+			_, err := parseAction([]byte(tt.raw), strict)
+			if (err == nil) != want {
+				t.Errorf("%s (strict %v): err = %v, want ok %v", tt.name, strict, err, want)
+				continue
+			}
+			var invalid InvalidResponseError
+			if err != nil && (!errors.As(err, &invalid) || invalid.Raw != tt.raw) {
+				t.Errorf("%s (strict %v): err = %#v, want InvalidResponseError carrying the raw output", tt.name, strict, err)
+			}
+		}
+	}
+}
+
+func TestDecideReportsInvalidResponses(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed json", `{"action": {"action_type": `},
+		{"invalid action", `{"action": {"action_type": "DANCE", "confidence": 0.5, "risk_level": "LOW"}}`},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, tt.body)
+		}))
+		_, _, _, err := NewClient(server.URL).Decide(models.Context{Mode: models.ModeActive}, "req-1")
+		server.Close()
+		var invalid InvalidResponseError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: err = %v, want InvalidResponseError", tt.name, err)
+		}
+	}
+}
+
+func TestOpenAICompleteReportsInvalidContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		strict  bool
+		wantErr bool
+	}{
+		{"prose", "I think you should take a break.", false, true},
+		{"unknown action_type", `{"action_type":"DANCE","confidence":0.5,"risk_level":"LOW"}`, true, true},
+		{"unknown action_type, lenient", `{"action_type":"DANCE","confidence":0.5,"risk_level":"LOW"}`, false, false},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]any{
+				"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": tt.content}}},
+			})
+		}))
+		client, err := NewOpenAIClient(server.URL, "", "")
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		client.strict = tt.strict
+		_, _, err = client.complete([]byte(`{}`), "req-1")
+		server.Close()
+		var invalid InvalidResponseError
+		if got := errors.As(err, &invalid); got != tt.wantErr {
+			t.Errorf("%s: err = %v, want InvalidResponseError %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeValidation(t *testing.T) {
+	tests := map[string]string{
+		"":         ValidationStrict,
+		"Strict":   ValidationStrict,
+		" lenient": ValidationLenient,
+		"loose":    "",
+	}
+	for value, want := range tests {
+		if got := NormalizeValidation(value); got != want {
+			t.Errorf("NormalizeValidation(%q) = %q, want %q", value, got, want)
+		}
+	}
+	if _, err := NewBackend(Config{Backend: BackendOllama, Validation: "loose"}); err == nil {
+		t.Error("NewBackend accepted an unknown validation level")
+	}
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"always/core/internal/models"
+)
+
+// AckDecision records when the decision reqID was dismissed. Only the first
+// dismissal counts; a repeat returns the stored one unchanged. Callers check
+// ownership and that dismissedAtMs is not before the decision.
+func (s *Store) AckDecision(reqID string, dismissedAtMs, latencyMs int64) (models.DecisionAck, error) {
+	if _, err := s.db.Exec(
+		`INSERT INTO decision_acks (request_id, dismissed_at_ms, latency_ms, created_at_ms) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(request_id) DO NOTHING`,
+		reqID,
+		dismissedAtMs,
+		latencyMs,
+		time.Now().UnixMilli(),
+	); err != nil {
+		return models.DecisionAck{}, fmt.Errorf("insert ack: %w", err)
+	}
+	ack := models.DecisionAck{RequestID: reqID}
+	row := s.db.QueryRow(`SELECT dismissed_at_ms, latency_ms FROM decision_acks WHERE request_id = ?`, reqID)
+	if err := row.Scan(&ack.DismissedAtMs, &ack.LatencyMs); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ack, fmt.Errorf("ack for %s missing after insert", reqID)
+		}
+		return ack, fmt.Errorf("scan ack: %w", err)
+	}
+	return ack, nil
+}
+
+// DismissStats averages time-to-dismiss per action type over decisions
+// logged in [sinceMs, untilMs), sorted by action type.
+func (s *Store) DismissStats(sinceMs, untilMs int64) ([]models.DismissStats, error) {
+	rows, err := s.db.Query(
+		`SELECT e.final_action_json, a.latency_ms
+		 FROM decision_acks a JOIN event_logs e ON e.request_id = a.request_id
+		 WHERE e.user_id = ? AND e.created_at_ms >= ? AND e.created_at_ms < ?`,
+		s.userID,
+		sinceMs,
+		untilMs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query dismiss stats: %w", err)
+	}
+	defer rows.Close()
+	byAction := map[models.ActionType]*models.DismissStats{}
+	totals := map[models.ActionType]int64{}
+	for rows.Next() {
+		var actionJSON string
+		var latencyMs int64
+		if err := rows.Scan(&actionJSON, &latencyMs); err != nil {
+			return nil, fmt.Errorf("scan dismiss stats: %w", err)
+		}
+		actionType := decodeAction(actionJSON).ActionType
+		if actionType == "" {
+			continue
+		}
+		stats := byAction[actionType]
+		if stats == nil {
+			stats = &models.DismissStats{ActionType: actionType, MinLatencyMs: latencyMs}
+			byAction[actionType] = stats
+		}
+		stats.Count++
+		totals[actionType] += latencyMs
+		stats.MinLatencyMs = min(stats.MinLatencyMs, latencyMs)
+		stats.MaxLatencyMs = max(stats.MaxLatencyMs, latencyMs)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dismiss stats rows: %w", err)
+	}
+
+	result := make([]models.DismissStats, 0, len(byAction))
+	for actionType, stats := range byAction {
+		stats.AvgLatencyMs = float64(totals[actionType]) / float64(stats.Count)
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ActionType < result[j].ActionType })
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"always/core/internal/models"
+)
+
+// InsertBudgetSnapshot appends a gateway budget snapshot to the history.
+func (s *Store) InsertBudgetSnapshot(snapshot models.BudgetSnapshot) error {
+	budgetsJSON, err := json.Marshal(snapshot.Budgets)
+	if err != nil {
+		return fmt.Errorf("marshal budgets: %w", err)
+	}
+	maxBudgetsJSON, err := json.Marshal(snapshot.MaxBudgets)
+	if err != nil {
+		return fmt.Errorf("marshal max budgets: %w", err)
+	}
+	if _, err := s.db.Exec(
+		`INSERT INTO budget_history (user_id, ts_ms, budgets_json, max_budgets_json, hourly_used, daily_used)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		s.userID,
+		snapshot.TsMs,
+		string(budgetsJSON),
+		string(maxBudgetsJSON),
+		snapshot.HourlyUsed,
+		snapshot.DailyUsed,
+	); err != nil {
+		return fmt.Errorf("insert budget snapshot: %w", err)
+	}
+	return nil
+}
+
+// ListBudgetSnapshots returns up to limit budget snapshots in [sinceMs,
+// untilMs], newest first. A zero bound is open.
+func (s *Store) ListBudgetSnapshots(limit int, sinceMs int64, untilMs int64) ([]models.BudgetSnapshot, error) {
+	if limit <= 0 {
+		limit = 200
+	}
+	where := []string{"user_id = ?"}
+	args := []any{s.userID}
+	if sinceMs > 0 {
+		where = append(where, "ts_ms >= ?")
+		args = append(args, sinceMs)
+	}
+	if untilMs > 0 {
+		where = append(where, "ts_ms <= ?")
+		args = append(args, untilMs)
+	}
+	query := `SELECT ts_ms, budgets_json, max_budgets_json, hourly_used, daily_used FROM budget_history`
+	query += " WHERE " + strings.Join(where, " AND ")
+	query += " ORDER BY ts_ms DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query budget history: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []models.BudgetSnapshot{}
+	for rows.Next() {
+		var snapshot models.BudgetSnapshot
+		var budgetsJSON, maxBudgetsJSON string
+		if err := rows.Scan(&snapshot.TsMs, &budgetsJSON, &maxBudgetsJSON, &snapshot.HourlyUsed, &snapshot.DailyUsed); err != nil {
+			return nil, fmt.Errorf("scan budget snapshot: %w", err)
+		}
+		if err := json.Unmarshal([]byte(budgetsJSON), &snapshot.Budgets); err != nil {
+			return nil, fmt.Errorf("decode budgets: %w", err)
+		}
+		if err := json.Unmarshal([]byte(maxBudgetsJSON), &snapshot.MaxBudgets); err != nil {
+			return nil, fmt.Errorf("decode max budgets: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("budget history rows: %w", err)
+	}
+	return snapshots, nil
+}
@@ -10,7 +10,8 @@ import (
 	"strings"
 	"time"
 
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 
 	"always/core/internal/models"
 )
@@ -18,6 +19,7 @@ import (
 const schema = `
 CREATE TABLE IF NOT EXISTS event_logs (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id TEXT NOT NULL DEFAULT 'default',
   request_id TEXT NOT NULL UNIQUE,
   context_json TEXT NOT NULL,
   action_json TEXT NOT NULL,
@@ -45,17 +47,20 @@ CREATE TABLE IF NOT EXISTS implicit_feedback_events (
   request_id TEXT,
   feedback_type TEXT NOT NULL,
   feedback_text TEXT,
+  reason_code TEXT,
   created_at_ms INTEGER NOT NULL
 );
 
 CREATE TABLE IF NOT EXISTS user_settings (
-  key TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL DEFAULT 'default',
+  key TEXT NOT NULL,
   value TEXT NOT NULL,
-  updated_at_ms INTEGER NOT NULL
+  updated_at_ms INTEGER NOT NULL,
+  PRIMARY KEY (user_id, key)
 );
 
 CREATE TABLE IF NOT EXISTS budget_usage (
-  id INTEGER PRIMARY KEY CHECK (id = 1),
+  user_id TEXT PRIMARY KEY,
   daily_day TEXT NOT NULL,
   daily_used REAL NOT NULL,
   hourly_hour TEXT NOT NULL,
@@ -63,6 +68,13 @@ CREATE TABLE IF NOT EXISTS budget_usage (
   updated_at_ms INTEGER NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS auto_suggest_usage (
+  user_id TEXT PRIMARY KEY,
+  day TEXT NOT NULL,
+  count INTEGER NOT NULL,
+  updated_at_ms INTEGER NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS focus_events (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
   ts_ms INTEGER NOT NULL,
@@ -76,17 +88,29 @@ CREATE TABLE IF NOT EXISTS focus_events (
 CREATE UNIQUE INDEX IF NOT EXISTS idx_event_logs_request_id ON event_logs (request_id);
 CREATE INDEX IF NOT EXISTS idx_event_logs_created_at_ms ON event_logs (created_at_ms);
 CREATE INDEX IF NOT EXISTS idx_feedback_logs_request_id ON feedback_logs (request_id);
+CREATE INDEX IF NOT EXISTS idx_implicit_feedback_request_id ON implicit_feedback_events (request_id);
 CREATE INDEX IF NOT EXISTS idx_focus_events_ts_ms ON focus_events (ts_ms);
 
 CREATE TABLE IF NOT EXISTS profiles (
-  key TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL DEFAULT 'default',
+  key TEXT NOT NULL,
   value TEXT NOT NULL,
   confidence REAL DEFAULT 1.0,
-  updated_at_ms INTEGER NOT NULL
+  updated_at_ms INTEGER NOT NULL,
+  decayed_at_ms INTEGER,
+  PRIMARY KEY (user_id, key)
+);
+
+CREATE TABLE IF NOT EXISTS profile_suppressions (
+  user_id TEXT NOT NULL DEFAULT 'default',
+  key TEXT NOT NULL,
+  until_ms INTEGER NOT NULL,
+  PRIMARY KEY (user_id, key)
 );
 
 CREATE TABLE IF NOT EXISTS memory_events (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id TEXT NOT NULL DEFAULT 'default',
   event_type TEXT NOT NULL,
   summary TEXT NOT NULL,
   created_at_ms INTEGER NOT NULL,
@@ -104,6 +128,32 @@ CREATE TABLE IF NOT EXISTS focus_state_snapshots (
   window_title TEXT
 );
 
+CREATE TABLE IF NOT EXISTS decision_tags (
+  request_id TEXT NOT NULL,
+  tag TEXT NOT NULL,
+  created_at_ms INTEGER NOT NULL,
+  PRIMARY KEY (request_id, tag)
+);
+
+CREATE TABLE IF NOT EXISTS decision_acks (
+  request_id TEXT PRIMARY KEY,
+  dismissed_at_ms INTEGER NOT NULL,
+  latency_ms INTEGER NOT NULL,
+  created_at_ms INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS budget_history (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id TEXT NOT NULL DEFAULT 'default',
+  ts_ms INTEGER NOT NULL,
+  budgets_json TEXT NOT NULL,
+  max_budgets_json TEXT NOT NULL,
+  hourly_used REAL NOT NULL,
+  daily_used REAL NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_history_user_ts ON budget_history (user_id, ts_ms);
+CREATE INDEX IF NOT EXISTS idx_decision_tags_tag ON decision_tags (tag);
 CREATE INDEX IF NOT EXISTS idx_memory_events_type ON memory_events (event_type);
 CREATE INDEX IF NOT EXISTS idx_memory_events_created ON memory_events (created_at_ms);
 CREATE INDEX IF NOT EXISTS idx_focus_state_snapshots_ts_ms ON focus_state_snapshots (ts_ms);
@@ -111,8 +161,30 @@ CREATE INDEX IF NOT EXISTS idx_focus_state_snapshots_ts_ms ON focus_state_snapsh
 
 const budgetUsageKey = "budget_usage"
 
+// sqliteParams make concurrent writers queue instead of failing. Every
+// transaction here writes, so each takes the write lock when it begins
+// (_txlock=immediate) rather than upgrading after its reads, which SQLite
+// cannot wait out; busy_timeout then lets it wait for the current writer.
+const sqliteParams = "?_pragma=busy_timeout(5000)&_txlock=immediate"
+
+// userScopedTables are rebuilt by migrateUserScope when they predate the
+// user_id column, because their primary keys must include it.
+var userScopedTables = []struct {
+	name    string
+	columns string
+	filter  string
+}{
+	{name: "user_settings", columns: "key, value, updated_at_ms"},
+	{name: "profiles", columns: "key, value, confidence, updated_at_ms, decayed_at_ms"},
+	{name: "profile_suppressions", columns: "key, until_ms"},
+	{name: "budget_usage", columns: "daily_day, daily_used, hourly_hour, hourly_used, updated_at_ms", filter: "WHERE id = 1"},
+}
+
+// Store reads and writes on behalf of a single user; see ForUser. Focus
+// events and snapshots describe the machine and are shared by all users.
 type Store struct {
-	db *sql.DB
+	db     *sql.DB
+	userID string
 }
 
 func Open(path string) (*Store, error) {
@@ -122,7 +194,7 @@ func Open(path string) (*Store, error) {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, fmt.Errorf("create db dir: %w", err)
 	}
-	db, err := sql.Open("sqlite", path)
+	db, err := sql.Open("sqlite", path+sqliteParams)
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite: %w", err)
 	}
@@ -132,13 +204,56 @@ func Open(path string) (*Store, error) {
 	if err := applyMigrations(db); err != nil {
 		return nil, fmt.Errorf("apply migrations: %w", err)
 	}
-	return &Store{db: db}, nil
+	return &Store{db: db, userID: models.DefaultUserID}, nil
 }
 
 func (s *Store) DB() *sql.DB {
 	return s.db
 }
 
+// ForUser returns a Store sharing the same database whose settings, logs and
+// budget usage are scoped to userID.
+func (s *Store) ForUser(userID string) *Store {
+	if userID == "" {
+		userID = models.DefaultUserID
+	}
+	return &Store{db: s.db, userID: userID}
+}
+
+// UserID reports the user this Store is scoped to.
+func (s *Store) UserID() string {
+	return s.userID
+}
+
+// UserIDs lists every user with settings, profiles, memory or decisions on
+// record, always including the default user.
+func (s *Store) UserIDs() ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT ? UNION
+		SELECT user_id FROM user_settings UNION
+		SELECT user_id FROM profiles UNION
+		SELECT user_id FROM memory_events UNION
+		SELECT user_id FROM event_logs
+		ORDER BY 1`, models.DefaultUserID)
+	if err != nil {
+		return nil, fmt.Errorf("query user ids: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan user id: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return userIDs, nil
+}
+
 func applyMigrations(db *sql.DB) error {
 	// Check if this is a fresh database by checking if event_logs table is empty
 	var tableExists int
@@ -176,6 +291,78 @@ func applyMigrations(db *sql.DB) error {
 	if err := addColumnIfMissing(db, "focus_events", "window_title TEXT"); err != nil {
 		return err
 	}
+	if err := addColumnIfMissing(db, "implicit_feedback_events", "reason_code TEXT"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "profiles", "decayed_at_ms INTEGER"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "feedback_logs", "rating INTEGER"); err != nil {
+		return err
+	}
+	return migrateUserScope(db)
+}
+
+// migrateUserScope adds the user_id dimension to databases created before
+// per-user namespaces. Existing rows are assigned to the default user.
+func migrateUserScope(db *sql.DB) error {
+	for _, table := range []string{"event_logs", "memory_events"} {
+		if err := addColumnIfMissing(db, table, "user_id TEXT NOT NULL DEFAULT 'default'"); err != nil {
+			return err
+		}
+	}
+	for _, table := range userScopedTables {
+		if err := rebuildWithUserID(db, table.name, table.columns, table.filter); err != nil {
+			return err
+		}
+	}
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_event_logs_user_created ON event_logs (user_id, created_at_ms)",
+		"CREATE INDEX IF NOT EXISTS idx_memory_events_user_created ON memory_events (user_id, created_at_ms)",
+		"CREATE INDEX IF NOT EXISTS idx_event_logs_unrated ON event_logs (user_id, created_at_ms) WHERE COALESCE(user_feedback, '') = ''",
+	}
+	for _, stmt := range indexes {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("create user index: %w", err)
+		}
+	}
+	return nil
+}
+
+// rebuildWithUserID recreates table from schema and copies its rows over as
+// the default user. SQLite cannot alter a primary key in place.
+func rebuildWithUserID(db *sql.DB, table, columns, filter string) error {
+	var hasColumn int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = 'user_id'", table,
+	).Scan(&hasColumn)
+	if err != nil {
+		return fmt.Errorf("check %s user_id: %w", table, err)
+	}
+	if hasColumn > 0 {
+		return nil
+	}
+	legacy := table + "_legacy"
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin %s rebuild: %w", table, err)
+	}
+	stmts := []string{
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", table, legacy),
+		schema,
+		fmt.Sprintf("INSERT INTO %s (user_id, %s) SELECT '%s', %s FROM %s %s",
+			table, columns, models.DefaultUserID, columns, legacy, filter),
+		fmt.Sprintf("DROP TABLE %s", legacy),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("rebuild %s: %w", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit %s rebuild: %w", table, err)
+	}
 	return nil
 }
 
@@ -304,8 +491,9 @@ func (s *Store) InsertDecision(entry models.DecisionLogEntry) error {
 	}
 
 	_, err = s.db.Exec(
-		`INSERT INTO event_logs (request_id, context_json, action_json, raw_action_json, final_action_json, gateway_decision_json, policy_version, model_version, latency_ms, created_at, created_at_ms)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO event_logs (user_id, request_id, context_json, action_json, raw_action_json, final_action_json, gateway_decision_json, policy_version, model_version, latency_ms, created_at, created_at_ms)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.userID,
 		entry.RequestID,
 		string(ctxJSON),
 		string(finalActionJSON),
@@ -319,12 +507,39 @@ func (s *Store) InsertDecision(entry models.DecisionLogEntry) error {
 		createdAtMs,
 	)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateRequestID
+		}
 		return fmt.Errorf("insert event log: %w", err)
 	}
 	return nil
 }
 
+// ErrDuplicateRequestID is returned by InsertDecision when a decision with
+// the same request_id is already stored.
+var ErrDuplicateRequestID = errors.New("duplicate request_id")
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
 func (s *Store) DecisionExists(reqID string) (bool, error) {
+	row := s.db.QueryRow(`SELECT 1 FROM event_logs WHERE user_id = ? AND request_id = ? LIMIT 1`, s.userID, reqID)
+	var exists int
+	if err := row.Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("check request_id: %w", err)
+	}
+	return true, nil
+}
+
+// RequestIDInUse reports whether any user has logged a decision under reqID.
+// request_id stays unique across users because feedback, tags and acks are
+// keyed by it alone.
+func (s *Store) RequestIDInUse(reqID string) (bool, error) {
 	row := s.db.QueryRow(`SELECT 1 FROM event_logs WHERE request_id = ? LIMIT 1`, reqID)
 	var exists int
 	if err := row.Scan(&exists); err != nil {
@@ -336,10 +551,123 @@ func (s *Store) DecisionExists(reqID string) (bool, error) {
 	return true, nil
 }
 
-func (s *Store) RecordFeedback(reqID, feedback string) error {
+// GetDecisionResponse rebuilds the response originally returned for reqID.
+func (s *Store) GetDecisionResponse(reqID string) (models.DecisionResponse, bool, error) {
+	row := s.db.QueryRow(
+		`SELECT request_id, context_json, final_action_json, action_json, gateway_decision_json, policy_version, model_version, latency_ms, created_at, created_at_ms
+		 FROM event_logs WHERE user_id = ? AND request_id = ? LIMIT 1`,
+		s.userID,
+		reqID,
+	)
+	var resp models.DecisionResponse
+	var contextJSON, finalActionJSON, actionJSON, gatewayDecisionJSON, createdAt string
+	if err := row.Scan(
+		&resp.RequestID,
+		&contextJSON,
+		&finalActionJSON,
+		&actionJSON,
+		&gatewayDecisionJSON,
+		&resp.PolicyVersion,
+		&resp.ModelVersion,
+		&resp.LatencyMs,
+		&createdAt,
+		&resp.CreatedAtMs,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.DecisionResponse{}, false, nil
+		}
+		return models.DecisionResponse{}, false, fmt.Errorf("query decision: %w", err)
+	}
+	resp.Context = decodeContext(contextJSON)
+	resp.Action = decodeAction(finalActionJSON)
+	if resp.Action.ActionType == "" {
+		resp.Action = decodeAction(actionJSON)
+	}
+	resp.GatewayDecision = decodeGatewayDecision(gatewayDecisionJSON)
+	resp.CreatedAt = parseCreatedAt(createdAt, resp.CreatedAtMs)
+	return resp, true, nil
+}
+
+// recentMessagesScanLimit bounds how many decisions RecentModelMessages reads
+// while looking for one session's messages.
+const recentMessagesScanLimit = 50
+
+// RecentModelMessages returns the messages of up to limit latest non-silent
+// actions the model proposed, newest first and before the gateway touched
+// them. Canned responses (model_version "n/a") are skipped. A non-empty
+// sessionID keeps only decisions whose session_id signal matches.
+func (s *Store) RecentModelMessages(sessionID string, limit int) ([]string, error) {
+	rows, err := s.db.Query(
+		`SELECT context_json, raw_action_json FROM event_logs
+		 WHERE user_id = ? AND model_version != 'n/a'
+		 ORDER BY created_at_ms DESC, id DESC LIMIT ?`,
+		s.userID,
+		recentMessagesScanLimit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query recent messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []string
+	for rows.Next() && len(messages) < limit {
+		var contextJSON, rawActionJSON string
+		if err := rows.Scan(&contextJSON, &rawActionJSON); err != nil {
+			return nil, fmt.Errorf("scan recent message: %w", err)
+		}
+		if sessionID != "" && decodeContext(contextJSON).Signals["session_id"] != sessionID {
+			continue
+		}
+		action := decodeAction(rawActionJSON)
+		if action.ActionType == models.ActionDoNotDisturb || action.Message == "" {
+			continue
+		}
+		messages = append(messages, action.Message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("recent message rows: %w", err)
+	}
+	return messages, nil
+}
+
+// LastAllowedAction returns when an actionType suggestion last got through
+// the gateway for this user, looking only at decisions since sinceMs.
+func (s *Store) LastAllowedAction(actionType models.ActionType, sinceMs int64) (int64, bool, error) {
+	rows, err := s.db.Query(
+		`SELECT final_action_json, gateway_decision_json, created_at_ms FROM event_logs
+		 WHERE user_id = ? AND created_at_ms >= ?
+		 ORDER BY created_at_ms DESC, id DESC`,
+		s.userID,
+		sinceMs,
+	)
+	if err != nil {
+		return 0, false, fmt.Errorf("query last allowed action: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var actionJSON, gatewayDecisionJSON string
+		var createdAtMs int64
+		if err := rows.Scan(&actionJSON, &gatewayDecisionJSON, &createdAtMs); err != nil {
+			return 0, false, fmt.Errorf("scan last allowed action: %w", err)
+		}
+		if decodeAction(actionJSON).ActionType == actionType && decodeGatewayDecision(gatewayDecisionJSON).Decision == models.GatewayAllow {
+			return createdAtMs, true, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, false, fmt.Errorf("last allowed action rows: %w", err)
+	}
+	return 0, false, nil
+}
+
+// RecordFeedback stores feedback for a decision. A zero rating is stored as
+// NULL.
+func (s *Store) RecordFeedback(reqID, feedback string, rating int) error {
 	_, err := s.db.Exec(
-		`UPDATE event_logs SET user_feedback = ? WHERE request_id = ?`,
+		`UPDATE event_logs SET user_feedback = ? WHERE user_id = ? AND request_id = ?`,
 		feedback,
+		s.userID,
 		reqID,
 	)
 	if err != nil {
@@ -347,9 +675,10 @@ func (s *Store) RecordFeedback(reqID, feedback string) error {
 	}
 	createdAt := time.Now()
 	_, err = s.db.Exec(
-		`INSERT INTO feedback_logs (request_id, feedback, created_at, created_at_ms) VALUES (?, ?, ?, ?)`,
+		`INSERT INTO feedback_logs (request_id, feedback, rating, created_at, created_at_ms) VALUES (?, ?, ?, ?, ?)`,
 		reqID,
 		feedback,
+		sql.NullInt64{Int64: int64(rating), Valid: rating != 0},
 		createdAt.Format(time.RFC3339Nano),
 		createdAt.UnixMilli(),
 	)
@@ -359,14 +688,15 @@ func (s *Store) RecordFeedback(reqID, feedback string) error {
 	return nil
 }
 
-func (s *Store) RecordImplicitFeedback(reqID string, feedbackType string, feedbackText string) error {
+func (s *Store) RecordImplicitFeedback(reqID string, feedbackType string, feedbackText string, reasonCode string) error {
 	createdAtMs := time.Now().UnixMilli()
 	_, err := s.db.Exec(
-		`INSERT INTO implicit_feedback_events (request_id, feedback_type, feedback_text, created_at_ms)
-		 VALUES (?, ?, ?, ?)`,
+		`INSERT INTO implicit_feedback_events (request_id, feedback_type, feedback_text, reason_code, created_at_ms)
+		 VALUES (?, ?, ?, ?, ?)`,
 		reqID,
 		feedbackType,
 		feedbackText,
+		reasonCode,
 		createdAtMs,
 	)
 	if err != nil {
@@ -375,19 +705,97 @@ func (s *Store) RecordImplicitFeedback(reqID string, feedbackType string, feedba
 	return nil
 }
 
+// AnnoyanceTimes returns when the current user ignored a suggestion or marked
+// it too frequent since sinceMs, oldest first.
+func (s *Store) AnnoyanceTimes(sinceMs int64) ([]int64, error) {
+	rows, err := s.db.Query(
+		`SELECT created_at_ms FROM implicit_feedback_events
+		 WHERE created_at_ms >= ?
+		   AND (feedback_type = ? OR reason_code = ?)
+		   AND request_id IN (SELECT request_id FROM event_logs WHERE user_id = ?)
+		 ORDER BY created_at_ms ASC`,
+		sinceMs,
+		string(models.FeedbackIgnored),
+		string(models.ReasonTooFrequent),
+		s.userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query annoyance feedback: %w", err)
+	}
+	defer rows.Close()
+	var times []int64
+	for rows.Next() {
+		var createdAtMs int64
+		if err := rows.Scan(&createdAtMs); err != nil {
+			return nil, fmt.Errorf("scan annoyance feedback: %w", err)
+		}
+		times = append(times, createdAtMs)
+	}
+	return times, rows.Err()
+}
+
+// WantedMoreTimes returns when the user's WANTED_MORE feedback since sinceMs
+// was recorded, oldest first.
+func (s *Store) WantedMoreTimes(sinceMs int64) ([]int64, error) {
+	prefix := string(models.FeedbackWantedMore)
+	rows, err := s.db.Query(
+		`SELECT created_at_ms FROM feedback_logs
+		 WHERE created_at_ms >= ?
+		   AND substr(feedback, 1, length(?)) = ?
+		   AND request_id IN (SELECT request_id FROM event_logs WHERE user_id = ?)
+		 ORDER BY created_at_ms ASC`,
+		sinceMs,
+		prefix,
+		prefix,
+		s.userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query wanted-more feedback: %w", err)
+	}
+	defer rows.Close()
+	var times []int64
+	for rows.Next() {
+		var createdAtMs int64
+		if err := rows.Scan(&createdAtMs); err != nil {
+			return nil, fmt.Errorf("scan wanted-more feedback: %w", err)
+		}
+		times = append(times, createdAtMs)
+	}
+	return times, rows.Err()
+}
+
 func (s *Store) ListLogs(limit int) ([]models.EventLog, error) {
 	return s.ListLogsRange(limit, 0, 0)
 }
 
 func (s *Store) ListLogsRange(limit int, sinceMs int64, untilMs int64) ([]models.EventLog, error) {
+	return s.listLogs(logFilter{}, limit, sinceMs, untilMs)
+}
+
+// ListUnratedDecisions returns the newest decisions in range that have no
+// feedback yet.
+func (s *Store) ListUnratedDecisions(limit int, sinceMs int64, untilMs int64) ([]models.EventLog, error) {
+	return s.listLogs(logFilter{unrated: true}, limit, sinceMs, untilMs)
+}
+
+// logFilter narrows listLogs beyond the time range.
+type logFilter struct {
+	// tag keeps only decisions carrying it when non-empty.
+	tag string
+	// unrated keeps only decisions without user feedback.
+	unrated bool
+}
+
+// listLogs returns the newest logs in range that match filter.
+func (s *Store) listLogs(filter logFilter, limit int, sinceMs int64, untilMs int64) ([]models.EventLog, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 	if sinceMs < 0 {
 		sinceMs = 0
 	}
-	where := []string{}
-	args := []any{}
+	where := []string{"user_id = ?"}
+	args := []any{s.userID}
 	if sinceMs > 0 {
 		where = append(where, "created_at_ms >= ?")
 		args = append(args, sinceMs)
@@ -396,11 +804,17 @@ func (s *Store) ListLogsRange(limit int, sinceMs int64, untilMs int64) ([]models
 		where = append(where, "created_at_ms <= ?")
 		args = append(args, untilMs)
 	}
+	if filter.tag != "" {
+		where = append(where, "request_id IN (SELECT request_id FROM decision_tags WHERE tag = ?)")
+		args = append(args, filter.tag)
+	}
+	if filter.unrated {
+		// Matches the idx_event_logs_unrated partial index condition.
+		where = append(where, "COALESCE(user_feedback, '') = ''")
+	}
 
 	query := `SELECT request_id, context_json, action_json, raw_action_json, final_action_json, gateway_decision_json, policy_version, model_version, latency_ms, COALESCE(user_feedback, ''), created_at, created_at_ms FROM event_logs`
-	if len(where) > 0 {
-		query += " WHERE " + strings.Join(where, " AND ")
-	}
+	query += " WHERE " + strings.Join(where, " AND ")
 	query += " ORDER BY created_at_ms DESC, id DESC LIMIT ?"
 	args = append(args, limit)
 
@@ -455,20 +869,23 @@ func (s *Store) ListLogsRange(limit int, sinceMs int64, untilMs int64) ([]models
 	return logs, nil
 }
 
-func (s *Store) ExportRecords(limit int, sinceMs int64) ([]models.ExportRecord, error) {
+func (s *Store) ExportRecords(limit int, sinceMs int64, untilMs int64) ([]models.ExportRecord, error) {
 	if limit <= 0 {
 		limit = 1000
 	}
 	if sinceMs < 0 {
 		sinceMs = 0
 	}
-	rows, err := s.db.Query(
-		`SELECT request_id, context_json, raw_action_json, final_action_json, gateway_decision_json, policy_version, model_version, latency_ms, COALESCE(user_feedback, ''), created_at, created_at_ms
-		 FROM event_logs WHERE created_at_ms >= ?
-		 ORDER BY created_at_ms ASC LIMIT ?`,
-		sinceMs,
-		limit,
-	)
+	query := `SELECT request_id, context_json, raw_action_json, final_action_json, gateway_decision_json, policy_version, model_version, latency_ms, COALESCE(user_feedback, ''), created_at, created_at_ms
+		 FROM event_logs WHERE user_id = ? AND created_at_ms >= ?`
+	args := []any{s.userID, sinceMs}
+	if untilMs > 0 {
+		query += " AND created_at_ms <= ?"
+		args = append(args, untilMs)
+	}
+	query += " ORDER BY created_at_ms ASC LIMIT ?"
+	args = append(args, limit)
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query export: %w", err)
 	}
@@ -512,7 +929,20 @@ func (s *Store) ExportRecords(limit int, sinceMs int64) ([]models.ExportRecord,
 }
 
 func (s *Store) ListSettings() ([]models.SettingItem, error) {
-	rows, err := s.db.Query(`SELECT key, value, updated_at_ms FROM user_settings ORDER BY key ASC`)
+	return s.ListSettingsByPrefix("")
+}
+
+// ListSettingsByPrefix returns the settings whose key starts with prefix,
+// ordered by key. The prefix is compared literally, so "_" is not a wildcard.
+func (s *Store) ListSettingsByPrefix(prefix string) ([]models.SettingItem, error) {
+	rows, err := s.db.Query(
+		`SELECT key, value, updated_at_ms FROM user_settings
+		 WHERE user_id = ? AND substr(key, 1, length(?)) = ?
+		 ORDER BY key ASC`,
+		s.userID,
+		prefix,
+		prefix,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("query settings: %w", err)
 	}
@@ -535,9 +965,10 @@ func (s *Store) ListSettings() ([]models.SettingItem, error) {
 func (s *Store) UpsertSetting(key, value string) error {
 	updatedAt := time.Now().UnixMilli()
 	_, err := s.db.Exec(
-		`INSERT INTO user_settings (key, value, updated_at_ms)
-		 VALUES (?, ?, ?)
-		 ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at_ms = excluded.updated_at_ms`,
+		`INSERT INTO user_settings (user_id, key, value, updated_at_ms)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT(user_id, key) DO UPDATE SET value = excluded.value, updated_at_ms = excluded.updated_at_ms`,
+		s.userID,
 		key,
 		value,
 		updatedAt,
@@ -549,7 +980,7 @@ func (s *Store) UpsertSetting(key, value string) error {
 }
 
 func (s *Store) GetSetting(key string) (string, bool, error) {
-	row := s.db.QueryRow(`SELECT value FROM user_settings WHERE key = ?`, key)
+	row := s.db.QueryRow(`SELECT value FROM user_settings WHERE user_id = ? AND key = ?`, s.userID, key)
 	var value string
 	if err := row.Scan(&value); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -562,7 +993,8 @@ func (s *Store) GetSetting(key string) (string, bool, error) {
 
 func (s *Store) GetBudgetUsage() (models.BudgetUsage, error) {
 	row := s.db.QueryRow(
-		`SELECT daily_day, daily_used, hourly_hour, hourly_used FROM budget_usage WHERE id = 1`,
+		`SELECT daily_day, daily_used, hourly_hour, hourly_used FROM budget_usage WHERE user_id = ?`,
+		s.userID,
 	)
 	var usage models.BudgetUsage
 	if err := row.Scan(&usage.DailyDay, &usage.DailyUsed, &usage.HourlyHour, &usage.HourlyUsed); err != nil {
@@ -585,14 +1017,15 @@ func (s *Store) GetBudgetUsage() (models.BudgetUsage, error) {
 func (s *Store) SetBudgetUsage(usage models.BudgetUsage) error {
 	updatedAt := time.Now().UnixMilli()
 	_, err := s.db.Exec(
-		`INSERT INTO budget_usage (id, daily_day, daily_used, hourly_hour, hourly_used, updated_at_ms)
-		 VALUES (1, ?, ?, ?, ?, ?)
-		 ON CONFLICT(id) DO UPDATE SET
+		`INSERT INTO budget_usage (user_id, daily_day, daily_used, hourly_hour, hourly_used, updated_at_ms)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(user_id) DO UPDATE SET
 		   daily_day = excluded.daily_day,
 		   daily_used = excluded.daily_used,
 		   hourly_hour = excluded.hourly_hour,
 		   hourly_used = excluded.hourly_used,
 		   updated_at_ms = excluded.updated_at_ms`,
+		s.userID,
 		usage.DailyDay,
 		usage.DailyUsed,
 		usage.HourlyHour,
@@ -605,6 +1038,47 @@ func (s *Store) SetBudgetUsage(usage models.BudgetUsage) error {
 	return nil
 }
 
+// AutoSuggestCount returns how many auto-suggestions were let through on day
+// (YYYY-MM-DD). Counts from earlier days read as zero.
+func (s *Store) AutoSuggestCount(day string) (int, error) {
+	var storedDay string
+	var count int
+	err := s.db.QueryRow(
+		`SELECT day, count FROM auto_suggest_usage WHERE user_id = ?`,
+		s.userID,
+	).Scan(&storedDay, &count)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("query auto suggest usage: %w", err)
+	}
+	if storedDay != day {
+		return 0, nil
+	}
+	return count, nil
+}
+
+// IncrementAutoSuggestCount records one more auto-suggestion on day, starting
+// over when day has rolled, and returns the new count.
+func (s *Store) IncrementAutoSuggestCount(day string) (int, error) {
+	_, err := s.db.Exec(
+		`INSERT INTO auto_suggest_usage (user_id, day, count, updated_at_ms)
+		 VALUES (?, ?, 1, ?)
+		 ON CONFLICT(user_id) DO UPDATE SET
+		   count = CASE WHEN auto_suggest_usage.day = excluded.day THEN auto_suggest_usage.count + 1 ELSE 1 END,
+		   day = excluded.day,
+		   updated_at_ms = excluded.updated_at_ms`,
+		s.userID,
+		day,
+		time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("increment auto suggest usage: %w", err)
+	}
+	return s.AutoSuggestCount(day)
+}
+
 func (s *Store) loadLegacyBudgetUsage() (models.BudgetUsage, error) {
 	value, ok, err := s.GetSetting(budgetUsageKey)
 	if err != nil {
@@ -620,8 +1094,60 @@ func (s *Store) loadLegacyBudgetUsage() (models.BudgetUsage, error) {
 	return usage, nil
 }
 
+// execer is satisfied by both *sql.DB and *sql.Tx, so the focus event writes
+// can run standalone or inside a FocusTx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// FocusTx writes focus events inside one transaction; see InFocusTx.
+type FocusTx struct {
+	tx *sql.Tx
+}
+
+func (t *FocusTx) InsertFocusEvent(event models.FocusEvent) (int64, error) {
+	return insertFocusEvent(t.tx, event)
+}
+
+func (t *FocusTx) UpdateFocusDuration(id int64, durationMs int64) error {
+	return updateFocusDuration(t.tx, id, durationMs)
+}
+
+func (t *FocusTx) UpdateFocusWindowTitle(id int64, title string) error {
+	return updateFocusWindowTitle(t.tx, id, title)
+}
+
+// InFocusTx runs fn in a transaction, committing when it returns nil and
+// rolling back otherwise.
+func (s *Store) InFocusTx(fn func(*FocusTx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin focus batch: %w", err)
+	}
+	if err := fn(&FocusTx{tx: tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit focus batch: %w", err)
+	}
+	return nil
+}
+
 func (s *Store) InsertFocusEvent(event models.FocusEvent) (int64, error) {
-	result, err := s.db.Exec(
+	return insertFocusEvent(s.db, event)
+}
+
+func (s *Store) UpdateFocusDuration(id int64, durationMs int64) error {
+	return updateFocusDuration(s.db, id, durationMs)
+}
+
+func (s *Store) UpdateFocusWindowTitle(id int64, title string) error {
+	return updateFocusWindowTitle(s.db, id, title)
+}
+
+func insertFocusEvent(e execer, event models.FocusEvent) (int64, error) {
+	result, err := e.Exec(
 		`INSERT INTO focus_events (ts_ms, app_name, bundle_id, pid, window_title, duration_ms)
 		 VALUES (?, ?, ?, ?, ?, ?)`,
 		event.TsMs,
@@ -641,8 +1167,8 @@ func (s *Store) InsertFocusEvent(event models.FocusEvent) (int64, error) {
 	return id, nil
 }
 
-func (s *Store) UpdateFocusDuration(id int64, durationMs int64) error {
-	_, err := s.db.Exec(
+func updateFocusDuration(e execer, id int64, durationMs int64) error {
+	_, err := e.Exec(
 		`UPDATE focus_events SET duration_ms = ? WHERE id = ?`,
 		durationMs,
 		id,
@@ -653,8 +1179,8 @@ func (s *Store) UpdateFocusDuration(id int64, durationMs int64) error {
 	return nil
 }
 
-func (s *Store) UpdateFocusWindowTitle(id int64, title string) error {
-	_, err := s.db.Exec(
+func updateFocusWindowTitle(e execer, id int64, title string) error {
+	_, err := e.Exec(
 		`UPDATE focus_events SET window_title = ? WHERE id = ?`,
 		title,
 		id,
@@ -681,14 +1207,41 @@ func (s *Store) LatestFocusEvent() (models.FocusEvent, bool, error) {
 }
 
 func (s *Store) ListFocusEvents(limit int) ([]models.FocusEvent, error) {
+	return s.ListFocusEventsFiltered(models.FocusEventFilter{Limit: limit})
+}
+
+func (s *Store) ListFocusEventsFiltered(filter models.FocusEventFilter) ([]models.FocusEvent, error) {
+	limit := filter.Limit
 	if limit <= 0 {
 		limit = 200
 	}
-	rows, err := s.db.Query(
-		`SELECT id, ts_ms, app_name, COALESCE(bundle_id, ''), COALESCE(pid, 0), COALESCE(window_title, ''), duration_ms
-		 FROM focus_events ORDER BY ts_ms DESC, id DESC LIMIT ?`,
-		limit,
-	)
+	where := []string{}
+	args := []any{}
+	if filter.AppName != "" {
+		where = append(where, "app_name = ?")
+		args = append(args, filter.AppName)
+	}
+	if filter.BundleID != "" {
+		where = append(where, "bundle_id = ?")
+		args = append(args, filter.BundleID)
+	}
+	if filter.SinceMs > 0 {
+		where = append(where, "ts_ms >= ?")
+		args = append(args, filter.SinceMs)
+	}
+	if filter.UntilMs > 0 {
+		where = append(where, "ts_ms <= ?")
+		args = append(args, filter.UntilMs)
+	}
+
+	query := `SELECT id, ts_ms, app_name, COALESCE(bundle_id, ''), COALESCE(pid, 0), COALESCE(window_title, ''), duration_ms FROM focus_events`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY ts_ms DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("list focus events: %w", err)
 	}
@@ -772,6 +1325,39 @@ func (s *Store) FocusMetrics(windowMs int64) (models.FocusMetrics, error) {
 	}, nil
 }
 
+// DeleteFocusEventsBefore removes focus events and focus state snapshots
+// recorded before beforeMs, or all of them when beforeMs is zero. Decisions
+// and memory are left untouched.
+func (s *Store) DeleteFocusEventsBefore(beforeMs int64) (models.FocusPurgeResult, error) {
+	result := models.FocusPurgeResult{BeforeMs: beforeMs}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("begin focus purge: %w", err)
+	}
+	where := ""
+	args := []any{}
+	if beforeMs > 0 {
+		where = " WHERE ts_ms < ?"
+		args = append(args, beforeMs)
+	}
+	res, err := tx.Exec(`DELETE FROM focus_events`+where, args...)
+	if err != nil {
+		_ = tx.Rollback()
+		return result, fmt.Errorf("delete focus events: %w", err)
+	}
+	result.EventsDeleted, _ = res.RowsAffected()
+	res, err = tx.Exec(`DELETE FROM focus_state_snapshots`+where, args...)
+	if err != nil {
+		_ = tx.Rollback()
+		return result, fmt.Errorf("delete focus state snapshots: %w", err)
+	}
+	result.SnapshotsDeleted, _ = res.RowsAffected()
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit focus purge: %w", err)
+	}
+	return result, nil
+}
+
 func (s *Store) InsertFocusStateSnapshot(snapshot models.FocusStateSnapshot) error {
 	_, err := s.db.Exec(
 		`INSERT INTO focus_state_snapshots (ts_ms, focus_state, switch_count, no_progress_ms, focus_minutes, app_name, window_title)
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"always/core/internal/models"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "always.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.DB().Close() })
+	return store
+}
+
+func TestUserIDs(t *testing.T) {
+	store := openTestStore(t)
+	got, err := store.UserIDs()
+	if err != nil {
+		t.Fatalf("UserIDs: %v", err)
+	}
+	if want := []string{models.DefaultUserID}; !slices.Equal(got, want) {
+		t.Fatalf("UserIDs on an empty store = %v, want %v", got, want)
+	}
+
+	if err := store.ForUser("bob").UpsertSetting("timezone", "UTC"); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := store.ForUser("alice").InsertDecision(models.DecisionLogEntry{RequestID: "r1"}); err != nil {
+		t.Fatalf("insert decision: %v", err)
+	}
+	got, err = store.UserIDs()
+	if err != nil {
+		t.Fatalf("UserIDs: %v", err)
+	}
+	if want := []string{"alice", "bob", models.DefaultUserID}; !slices.Equal(got, want) {
+		t.Errorf("UserIDs = %v, want %v", got, want)
+	}
+}
+
+func TestInsertDecisionDuplicateRequestID(t *testing.T) {
+	store := openTestStore(t)
+	if err := store.InsertDecision(models.DecisionLogEntry{RequestID: "r1"}); err != nil {
+		t.Fatalf("insert decision: %v", err)
+	}
+	for _, s := range []*Store{store, store.ForUser("alice")} {
+		if err := s.InsertDecision(models.DecisionLogEntry{RequestID: "r1"}); !errors.Is(err, ErrDuplicateRequestID) {
+			t.Errorf("second insert for %s = %v, want ErrDuplicateRequestID", s.UserID(), err)
+		}
+	}
+}
+
+func TestLastAllowedAction(t *testing.T) {
+	store := openTestStore(t)
+	insert := func(requestID string, actionType models.ActionType, decision models.GatewayDecisionType, atMs int64) {
+		t.Helper()
+		err := store.InsertDecision(models.DecisionLogEntry{
+			RequestID:       requestID,
+			FinalAction:     models.Action{ActionType: actionType},
+			GatewayDecision: models.GatewayDecision{Decision: decision},
+			CreatedAtMs:     atMs,
+		})
+		if err != nil {
+			t.Fatalf("insert decision %s: %v", requestID, err)
+		}
+	}
+	insert("r1", models.ActionRestReminder, models.GatewayAllow, 1_000)
+	insert("r2", models.ActionRestReminder, models.GatewayOverride, 2_000)
+	insert("r3", models.ActionEncourage, models.GatewayAllow, 3_000)
+
+	tests := []struct {
+		sinceMs int64
+		wantMs  int64
+		wantOK  bool
+	}{
+		{0, 1_000, true},
+		{1_500, 0, false},
+	}
+	for _, tt := range tests {
+		atMs, ok, err := store.LastAllowedAction(models.ActionRestReminder, tt.sinceMs)
+		if err != nil || atMs != tt.wantMs || ok != tt.wantOK {
+			t.Errorf("LastAllowedAction since %d = %d, %v, %v; want %d, %v", tt.sinceMs, atMs, ok, err, tt.wantMs, tt.wantOK)
+		}
+	}
+	if _, ok, _ := store.ForUser("alice").LastAllowedAction(models.ActionRestReminder, 0); ok {
+		t.Error("another user's reminder counted")
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"always/core/internal/models"
+)
+
+// ListEnrichedLogs is listLogs with each decision's feedback history and
+// implicit events attached. Feedback is loaded with one query per table for
+// the whole page rather than one per decision.
+func (s *Store) ListEnrichedLogs(tag string, limit int, sinceMs int64, untilMs int64) ([]models.EnrichedLog, error) {
+	logs, err := s.listLogs(logFilter{tag: tag}, limit, sinceMs, untilMs)
+	if err != nil {
+		return nil, err
+	}
+	enriched := make([]models.EnrichedLog, len(logs))
+	if len(logs) == 0 {
+		return enriched, nil
+	}
+	index := make(map[string]int, len(logs))
+	ids := make([]any, len(logs))
+	for i, entry := range logs {
+		enriched[i] = models.EnrichedLog{
+			EventLog:       entry,
+			Feedback:       []models.FeedbackEntry{},
+			ImplicitEvents: []models.ImplicitFeedbackEvent{},
+		}
+		index[entry.RequestID] = i
+		ids[i] = entry.RequestID
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+
+	rows, err := s.db.Query(
+		`SELECT request_id, feedback, COALESCE(rating, 0), created_at_ms FROM feedback_logs
+		 WHERE request_id IN (`+placeholders+`)
+		 ORDER BY created_at_ms ASC, id ASC`,
+		ids...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query feedback logs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var reqID string
+		var entry models.FeedbackEntry
+		if err := rows.Scan(&reqID, &entry.Feedback, &entry.Rating, &entry.CreatedAtMs); err != nil {
+			return nil, fmt.Errorf("scan feedback log: %w", err)
+		}
+		i := index[reqID]
+		enriched[i].Feedback = append(enriched[i].Feedback, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+
+	implicitRows, err := s.db.Query(
+		`SELECT request_id, feedback_type, COALESCE(feedback_text, ''), COALESCE(reason_code, ''), created_at_ms
+		 FROM implicit_feedback_events
+		 WHERE request_id IN (`+placeholders+`)
+		 ORDER BY created_at_ms ASC, id ASC`,
+		ids...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query implicit feedback: %w", err)
+	}
+	defer implicitRows.Close()
+	for implicitRows.Next() {
+		var reqID string
+		var event models.ImplicitFeedbackEvent
+		if err := implicitRows.Scan(&reqID, &event.FeedbackType, &event.FeedbackText, &event.ReasonCode, &event.CreatedAtMs); err != nil {
+			return nil, fmt.Errorf("scan implicit feedback: %w", err)
+		}
+		i := index[reqID]
+		enriched[i].ImplicitEvents = append(enriched[i].ImplicitEvents, event)
+	}
+	if err := implicitRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return enriched, nil
+}
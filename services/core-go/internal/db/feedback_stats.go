@@ -0,0 +1,149 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"always/core/internal/models"
+)
+
+const (
+	// feedbackRollingWindow is how many buckets, ending at each bucket, feed
+	// its rolling acceptance rate.
+	feedbackRollingWindow = 7
+	maxFeedbackBuckets    = 1000
+)
+
+// ErrTooManyBuckets is returned when a stats range would produce more than
+// maxFeedbackBuckets buckets at the requested interval.
+var ErrTooManyBuckets = errors.New("too many buckets")
+
+// FeedbackStats buckets feedback received in [sinceMs, untilMs) by interval,
+// using calendar boundaries in loc. Empty buckets are included so the series
+// is continuous.
+func (s *Store) FeedbackStats(sinceMs, untilMs int64, interval string, loc *time.Location) (models.FeedbackStats, error) {
+	stats := models.FeedbackStats{
+		Interval:      interval,
+		SinceMs:       sinceMs,
+		UntilMs:       untilMs,
+		RollingWindow: feedbackRollingWindow,
+		Buckets:       []models.FeedbackBucket{},
+	}
+	until := time.UnixMilli(untilMs).In(loc)
+	for start := bucketStart(time.UnixMilli(sinceMs).In(loc), interval); start.Before(until); start = nextBucket(start, interval) {
+		if len(stats.Buckets) == maxFeedbackBuckets {
+			return stats, ErrTooManyBuckets
+		}
+		stats.Buckets = append(stats.Buckets, models.FeedbackBucket{
+			Start:    bucketLabel(start, interval),
+			SinceMs:  start.UnixMilli(),
+			UntilMs:  nextBucket(start, interval).UnixMilli(),
+			Counts:   map[string]int{},
+			ByAction: map[string]models.FeedbackTally{},
+		})
+	}
+
+	rows, err := s.db.Query(
+		`SELECT f.feedback, f.created_at_ms, e.final_action_json
+		 FROM feedback_logs f JOIN event_logs e ON e.request_id = f.request_id
+		 WHERE e.user_id = ? AND f.created_at_ms >= ? AND f.created_at_ms < ?
+		 ORDER BY f.created_at_ms ASC`,
+		s.userID,
+		sinceMs,
+		untilMs,
+	)
+	if err != nil {
+		return stats, fmt.Errorf("query feedback stats: %w", err)
+	}
+	defer rows.Close()
+	accepted := make([]int, len(stats.Buckets))
+	index := 0
+	for rows.Next() && len(stats.Buckets) > 0 {
+		var feedback, actionJSON string
+		var createdAtMs int64
+		if err := rows.Scan(&feedback, &createdAtMs, &actionJSON); err != nil {
+			return stats, fmt.Errorf("scan feedback stats: %w", err)
+		}
+		for index < len(stats.Buckets)-1 && createdAtMs >= stats.Buckets[index].UntilMs {
+			index++
+		}
+		bucket := &stats.Buckets[index]
+		positive := isPositiveFeedback(feedback)
+		bucket.Total++
+		bucket.Counts[feedbackTypeOf(feedback)]++
+		if action := decodeAction(actionJSON); action.ActionType != "" {
+			tally := bucket.ByAction[string(action.ActionType)]
+			tally.Total++
+			if positive {
+				tally.Accepted++
+			}
+			bucket.ByAction[string(action.ActionType)] = tally
+		}
+		if positive {
+			accepted[index]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("feedback stats rows: %w", err)
+	}
+
+	for i := range stats.Buckets {
+		bucket := &stats.Buckets[i]
+		bucket.AcceptanceRate = ratio(accepted[i], bucket.Total)
+		var windowAccepted, windowTotal int
+		for j := max(0, i-feedbackRollingWindow+1); j <= i; j++ {
+			windowAccepted += accepted[j]
+			windowTotal += stats.Buckets[j].Total
+		}
+		bucket.RollingAcceptanceRate = ratio(windowAccepted, windowTotal)
+	}
+	return stats, nil
+}
+
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+// feedbackTypeOf extracts TYPE from a stored "TYPE" or "TYPE: text" value.
+func feedbackTypeOf(feedback string) string {
+	return strings.ToUpper(strings.TrimSpace(strings.SplitN(feedback, ":", 2)[0]))
+}
+
+func bucketStart(t time.Time, interval string) time.Time {
+	switch interval {
+	case models.IntervalHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case models.IntervalWeek:
+		return isoWeekStart(t)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+func nextBucket(start time.Time, interval string) time.Time {
+	switch interval {
+	case models.IntervalHour:
+		return start.Add(time.Hour)
+	case models.IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func bucketLabel(start time.Time, interval string) string {
+	switch interval {
+	case models.IntervalHour:
+		return start.Format("2006-01-02T15:00")
+	case models.IntervalWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	default:
+		return start.Format("2006-01-02")
+	}
+}
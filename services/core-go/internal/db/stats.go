@@ -0,0 +1,414 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"always/core/internal/models"
+)
+
+const topAppsLimit = 5
+
+// DailySummary aggregates focus, intervention, and feedback activity for the
+// local calendar day containing day.
+func (s *Store) DailySummary(day time.Time) (models.DailySummary, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	summary := models.DailySummary{
+		Date:                start.Format("2006-01-02"),
+		TopApps:             []models.AppUsage{},
+		InterventionsByType: map[string]int{},
+		FocusTransitions:    map[string]int{},
+	}
+
+	appMs, totalMs, err := s.focusUsage(start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return summary, err
+	}
+	summary.FocusMinutes = float64(totalMs) / 60000
+	summary.TopApps = topApps(appMs, topAppsLimit)
+
+	counts, err := s.interventionCounts(start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return summary, err
+	}
+	summary.InterventionsByType = counts.byType
+	summary.Interventions = counts.total
+	summary.RatedCount = counts.rated
+	summary.AcceptedCount = counts.accepted
+	summary.AcceptanceRate = counts.acceptanceRate()
+
+	transitions, err := s.focusTransitions(start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return summary, err
+	}
+	summary.FocusTransitions = transitions
+	return summary, nil
+}
+
+// WeeklyTrend compares the ISO week containing now against the previous week.
+// A partial current week is compared against the same elapsed span of last week.
+func (s *Store) WeeklyTrend(now time.Time) (models.WeeklyTrend, error) {
+	currentStart := isoWeekStart(now)
+	elapsed := now.Sub(currentStart)
+	previousStart := currentStart.AddDate(0, 0, -7)
+
+	current, err := s.weekStats(currentStart, now)
+	if err != nil {
+		return models.WeeklyTrend{}, err
+	}
+	previous, err := s.weekStats(previousStart, previousStart.Add(elapsed))
+	if err != nil {
+		return models.WeeklyTrend{}, err
+	}
+	return models.WeeklyTrend{
+		Current:                 current,
+		Previous:                previous,
+		Partial:                 elapsed < 7*24*time.Hour,
+		ElapsedDays:             elapsed.Hours() / 24,
+		FocusMinutesChangePct:   percentChange(previous.FocusMinutes, current.FocusMinutes),
+		InterventionsChangePct:  percentChange(float64(previous.Interventions), float64(current.Interventions)),
+		AcceptanceRateChangePct: percentChange(previous.AcceptanceRate, current.AcceptanceRate),
+	}, nil
+}
+
+func (s *Store) weekStats(start, until time.Time) (models.WeekStats, error) {
+	year, week := start.ISOWeek()
+	stats := models.WeekStats{
+		Week:    fmt.Sprintf("%d-W%02d", year, week),
+		SinceMs: start.UnixMilli(),
+		UntilMs: until.UnixMilli(),
+	}
+	_, totalMs, err := s.focusUsage(stats.SinceMs, stats.UntilMs)
+	if err != nil {
+		return stats, err
+	}
+	stats.FocusMinutes = float64(totalMs) / 60000
+
+	counts, err := s.interventionCounts(stats.SinceMs, stats.UntilMs)
+	if err != nil {
+		return stats, err
+	}
+	stats.Interventions = counts.total
+	stats.RatedCount = counts.rated
+	stats.AcceptedCount = counts.accepted
+	stats.AcceptanceRate = counts.acceptanceRate()
+
+	var snapshots, focused int
+	if err := s.db.QueryRow(
+		`SELECT COUNT(*), COALESCE(SUM(CASE WHEN focus_state = 'FOCUSED' THEN 1 ELSE 0 END), 0)
+		 FROM focus_state_snapshots WHERE ts_ms >= ? AND ts_ms < ?`,
+		stats.SinceMs,
+		stats.UntilMs,
+	).Scan(&snapshots, &focused); err != nil {
+		return stats, fmt.Errorf("query week focus states: %w", err)
+	}
+	if snapshots > 0 {
+		stats.FocusedRatio = float64(focused) / float64(snapshots)
+	}
+	return stats, nil
+}
+
+// snapshotHoldLimit caps how long a focus-state snapshot is assumed to hold.
+// Snapshots are only written on decisions, so a long gap means nobody was
+// asking rather than that the state persisted.
+const snapshotHoldLimit = 15 * time.Minute
+
+// FocusAnalytics summarises focus activity in [sinceMs, untilMs). State
+// minutes weight each snapshot by the time until the next one, capped at
+// snapshotHoldLimit; sessions are individual focus events, numbered by
+// session_seq from local midnight in loc.
+func (s *Store) FocusAnalytics(sinceMs, untilMs int64, loc *time.Location) (models.FocusAnalytics, error) {
+	analytics := models.FocusAnalytics{
+		SinceMs:  sinceMs,
+		UntilMs:  untilMs,
+		TopApps:  []models.AppUsage{},
+		Sessions: []models.FocusEvent{},
+	}
+	endMs := untilMs
+	if nowMs := time.Now().UnixMilli(); nowMs < endMs {
+		endMs = nowMs
+	}
+
+	// Read from midnight of the first day so session_seq counts the
+	// sessions before sinceMs too.
+	since := time.UnixMilli(sinceMs).In(loc)
+	midnight := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, loc)
+	rows, err := s.db.Query(
+		`SELECT id, ts_ms, app_name, COALESCE(bundle_id, ''), COALESCE(pid, 0), COALESCE(window_title, ''), duration_ms FROM focus_events
+		 WHERE ts_ms >= ? AND ts_ms < ? ORDER BY ts_ms ASC, id ASC`,
+		midnight.UnixMilli(),
+		untilMs,
+	)
+	if err != nil {
+		return analytics, fmt.Errorf("query focus sessions: %w", err)
+	}
+	appMs := map[string]int64{}
+	var totalMs, longestMs int64
+	seq, seqDay := 0, ""
+	for rows.Next() {
+		var event models.FocusEvent
+		if err := rows.Scan(&event.ID, &event.TsMs, &event.AppName, &event.BundleID, &event.PID, &event.WindowTitle, &event.DurationMs); err != nil {
+			rows.Close()
+			return analytics, fmt.Errorf("scan focus session: %w", err)
+		}
+		if day := time.UnixMilli(event.TsMs).In(loc).Format("2006-01-02"); day != seqDay {
+			seq, seqDay = 0, day
+		}
+		seq++
+		if event.TsMs < sinceMs {
+			continue
+		}
+		event.SessionSeq = seq
+		analytics.Sessions = append(analytics.Sessions, event)
+
+		durationMs := event.DurationMs
+		if durationMs <= 0 {
+			durationMs = endMs - event.TsMs
+		}
+		if durationMs <= 0 {
+			continue
+		}
+		analytics.SessionCount++
+		appMs[event.AppName] += durationMs
+		totalMs += durationMs
+		if durationMs > longestMs {
+			longestMs = durationMs
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return analytics, fmt.Errorf("focus session rows: %w", err)
+	}
+	rows.Close()
+	if analytics.SessionCount > 0 {
+		analytics.AvgSessionMinutes = float64(totalMs) / float64(analytics.SessionCount) / 60000
+	}
+	analytics.LongestSessionMinutes = float64(longestMs) / 60000
+	analytics.TopApps = topApps(appMs, topAppsLimit)
+
+	stateMs, err := s.focusStateDurations(sinceMs, endMs)
+	if err != nil {
+		return analytics, err
+	}
+	analytics.FocusedMinutes = float64(stateMs["FOCUSED"]) / 60000
+	analytics.DistractedMinutes = float64(stateMs["DISTRACTED"]) / 60000
+	analytics.NoProgressMinutes = float64(stateMs["NO_PROGRESS"]) / 60000
+	return analytics, nil
+}
+
+// focusStateDurations attributes the time between consecutive snapshots in
+// [sinceMs, endMs) to the earlier snapshot's state.
+func (s *Store) focusStateDurations(sinceMs, endMs int64) (map[string]int64, error) {
+	rows, err := s.db.Query(
+		`SELECT ts_ms, focus_state FROM focus_state_snapshots
+		 WHERE ts_ms >= ? AND ts_ms < ? ORDER BY ts_ms ASC, id ASC`,
+		sinceMs,
+		endMs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query focus state durations: %w", err)
+	}
+	defer rows.Close()
+
+	stateMs := map[string]int64{}
+	holdMs := snapshotHoldLimit.Milliseconds()
+	var prevTs int64
+	prevState := ""
+	credit := func(untilMs int64) {
+		if prevState == "" {
+			return
+		}
+		span := untilMs - prevTs
+		if span > holdMs {
+			span = holdMs
+		}
+		if span > 0 {
+			stateMs[prevState] += span
+		}
+	}
+	for rows.Next() {
+		var tsMs int64
+		var state string
+		if err := rows.Scan(&tsMs, &state); err != nil {
+			return nil, fmt.Errorf("scan focus state duration: %w", err)
+		}
+		credit(tsMs)
+		prevTs, prevState = tsMs, state
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("focus state duration rows: %w", err)
+	}
+	credit(endMs)
+	return stateMs, nil
+}
+
+func isoWeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // Monday = 0
+	day := t.AddDate(0, 0, -offset)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// percentChange returns nil when there is no baseline to compare against.
+func percentChange(previous, current float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := (current - previous) / previous * 100
+	return &change
+}
+
+type interventionTally struct {
+	byType   map[string]int
+	total    int
+	rated    int
+	accepted int
+}
+
+func (c interventionTally) acceptanceRate() float64 {
+	if c.rated == 0 {
+		return 0
+	}
+	return float64(c.accepted) / float64(c.rated)
+}
+
+// interventionCounts tallies non-DND final actions logged in [sinceMs, untilMs)
+// and how many of them were rated and accepted.
+func (s *Store) interventionCounts(sinceMs, untilMs int64) (interventionTally, error) {
+	counts := interventionTally{byType: map[string]int{}}
+	rows, err := s.db.Query(
+		`SELECT final_action_json, COALESCE(user_feedback, '') FROM event_logs
+		 WHERE user_id = ? AND created_at_ms >= ? AND created_at_ms < ?`,
+		s.userID,
+		sinceMs,
+		untilMs,
+	)
+	if err != nil {
+		return counts, fmt.Errorf("query interventions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var actionJSON, feedback string
+		if err := rows.Scan(&actionJSON, &feedback); err != nil {
+			return counts, fmt.Errorf("scan intervention: %w", err)
+		}
+		action := decodeAction(actionJSON)
+		if action.ActionType == "" || action.ActionType == models.ActionDoNotDisturb {
+			continue
+		}
+		counts.total++
+		counts.byType[string(action.ActionType)]++
+		if feedback == "" {
+			continue
+		}
+		counts.rated++
+		if isPositiveFeedback(feedback) {
+			counts.accepted++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return counts, fmt.Errorf("intervention rows: %w", err)
+	}
+	return counts, nil
+}
+
+// focusUsage returns per-app and total focus milliseconds for events starting
+// in [sinceMs, untilMs), clamping still-open events to the window end.
+func (s *Store) focusUsage(sinceMs, untilMs int64) (map[string]int64, int64, error) {
+	rows, err := s.db.Query(
+		`SELECT ts_ms, app_name, duration_ms FROM focus_events
+		 WHERE ts_ms >= ? AND ts_ms < ? ORDER BY ts_ms ASC`,
+		sinceMs,
+		untilMs,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query focus usage: %w", err)
+	}
+	defer rows.Close()
+
+	endMs := untilMs
+	if nowMs := time.Now().UnixMilli(); nowMs < endMs {
+		endMs = nowMs
+	}
+	appMs := map[string]int64{}
+	var totalMs int64
+	for rows.Next() {
+		var tsMs, durationMs int64
+		var appName string
+		if err := rows.Scan(&tsMs, &appName, &durationMs); err != nil {
+			return nil, 0, fmt.Errorf("scan focus usage: %w", err)
+		}
+		if durationMs <= 0 {
+			durationMs = endMs - tsMs
+		}
+		if durationMs <= 0 {
+			continue
+		}
+		appMs[appName] += durationMs
+		totalMs += durationMs
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("focus usage rows: %w", err)
+	}
+	return appMs, totalMs, nil
+}
+
+// focusTransitions counts consecutive focus-state changes such as "LIGHT->FOCUSED".
+func (s *Store) focusTransitions(sinceMs, untilMs int64) (map[string]int, error) {
+	rows, err := s.db.Query(
+		`SELECT focus_state FROM focus_state_snapshots
+		 WHERE ts_ms >= ? AND ts_ms < ? ORDER BY ts_ms ASC, id ASC`,
+		sinceMs,
+		untilMs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query focus transitions: %w", err)
+	}
+	defer rows.Close()
+
+	transitions := map[string]int{}
+	previous := ""
+	for rows.Next() {
+		var state string
+		if err := rows.Scan(&state); err != nil {
+			return nil, fmt.Errorf("scan focus transition: %w", err)
+		}
+		if previous != "" && state != previous {
+			transitions[previous+"->"+state]++
+		}
+		previous = state
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("focus transition rows: %w", err)
+	}
+	return transitions, nil
+}
+
+func topApps(appMs map[string]int64, limit int) []models.AppUsage {
+	apps := make([]models.AppUsage, 0, len(appMs))
+	for name, ms := range appMs {
+		apps = append(apps, models.AppUsage{AppName: name, FocusMinutes: float64(ms) / 60000})
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		if apps[i].FocusMinutes == apps[j].FocusMinutes {
+			return apps[i].AppName < apps[j].AppName
+		}
+		return apps[i].FocusMinutes > apps[j].FocusMinutes
+	})
+	if len(apps) > limit {
+		apps = apps[:limit]
+	}
+	return apps
+}
+
+// isPositiveFeedback reports whether a stored user_feedback value ("TYPE" or
+// "TYPE: text") counts as acceptance.
+func isPositiveFeedback(feedback string) bool {
+	switch models.FeedbackType(feedbackTypeOf(feedback)) {
+	case models.FeedbackLike, models.FeedbackAdopted, models.FeedbackOpen:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"always/core/internal/models"
+)
+
+// AddTags attaches tags to the decision reqID, keeping any it already has,
+// and returns the decision's full tag set. Callers check ownership first.
+func (s *Store) AddTags(reqID string, tags []string) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin add tags: %w", err)
+	}
+	createdAt := time.Now().UnixMilli()
+	for _, tag := range tags {
+		if _, err := tx.Exec(
+			`INSERT INTO decision_tags (request_id, tag, created_at_ms) VALUES (?, ?, ?)
+			 ON CONFLICT(request_id, tag) DO NOTHING`,
+			reqID,
+			tag,
+			createdAt,
+		); err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("insert tag: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tags: %w", err)
+	}
+	return s.Tags(reqID)
+}
+
+// Tags returns the tags on reqID in alphabetical order.
+func (s *Store) Tags(reqID string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT tag FROM decision_tags WHERE request_id = ? ORDER BY tag ASC`, reqID)
+	if err != nil {
+		return nil, fmt.Errorf("query tags: %w", err)
+	}
+	defer rows.Close()
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return tags, nil
+}
+
+// ListByTag is ListLogsRange restricted to decisions tagged with tag.
+func (s *Store) ListByTag(tag string, limit int, sinceMs int64, untilMs int64) ([]models.EventLog, error) {
+	return s.listLogs(logFilter{tag: tag}, limit, sinceMs, untilMs)
+}
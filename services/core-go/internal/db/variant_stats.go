@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"always/core/internal/models"
+)
+
+// VariantStats compares the A/B policy variants of decisions logged in
+// [sinceMs, untilMs). Interventions and acceptance count non-DND actions the
+// same way the daily summary does. Variants are sorted by name, with
+// decisions made without a variant first.
+func (s *Store) VariantStats(sinceMs, untilMs int64) ([]models.VariantStats, error) {
+	rows, err := s.db.Query(
+		`SELECT policy_version, final_action_json, COALESCE(user_feedback, '') FROM event_logs
+		 WHERE user_id = ? AND created_at_ms >= ? AND created_at_ms < ?`,
+		s.userID,
+		sinceMs,
+		untilMs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query variant stats: %w", err)
+	}
+	defer rows.Close()
+	byVariant := map[string]*models.VariantStats{}
+	for rows.Next() {
+		var policyVersion, actionJSON, feedback string
+		if err := rows.Scan(&policyVersion, &actionJSON, &feedback); err != nil {
+			return nil, fmt.Errorf("scan variant stats: %w", err)
+		}
+		variant := models.PolicyVariantOf(policyVersion)
+		stats := byVariant[variant]
+		if stats == nil {
+			stats = &models.VariantStats{Variant: variant}
+			byVariant[variant] = stats
+		}
+		stats.Decisions++
+		action := decodeAction(actionJSON)
+		if action.ActionType == "" || action.ActionType == models.ActionDoNotDisturb {
+			continue
+		}
+		stats.Interventions++
+		if feedback == "" {
+			continue
+		}
+		stats.RatedCount++
+		if isPositiveFeedback(feedback) {
+			stats.AcceptedCount++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("variant stats rows: %w", err)
+	}
+
+	result := make([]models.VariantStats, 0, len(byVariant))
+	for _, stats := range byVariant {
+		stats.AcceptanceRate = ratio(stats.AcceptedCount, stats.RatedCount)
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Variant < result[j].Variant })
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package focus
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+// appFilter decides which apps may be recorded. Patterns are shell globs
+// matched case-insensitively against the bundle id, or the app name when a
+// snapshot has no bundle id.
+type appFilter struct {
+	block []string
+	allow []string
+}
+
+// ParseAppPatterns validates a comma-separated focus_app_blocklist or
+// focus_app_allowlist value. "none" and empty values yield no patterns.
+func ParseAppPatterns(raw string) ([]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, "none") {
+		return nil, nil
+	}
+	var patterns []string
+	for _, part := range strings.Split(raw, ",") {
+		pattern := strings.ToLower(strings.TrimSpace(part))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad pattern %q", part)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func (f *appFilter) allows(snapshot FocusSnapshot) bool {
+	if f == nil || len(f.block)+len(f.allow) == 0 {
+		return true
+	}
+	id := snapshot.BundleID
+	if id == "" {
+		id = snapshot.AppName
+	}
+	id = strings.ToLower(id)
+	if matchesAny(f.block, id) {
+		return false
+	}
+	return len(f.allow) == 0 || matchesAny(f.allow, id)
+}
+
+// IsMeetingApp reports whether the app matches one of the meeting patterns.
+// Unlike the recording filter it checks both the bundle id and the app name,
+// so name patterns work on platforms that report bundle ids too.
+func IsMeetingApp(patterns []string, appName, bundleID string) bool {
+	if bundleID != "" && matchesAny(patterns, strings.ToLower(bundleID)) {
+		return true
+	}
+	return appName != "" && matchesAny(patterns, strings.ToLower(appName))
+}
+
+func matchesAny(patterns []string, id string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadAppFilter re-reads focus_app_blocklist and focus_app_allowlist.
+// Invalid stored values are logged and treated as unset.
+func (m *Monitor) ReloadAppFilter() {
+	reader := settings.New(m.store)
+	filter := &appFilter{}
+	for key, target := range map[string]*[]string{
+		settings.FocusAppBlocklist: &filter.block,
+		settings.FocusAppAllowlist: &filter.allow,
+	} {
+		raw, err := reader.GetString(key, "")
+		if err != nil {
+			m.logger.Warn("load focus app filter failed", slog.String("key", key), slog.Any("error", err))
+			continue
+		}
+		patterns, err := ParseAppPatterns(raw)
+		if err != nil {
+			m.logger.Warn("invalid focus app filter", slog.String("key", key), slog.Any("error", err))
+			continue
+		}
+		*target = patterns
+	}
+	m.filter.Store(filter)
+}
+
+// enterPrivacyGap handles a snapshot of an app that must not be recorded. The
+// current event is closed at nowMs and forgotten, so time spent in the hidden
+// app is neither attributed to the previous app nor counted as a switch, and
+// the next recorded app starts a fresh event.
+func (m *Monitor) enterPrivacyGap(w eventWriter, nowMs int64) error {
+	m.mu.Lock()
+	last := m.last
+	hasLast := m.hasLast
+	m.last = models.FocusEvent{}
+	m.hasLast = false
+	m.lastWindowTitle = ""
+	m.lastTitleChange = 0
+	m.noProgress = false
+	m.mu.Unlock()
+
+	if !hasLast || last.ID == 0 || last.DurationMs > 0 {
+		return nil
+	}
+	duration := nowMs - last.TsMs
+	if duration < 0 {
+		duration = 0
+	}
+	return w.UpdateFocusDuration(last.ID, duration)
+}
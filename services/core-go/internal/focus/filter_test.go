@@ -0,0 +1,118 @@
+package focus
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"always/core/internal/settings"
+)
+
+func TestParseAppPatterns(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{raw: ""},
+		{raw: "None"},
+		{raw: "com.agilebits.*, COM.Bank.App ,", want: []string{"com.agilebits.*", "com.bank.app"}},
+		{raw: "com.[bad", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAppPatterns(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAppPatterns(%q) error = %v, want error %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseAppPatterns(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestAppFilterAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   *appFilter
+		snapshot FocusSnapshot
+		want     bool
+	}{
+		{"no filter", nil, FocusSnapshot{BundleID: "com.bank.app"}, true},
+		{"blocked by glob", &appFilter{block: []string{"com.bank.*"}}, FocusSnapshot{BundleID: "com.Bank.App"}, false},
+		{"not blocked", &appFilter{block: []string{"com.bank.*"}}, FocusSnapshot{BundleID: "com.example.editor"}, true},
+		{"app name without bundle id", &appFilter{block: []string{"1password"}}, FocusSnapshot{AppName: "1Password"}, false},
+		{"outside the allowlist", &appFilter{allow: []string{"com.example.*"}}, FocusSnapshot{BundleID: "com.other.app"}, false},
+		{"inside the allowlist", &appFilter{allow: []string{"com.example.*"}}, FocusSnapshot{BundleID: "com.example.editor"}, true},
+		{"block beats allow", &appFilter{block: []string{"com.example.vault"}, allow: []string{"com.example.*"}}, FocusSnapshot{BundleID: "com.example.vault"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.allows(tt.snapshot); got != tt.want {
+			t.Errorf("%s: allows = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMonitorSkipsFilteredApps(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		value   string
+		// wantApps are the recorded events oldest first, with their durations.
+		wantApps      []string
+		wantDurations []time.Duration
+		wantSwitches  int
+	}{
+		{
+			name:          "blocked app is a privacy gap",
+			setting:       settings.FocusAppBlocklist,
+			value:         "com.example.vault",
+			wantApps:      []string{"editor", "editor", "browser"},
+			wantDurations: []time.Duration{2 * time.Minute, 2 * time.Minute, 0},
+			wantSwitches:  1,
+		},
+		{
+			name:          "only allowlisted apps are recorded",
+			setting:       settings.FocusAppAllowlist,
+			value:         "com.example.editor",
+			wantApps:      []string{"editor", "editor"},
+			wantDurations: []time.Duration{2 * time.Minute, 2 * time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := []FocusSnapshot{
+				app("editor", 0),
+				app("vault", 2*time.Minute),
+				app("editor", 5*time.Minute),
+				app("browser", 7*time.Minute),
+				app("browser", 8*time.Minute),
+			}
+			m, store := newScriptedMonitor(t, script)
+			if err := store.UpsertSetting(tt.setting, tt.value); err != nil {
+				t.Fatalf("set %s: %v", tt.setting, err)
+			}
+			m.ReloadAppFilter()
+			for range script {
+				m.poll()
+			}
+
+			events, err := store.ListFocusEvents(0)
+			if err != nil {
+				t.Fatalf("list events: %v", err)
+			}
+			var apps []string
+			var durations []time.Duration
+			for i := len(events) - 1; i >= 0; i-- {
+				apps = append(apps, events[i].AppName)
+				durations = append(durations, time.Duration(events[i].DurationMs)*time.Millisecond)
+			}
+			if !slices.Equal(apps, tt.wantApps) || !slices.Equal(durations, tt.wantDurations) {
+				t.Errorf("recorded %v %v, want %v %v", apps, durations, tt.wantApps, tt.wantDurations)
+			}
+			if got := m.SwitchCount(); got != tt.wantSwitches {
+				t.Errorf("SwitchCount = %d, want %d", got, tt.wantSwitches)
+			}
+		})
+	}
+}
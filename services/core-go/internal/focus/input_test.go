@@ -0,0 +1,113 @@
+package focus
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"always/core/internal/settings"
+)
+
+// inputProvider is a scriptedProvider that also reports a fixed last input.
+type inputProvider struct {
+	scriptedProvider
+	lastInput time.Time
+}
+
+func (p *inputProvider) LastInput() (time.Time, error) {
+	return p.lastInput, nil
+}
+
+func withInput(snapshot FocusSnapshot, offset time.Duration) FocusSnapshot {
+	snapshot.LastInputMs = at(offset)
+	return snapshot
+}
+
+func TestInputCountsAsProgress(t *testing.T) {
+	draft := func(offset time.Duration) FocusSnapshot {
+		return titled(app("editor", offset), "draft.md")
+	}
+	tests := []struct {
+		name       string
+		script     []FocusSnapshot
+		inputReset string
+		want       bool
+	}{
+		{
+			name: "typing throughout",
+			script: []FocusSnapshot{
+				withInput(draft(0), 0),
+				withInput(draft(20*time.Minute), 20*time.Minute-30*time.Second),
+				withInput(draft(40*time.Minute), 40*time.Minute-30*time.Second),
+				withInput(draft(50*time.Minute), 50*time.Minute-30*time.Second),
+			},
+		},
+		{
+			name: "input stopped long ago",
+			script: []FocusSnapshot{
+				withInput(draft(0), 0),
+				withInput(draft(5*time.Minute), 5*time.Minute),
+				withInput(draft(30*time.Minute), 5*time.Minute),
+				withInput(draft(51*time.Minute), 5*time.Minute),
+			},
+			want: true,
+		},
+		{
+			name: "input reset turned off",
+			script: []FocusSnapshot{
+				withInput(draft(0), 0),
+				withInput(draft(20*time.Minute), 20*time.Minute),
+				withInput(draft(46*time.Minute), 46*time.Minute),
+			},
+			inputReset: "false",
+			want:       true,
+		},
+		{
+			name: "input in the future is capped at now",
+			script: []FocusSnapshot{
+				withInput(draft(0), 0),
+				withInput(draft(10*time.Minute), 2*time.Hour),
+				draft(56 * time.Minute),
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, store := newScriptedMonitor(t, tt.script)
+			if tt.inputReset != "" {
+				if err := store.UpsertSetting(settings.NoProgressInputReset, tt.inputReset); err != nil {
+					t.Fatalf("set input reset: %v", err)
+				}
+				m.ReloadInputReset()
+			}
+			for range tt.script {
+				m.poll()
+			}
+			if got, _ := m.NoProgress(); got != tt.want {
+				t.Errorf("NoProgress = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitorReadsInputSource(t *testing.T) {
+	store := openTestStore(t)
+	script := []FocusSnapshot{
+		titled(app("editor", 0), "draft.md"),
+		titled(app("editor", 46*time.Minute), "draft.md"),
+	}
+	provider := &inputProvider{
+		scriptedProvider: scriptedProvider{snapshots: script},
+		lastInput:        time.UnixMilli(at(45 * time.Minute)),
+	}
+	m := NewMonitorWithProvider(store, slog.New(slog.DiscardHandler), time.Second, provider)
+	if err := m.SetEnabled(true); err != nil {
+		t.Fatalf("enable monitor: %v", err)
+	}
+	m.poll()
+	m.poll()
+	if got, _ := m.NoProgress(); got {
+		t.Error("NoProgress = true despite input a minute ago")
+	}
+}
@@ -2,24 +2,56 @@ package focus
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"always/core/internal/db"
 	"always/core/internal/models"
+	"always/core/internal/settings"
 )
 
 const (
 	defaultPollInterval   = time.Second
+	minPollInterval       = 200 * time.Millisecond
+	maxPollInterval       = 10 * time.Second
+	pollIntervalRefresh   = 10 * time.Second
+	defaultBatteryFactor  = 3.0
+	maxBatteryFactor      = 10.0
 	defaultSwitchWindow   = 10 * time.Minute
 	defaultNoProgressHold = 45 * time.Minute
 )
 
 var ErrUnsupported = errors.New("focus monitor unsupported")
 
-const settingFocusMonitorEnabled = "focus_monitor_enabled"
+// ErrIngestDisabled is returned by Ingest while focus_ingest_enabled is off.
+var ErrIngestDisabled = errors.New("focus ingestion disabled")
+
+// OutOfOrderError rejects a batch whose snapshot at Index is older than the
+// one before it, or than the event currently open when Index is 0.
+type OutOfOrderError struct {
+	Index   int
+	TsMs    int64
+	AfterMs int64
+}
+
+func (e OutOfOrderError) Error() string {
+	return fmt.Sprintf("snapshot %d at %d is before %d", e.Index, e.TsMs, e.AfterMs)
+}
+
+// eventWriter is where handleSnapshot records events: the store itself, or a
+// db.FocusTx while a batch is ingested.
+type eventWriter interface {
+	InsertFocusEvent(models.FocusEvent) (int64, error)
+	UpdateFocusDuration(id int64, durationMs int64) error
+	UpdateFocusWindowTitle(id int64, title string) error
+}
 
 type FocusSnapshot struct {
 	TsMs        int64
@@ -27,19 +59,53 @@ type FocusSnapshot struct {
 	BundleID    string
 	PID         int
 	WindowTitle string
+	// LastInputMs is when the user last typed or moved the mouse, or zero
+	// when the source cannot tell.
+	LastInputMs int64
 }
 
-type provider interface {
+// Provider reports the frontmost app. The darwin build shells out to focusd;
+// other platforms have none, and tests can register a scripted one.
+type Provider interface {
 	Current() (FocusSnapshot, error)
 }
 
+// PowerSource is implemented by providers that can tell whether the machine
+// is running on battery, which lets the monitor poll less often there.
+type PowerSource interface {
+	OnBattery() (bool, error)
+}
+
+// InputSource is implemented by providers that can report the time of the
+// last keyboard or mouse input, so typing in one document is not mistaken for
+// a stall.
+type InputSource interface {
+	LastInput() (time.Time, error)
+}
+
 type Monitor struct {
 	store    *db.Store
 	logger   *slog.Logger
 	interval time.Duration
-	provider provider
-
-	enabled atomic.Bool
+	provider Provider
+
+	enabled      atomic.Bool
+	ingest       atomic.Bool
+	pollInterval atomic.Int64
+	onBattery    atomic.Bool
+	backoff      atomic.Bool
+	redact       atomic.Bool
+	inputReset   atomic.Bool
+	filter       atomic.Pointer[appFilter]
+	// batteryFactor holds the float64 bits of battery_poll_multiplier.
+	batteryFactor atomic.Uint64
+	// intervalChanged wakes the loop so a new poll interval applies at once
+	// rather than after a tick of the old one.
+	intervalChanged chan struct{}
+
+	// ingestMu serialises Ingest and IngestBatch so a batch is applied as a
+	// whole.
+	ingestMu sync.Mutex
 
 	mu              sync.RWMutex
 	last            models.FocusEvent
@@ -47,48 +113,318 @@ type Monitor struct {
 	lastWindowTitle string
 	switchWindow    time.Duration
 	switches        []int64
+	titleSwitches   []int64
 	lastTitleChange int64
 	noProgressHold  time.Duration
 	noProgress      bool
 }
 
+// NewMonitor builds a Monitor around the provider named by FOCUS_PROVIDER or
+// the focus_provider setting; see resolveProvider for the fallbacks.
 func NewMonitor(store *db.Store, logger *slog.Logger, interval time.Duration) *Monitor {
+	name := providerName(store)
+	prov, err := resolveProvider(name, logger)
+	if err != nil {
+		logger.Warn("focus provider unavailable", slog.String("provider", name), slog.Any("error", err))
+	}
+	return NewMonitorWithProvider(store, logger, interval, prov)
+}
+
+// NewMonitorWithProvider builds a Monitor around an explicit provider. A nil
+// provider leaves the monitor permanently disabled.
+func NewMonitorWithProvider(store *db.Store, logger *slog.Logger, interval time.Duration, prov Provider) *Monitor {
 	if interval <= 0 {
 		interval = defaultPollInterval
 	}
-	prov, err := newProvider(logger)
+	m := &Monitor{
+		store:           store,
+		logger:          logger,
+		interval:        interval,
+		provider:        prov,
+		switchWindow:    defaultSwitchWindow,
+		noProgressHold:  defaultNoProgressHold,
+		intervalChanged: make(chan struct{}, 1),
+	}
+	m.pollInterval.Store(int64(interval))
+	m.backoff.Store(true)
+	m.inputReset.Store(true)
+	m.batteryFactor.Store(math.Float64bits(defaultBatteryFactor))
+	return m
+}
+
+// ParseBatteryFactor validates a battery_poll_multiplier value.
+func ParseBatteryFactor(raw string) (float64, error) {
+	factor, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
 	if err != nil {
-		logger.Warn("focus provider unavailable", slog.Any("error", err))
+		return 0, fmt.Errorf("not a number")
 	}
-	return &Monitor{
-		store:          store,
-		logger:         logger,
-		interval:       interval,
-		provider:       prov,
-		switchWindow:   defaultSwitchWindow,
-		noProgressHold: defaultNoProgressHold,
+	// Written so NaN fails too: a NaN factor makes the poll interval
+	// negative and time.NewTicker panics on it.
+	if !(factor >= 1 && factor <= maxBatteryFactor) {
+		return 0, fmt.Errorf("must be within [1,%g]", maxBatteryFactor)
 	}
+	return factor, nil
+}
+
+// ParsePollInterval validates a focus_poll_ms value in milliseconds.
+func ParsePollInterval(raw string) (time.Duration, error) {
+	ms, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("not an integer")
+	}
+	interval := time.Duration(ms) * time.Millisecond
+	if interval < minPollInterval || interval > maxPollInterval {
+		return 0, fmt.Errorf("must be within [%d,%d]", minPollInterval.Milliseconds(), maxPollInterval.Milliseconds())
+	}
+	return interval, nil
+}
+
+// PollInterval reports the configured polling period, before any battery backoff.
+func (m *Monitor) PollInterval() time.Duration {
+	return time.Duration(m.pollInterval.Load())
+}
+
+// EffectivePollInterval is PollInterval stretched by the battery multiplier
+// while the machine is on battery and backoff is enabled.
+func (m *Monitor) EffectivePollInterval() time.Duration {
+	interval := m.PollInterval()
+	if m.onBattery.Load() && m.backoff.Load() {
+		interval = time.Duration(float64(interval) * math.Float64frombits(m.batteryFactor.Load()))
+	}
+	return interval
+}
+
+// RefreshPower re-reads the battery backoff settings and asks the provider for
+// the current power source. Providers without PowerSource count as on AC.
+func (m *Monitor) RefreshPower() {
+	reader := settings.New(m.store)
+	enabled, err := reader.GetBool(settings.BatteryBackoff, true)
+	if err != nil {
+		m.logger.Warn("load battery backoff setting failed", slog.Any("error", err))
+	} else {
+		m.backoff.Store(enabled)
+	}
+	if raw, err := reader.GetString(settings.BatteryPollFactor, ""); err != nil {
+		m.logger.Warn("load battery multiplier failed", slog.Any("error", err))
+	} else if raw == "" {
+		m.batteryFactor.Store(math.Float64bits(defaultBatteryFactor))
+	} else if factor, err := ParseBatteryFactor(raw); err == nil {
+		m.batteryFactor.Store(math.Float64bits(factor))
+	}
+
+	power, ok := m.provider.(PowerSource)
+	if !ok {
+		m.onBattery.Store(false)
+		return
+	}
+	onBattery, err := power.OnBattery()
+	if err != nil {
+		m.logger.Warn("read power source failed", slog.Any("error", err))
+		return
+	}
+	if m.onBattery.Swap(onBattery) != onBattery {
+		m.logger.Info("focus power source changed", slog.Bool("on_battery", onBattery))
+	}
+}
+
+// Status reports the monitor's collection mode and polling cadence.
+func (m *Monitor) Status() models.FocusStatus {
+	return models.FocusStatus{
+		Enabled:                 m.Enabled(),
+		Polling:                 m.polling(),
+		IngestEnabled:           m.ingest.Load(),
+		PollIntervalMs:          m.PollInterval().Milliseconds(),
+		EffectivePollIntervalMs: m.EffectivePollInterval().Milliseconds(),
+		OnBattery:               m.onBattery.Load(),
+		BatteryBackoff:          m.backoff.Load(),
+		BatteryMultiplier:       math.Float64frombits(m.batteryFactor.Load()),
+		RedactWindowTitles:      m.redact.Load(),
+	}
+}
+
+// SetPollInterval changes the polling period; a running loop picks it up
+// straight away. Non-positive values restore the startup interval.
+func (m *Monitor) SetPollInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = m.interval
+	}
+	if time.Duration(m.pollInterval.Swap(int64(interval))) == interval {
+		return
+	}
+	select {
+	case m.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+// reloadPollInterval re-reads focus_poll_ms so edits made directly in the
+// database also take effect without a restart.
+func (m *Monitor) reloadPollInterval() {
+	raw, err := settings.New(m.store).GetString(settings.FocusPollMs, "")
+	if err != nil {
+		m.logger.Warn("load focus poll interval failed", slog.Any("error", err))
+		return
+	}
+	if raw == "" {
+		m.SetPollInterval(0)
+		return
+	}
+	interval, err := ParsePollInterval(raw)
+	if err != nil {
+		m.logger.Warn("invalid focus poll interval", slog.String("value", raw), slog.Any("error", err))
+		return
+	}
+	m.SetPollInterval(interval)
 }
 
 func (m *Monitor) Start() {
+	reader := settings.New(m.store)
+	ingest, err := reader.GetBool(settings.FocusIngestEnabled, false)
+	if err != nil {
+		m.logger.Error("load focus ingest setting failed", slog.Any("error", err))
+	}
+	m.ingest.Store(ingest)
+	redact, err := reader.GetBool(settings.RedactWindowTitles, false)
+	if err != nil {
+		m.logger.Error("load title redaction setting failed", slog.Any("error", err))
+	}
+	m.redact.Store(redact)
+	m.ReloadAppFilter()
+	m.ReloadInputReset()
 	if m.provider == nil {
+		if ingest {
+			m.loadLastEvent()
+		}
 		return
 	}
-	enabled, err := m.loadEnabledSetting()
+	enabled, err := reader.GetBool(settings.FocusMonitorEnabled, false)
 	if err != nil {
 		m.logger.Error("load focus setting failed", slog.Any("error", err))
 	}
 	m.enabled.Store(enabled)
-	if enabled {
+	if enabled || ingest {
 		m.loadLastEvent()
 	}
+	m.reloadPollInterval()
+	m.RefreshPower()
 	go m.loop()
 }
 
+// Enabled reports whether focus data is flowing, either from the OS provider
+// or from an external tracker posting through Ingest.
 func (m *Monitor) Enabled() bool {
+	return m.polling() || m.ingest.Load()
+}
+
+func (m *Monitor) polling() bool {
 	return m.provider != nil && m.enabled.Load()
 }
 
+// SetIngestEnabled toggles acceptance of externally reported snapshots.
+func (m *Monitor) SetIngestEnabled(enabled bool) {
+	previous := m.ingest.Swap(enabled)
+	if previous == enabled || m.polling() {
+		return
+	}
+	if enabled {
+		m.clearLast()
+		m.loadLastEvent()
+		return
+	}
+	m.closeCurrentEvent()
+}
+
+// Ingest records a snapshot reported by a client-side tracker, for platforms
+// where no provider exists. It goes through the same switch, duration and
+// no-progress bookkeeping as polled snapshots.
+func (m *Monitor) Ingest(snapshot FocusSnapshot) error {
+	if !m.ingest.Load() {
+		return ErrIngestDisabled
+	}
+	m.ingestMu.Lock()
+	defer m.ingestMu.Unlock()
+	m.handleSnapshot(snapshot)
+	return nil
+}
+
+// IngestBatch records snapshots a tracker collected while offline, in order
+// and in one transaction. Durations come from the snapshots' own timestamps,
+// so every snapshot needs TsMs, and timestamps may not go backwards, neither
+// within the batch nor behind the event currently open. Nothing is recorded
+// when the batch is rejected or a write fails.
+func (m *Monitor) IngestBatch(snapshots []FocusSnapshot) error {
+	if !m.ingest.Load() {
+		return ErrIngestDisabled
+	}
+	m.ingestMu.Lock()
+	defer m.ingestMu.Unlock()
+
+	m.mu.RLock()
+	afterMs := int64(0)
+	if m.hasLast {
+		afterMs = m.last.TsMs
+	}
+	m.mu.RUnlock()
+	for i, snapshot := range snapshots {
+		if snapshot.TsMs < afterMs {
+			return OutOfOrderError{Index: i, TsMs: snapshot.TsMs, AfterMs: afterMs}
+		}
+		afterMs = snapshot.TsMs
+	}
+
+	saved := m.saveState()
+	err := m.store.InFocusTx(func(tx *db.FocusTx) error {
+		for _, snapshot := range snapshots {
+			if err := m.applySnapshot(tx, snapshot); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		m.restoreState(saved)
+	}
+	return err
+}
+
+// monitorState is the in-memory bookkeeping a failed batch must roll back
+// along with its transaction.
+type monitorState struct {
+	last            models.FocusEvent
+	hasLast         bool
+	lastWindowTitle string
+	switches        []int64
+	titleSwitches   []int64
+	lastTitleChange int64
+	noProgress      bool
+}
+
+func (m *Monitor) saveState() monitorState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return monitorState{
+		last:            m.last,
+		hasLast:         m.hasLast,
+		lastWindowTitle: m.lastWindowTitle,
+		switches:        slices.Clone(m.switches),
+		titleSwitches:   slices.Clone(m.titleSwitches),
+		lastTitleChange: m.lastTitleChange,
+		noProgress:      m.noProgress,
+	}
+}
+
+func (m *Monitor) restoreState(state monitorState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.last = state.last
+	m.hasLast = state.hasLast
+	m.lastWindowTitle = state.lastWindowTitle
+	m.switches = state.switches
+	m.titleSwitches = state.titleSwitches
+	m.lastTitleChange = state.lastTitleChange
+	m.noProgress = state.noProgress
+}
+
 func (m *Monitor) SetEnabled(enabled bool) error {
 	if m.provider == nil {
 		m.enabled.Store(false)
@@ -127,6 +463,9 @@ func (m *Monitor) Current() (models.FocusCurrent, bool, error) {
 	if title == "" {
 		title = event.WindowTitle
 	}
+	if m.redact.Load() || IsRedactedTitle(title) {
+		title = ""
+	}
 	return models.FocusCurrent{
 		TsMs:         event.TsMs,
 		AppName:      event.AppName,
@@ -137,31 +476,116 @@ func (m *Monitor) Current() (models.FocusCurrent, bool, error) {
 	}, true, nil
 }
 
+// State derives the current focus state from the live metrics. It reports
+// false when the monitor is disabled or has not seen an app yet.
+func (m *Monitor) State() (string, bool, error) {
+	current, ok, err := m.Current()
+	if err != nil || !ok {
+		return "", false, err
+	}
+	threshold, err := LoadTitleSwitchThreshold(m.store)
+	if err != nil {
+		return "", false, err
+	}
+	noProgress, noProgressDuration := m.NoProgress()
+	return DeriveState(Metrics{
+		FocusMinutes:       current.FocusMinutes,
+		SwitchCount:        m.SwitchCount(),
+		TitleSwitchCount:   m.TitleSwitchCount(),
+		NoProgress:         noProgress,
+		NoProgressDuration: noProgressDuration,
+	}, threshold), true, nil
+}
+
 func (m *Monitor) loop() {
-	ticker := time.NewTicker(m.interval)
+	interval := m.EffectivePollInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
-
-	for range ticker.C {
-		if !m.Enabled() {
-			continue
+	refresh := time.NewTicker(pollIntervalRefresh)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.poll()
+		case <-refresh.C:
+			m.reloadPollInterval()
+			m.RefreshPower()
+		case <-m.intervalChanged:
 		}
-		snapshot, err := m.provider.Current()
-		if err != nil {
-			m.logger.Warn("focus poll failed", slog.Any("error", err))
-			continue
+		if next := m.EffectivePollInterval(); next != interval {
+			ticker.Stop()
+			ticker = time.NewTicker(next)
+			interval = next
 		}
-		if snapshot.AppName == "" {
-			continue
+	}
+}
+
+func (m *Monitor) poll() {
+	if !m.polling() {
+		return
+	}
+	snapshot, err := m.provider.Current()
+	if err != nil {
+		m.logger.Warn("focus poll failed", slog.Any("error", err))
+		return
+	}
+	if snapshot.AppName == "" {
+		return
+	}
+	if source, ok := m.provider.(InputSource); ok && snapshot.LastInputMs == 0 {
+		if at, err := source.LastInput(); err != nil {
+			m.logger.Debug("read last input failed", slog.Any("error", err))
+		} else {
+			snapshot.LastInputMs = at.UnixMilli()
 		}
-		m.handleSnapshot(snapshot)
 	}
+	m.handleSnapshot(snapshot)
+}
+
+// ReloadInputReset re-reads no_progress_input_reset, which lets recent input
+// count as progress. It defaults to on.
+func (m *Monitor) ReloadInputReset() {
+	enabled, err := settings.New(m.store).GetBool(settings.NoProgressInputReset, true)
+	if err != nil {
+		m.logger.Warn("load no-progress input setting failed", slog.Any("error", err))
+		return
+	}
+	m.inputReset.Store(enabled)
+}
+
+// inputMs is when snapshot last saw input, capped at nowMs, or zero when it
+// is unknown or no_progress_input_reset is off.
+func (m *Monitor) inputMs(snapshot FocusSnapshot, nowMs int64) int64 {
+	if !m.inputReset.Load() || snapshot.LastInputMs <= 0 {
+		return 0
+	}
+	return min(snapshot.LastInputMs, nowMs)
 }
 
 func (m *Monitor) handleSnapshot(snapshot FocusSnapshot) {
+	if err := m.applySnapshot(m.store, snapshot); err != nil {
+		m.logger.Error("record focus snapshot failed", slog.Any("error", err))
+	}
+}
+
+// applySnapshot runs the switch, duration and no-progress bookkeeping for one
+// snapshot, writing through w and stopping at the first failed write.
+func (m *Monitor) applySnapshot(w eventWriter, snapshot FocusSnapshot) error {
 	nowMs := snapshot.TsMs
 	if nowMs == 0 {
 		nowMs = time.Now().UnixMilli()
 	}
+	if !m.filter.Load().allows(snapshot) {
+		return m.enterPrivacyGap(w, nowMs)
+	}
+	if m.redact.Load() {
+		snapshot.WindowTitle = RedactTitle(snapshot.WindowTitle)
+	}
+	inputMs := m.inputMs(snapshot, nowMs)
+	if m.unchanged(snapshot, nowMs, inputMs) {
+		return nil
+	}
 
 	m.mu.Lock()
 	last := m.last
@@ -180,10 +604,20 @@ func (m *Monitor) handleSnapshot(snapshot FocusSnapshot) {
 		m.lastTitleChange = nowMs
 		m.noProgress = false
 	}
+	// Input counts as progress too, so long stretches of writing in one
+	// document are not flagged just because the title never changes.
+	if inputMs > m.lastTitleChange {
+		m.lastTitleChange = inputMs
+		m.noProgress = false
+	}
 
 	same := hasLast && sameApp(snapshot, last)
 	var updateTitleID int64
 	var updateTitle string
+	if titleChanged && same && prevTitle != "" {
+		m.titleSwitches = append(m.titleSwitches, nowMs)
+	}
+	m.titleSwitches = pruneBefore(m.titleSwitches, nowMs-m.switchWindow.Milliseconds())
 	if titleChanged && same && last.ID != 0 {
 		updateTitleID = last.ID
 		updateTitle = currentTitle
@@ -205,13 +639,13 @@ func (m *Monitor) handleSnapshot(snapshot FocusSnapshot) {
 	m.mu.Unlock()
 
 	if updateTitleID != 0 {
-		if err := m.store.UpdateFocusWindowTitle(updateTitleID, updateTitle); err != nil {
-			m.logger.Error("update focus window title failed", slog.Any("error", err))
+		if err := w.UpdateFocusWindowTitle(updateTitleID, updateTitle); err != nil {
+			return err
 		}
 	}
 
 	if hasLast && same {
-		return
+		return nil
 	}
 
 	if hasLast && last.ID != 0 {
@@ -219,8 +653,8 @@ func (m *Monitor) handleSnapshot(snapshot FocusSnapshot) {
 		if duration < 0 {
 			duration = 0
 		}
-		if err := m.store.UpdateFocusDuration(last.ID, duration); err != nil {
-			m.logger.Error("update focus duration failed", slog.Any("error", err))
+		if err := w.UpdateFocusDuration(last.ID, duration); err != nil {
+			return err
 		}
 	}
 
@@ -232,10 +666,9 @@ func (m *Monitor) handleSnapshot(snapshot FocusSnapshot) {
 		WindowTitle: snapshotTitle,
 		DurationMs:  0,
 	}
-	id, err := m.store.InsertFocusEvent(newEvent)
+	id, err := w.InsertFocusEvent(newEvent)
 	if err != nil {
-		m.logger.Error("insert focus event failed", slog.Any("error", err))
-		return
+		return err
 	}
 	newEvent.ID = id
 
@@ -243,6 +676,28 @@ func (m *Monitor) handleSnapshot(snapshot FocusSnapshot) {
 	m.last = newEvent
 	m.hasLast = true
 	m.mu.Unlock()
+	return nil
+}
+
+// unchanged reports whether snapshot repeats the current app and title and
+// would leave all bookkeeping as it is: no new input, no title switch due for
+// pruning and no no-progress transition pending. Those ticks, the common case
+// under fast polling, are settled under the read lock without touching the
+// store. The open event's duration is computed when the app changes, so
+// skipping them does not affect it.
+func (m *Monitor) unchanged(snapshot FocusSnapshot, nowMs int64, inputMs int64) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.hasLast || m.lastTitleChange == 0 || inputMs > m.lastTitleChange || !sameApp(snapshot, m.last) {
+		return false
+	}
+	if snapshot.WindowTitle != "" && snapshot.WindowTitle != m.lastWindowTitle {
+		return false
+	}
+	if len(m.titleSwitches) > 0 && m.titleSwitches[0] < nowMs-m.switchWindow.Milliseconds() {
+		return false
+	}
+	return m.noProgress || nowMs-m.lastTitleChange < m.noProgressHold.Milliseconds()
 }
 
 func (m *Monitor) SwitchCount() int {
@@ -251,6 +706,14 @@ func (m *Monitor) SwitchCount() int {
 	return len(m.switches)
 }
 
+// TitleSwitchCount is the number of window title changes within the same app
+// over the switch window.
+func (m *Monitor) TitleSwitchCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.titleSwitches)
+}
+
 func (m *Monitor) NoProgress() (bool, time.Duration) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -265,28 +728,16 @@ func (m *Monitor) NoProgress() (bool, time.Duration) {
 }
 
 func (m *Monitor) pruneSwitchesLocked(nowMs int64) {
-	if len(m.switches) == 0 {
-		return
-	}
-	cutoff := nowMs - m.switchWindow.Milliseconds()
-	idx := 0
-	for idx < len(m.switches) && m.switches[idx] < cutoff {
-		idx++
-	}
-	if idx > 0 {
-		m.switches = m.switches[idx:]
-	}
+	m.switches = pruneBefore(m.switches, nowMs-m.switchWindow.Milliseconds())
 }
 
-func (m *Monitor) loadEnabledSetting() (bool, error) {
-	value, ok, err := m.store.GetSetting(settingFocusMonitorEnabled)
-	if err != nil {
-		return false, err
-	}
-	if !ok {
-		return false, nil
+// pruneBefore drops the leading timestamps older than cutoff.
+func pruneBefore(timestamps []int64, cutoff int64) []int64 {
+	idx := 0
+	for idx < len(timestamps) && timestamps[idx] < cutoff {
+		idx++
 	}
-	return value == "true", nil
+	return timestamps[idx:]
 }
 
 func (m *Monitor) loadLastEvent() {
@@ -314,6 +765,24 @@ func (m *Monitor) clearLast() {
 	m.mu.Unlock()
 }
 
+// Purge deletes focus history before beforeMs (all of it when zero) and
+// forgets the in-memory current event and title if their row was removed, so
+// the next poll starts a fresh event instead of updating a deleted one.
+func (m *Monitor) Purge(beforeMs int64) (models.FocusPurgeResult, error) {
+	result, err := m.store.DeleteFocusEventsBefore(beforeMs)
+	if err != nil {
+		return result, err
+	}
+	m.mu.Lock()
+	if m.hasLast && (beforeMs <= 0 || m.last.TsMs < beforeMs) {
+		m.last = models.FocusEvent{}
+		m.hasLast = false
+		m.lastWindowTitle = ""
+	}
+	m.mu.Unlock()
+	return result, nil
+}
+
 func (m *Monitor) closeCurrentEvent() {
 	m.mu.RLock()
 	last := m.last
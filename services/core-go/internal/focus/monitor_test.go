@@ -0,0 +1,163 @@
+package focus
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"always/core/internal/settings"
+)
+
+func TestParseBatteryFactor(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want float64
+		ok   bool
+	}{
+		{raw: "1", want: 1, ok: true},
+		{raw: " 3.5 ", want: 3.5, ok: true},
+		{raw: "10", want: 10, ok: true},
+		{raw: "0.5"},
+		{raw: "11"},
+		{raw: "abc"},
+		{raw: "NaN"},
+		{raw: "nan"},
+		{raw: "Inf"},
+		{raw: "-Inf"},
+	}
+	for _, tt := range tests {
+		got, err := ParseBatteryFactor(tt.raw)
+		if tt.ok != (err == nil) {
+			t.Errorf("ParseBatteryFactor(%q) error = %v, want ok %v", tt.raw, err, tt.ok)
+			continue
+		}
+		if tt.ok && got != tt.want {
+			t.Errorf("ParseBatteryFactor(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestMonitorTitleSwitchCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		script []FocusSnapshot
+		want   int
+	}{
+		{
+			name: "tab flipping in one app",
+			script: []FocusSnapshot{
+				titled(app("browser", 0), "a"),
+				titled(app("browser", time.Minute), "b"),
+				titled(app("browser", 2*time.Minute), "c"),
+				titled(app("browser", 3*time.Minute), "a"),
+			},
+			want: 3,
+		},
+		{
+			name: "first title is not a switch",
+			script: []FocusSnapshot{
+				app("browser", 0),
+				titled(app("browser", time.Minute), "a"),
+			},
+			want: 0,
+		},
+		{
+			name: "app switches are counted separately",
+			script: []FocusSnapshot{
+				titled(app("browser", 0), "a"),
+				titled(app("editor", time.Minute), "b"),
+				titled(app("browser", 2*time.Minute), "c"),
+			},
+			want: 0,
+		},
+		{
+			name: "flips outside the window are pruned",
+			script: []FocusSnapshot{
+				titled(app("browser", 0), "a"),
+				titled(app("browser", time.Minute), "b"),
+				titled(app("browser", 2*time.Minute), "c"),
+				titled(app("browser", 13*time.Minute), "c"),
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newScriptedMonitor(t, tt.script)
+			for range tt.script {
+				m.poll()
+			}
+			if got := m.TitleSwitchCount(); got != tt.want {
+				t.Errorf("TitleSwitchCount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTitleSwitchThreshold(t *testing.T) {
+	store := openTestStore(t)
+	for _, tt := range []struct {
+		value string
+		want  int
+	}{
+		{"", DefaultTitleSwitchThreshold},
+		{"6", 6},
+		{"0", DefaultTitleSwitchThreshold},
+		{"-2", DefaultTitleSwitchThreshold},
+		{"many", DefaultTitleSwitchThreshold},
+	} {
+		if tt.value != "" {
+			if err := store.UpsertSetting(settings.TitleSwitchThreshold, tt.value); err != nil {
+				t.Fatalf("set %q: %v", tt.value, err)
+			}
+		}
+		got, err := LoadTitleSwitchThreshold(store)
+		if err != nil || got != tt.want {
+			t.Errorf("LoadTitleSwitchThreshold with %q = %d, %v; want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+// countingProvider reports the same app on every poll and counts the polls.
+type countingProvider struct {
+	polls atomic.Int64
+}
+
+func (p *countingProvider) Current() (FocusSnapshot, error) {
+	p.polls.Add(1)
+	return FocusSnapshot{AppName: "editor", BundleID: "com.example.editor"}, nil
+}
+
+func TestMonitorLoopAdaptsToPollInterval(t *testing.T) {
+	store := openTestStore(t)
+	provider := &countingProvider{}
+	m := NewMonitorWithProvider(store, slog.New(slog.DiscardHandler), maxPollInterval, provider)
+	if err := m.SetEnabled(true); err != nil {
+		t.Fatalf("enable monitor: %v", err)
+	}
+	go m.loop()
+
+	time.Sleep(300 * time.Millisecond)
+	if got := provider.polls.Load(); got != 0 {
+		t.Fatalf("polled %d times within 300ms at a %v interval", got, maxPollInterval)
+	}
+
+	// Lowering the interval applies without waiting out the old 10s tick.
+	m.SetPollInterval(minPollInterval)
+	deadline := time.Now().Add(3 * time.Second)
+	for provider.polls.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := provider.polls.Load(); got < 3 {
+		t.Fatalf("polled %d times after switching to %v, want at least 3", got, minPollInterval)
+	}
+
+	m.SetPollInterval(maxPollInterval)
+	time.Sleep(50 * time.Millisecond)
+	settled := provider.polls.Load()
+	time.Sleep(3 * minPollInterval)
+	if got := provider.polls.Load(); got != settled {
+		t.Errorf("polled %d more times after raising the interval to %v", got-settled, maxPollInterval)
+	}
+}
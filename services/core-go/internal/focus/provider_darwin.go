@@ -9,6 +9,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type cmdProvider struct {
@@ -24,7 +27,7 @@ type cmdOutput struct {
 	WindowTitle string `json:"window_title"`
 }
 
-func newProvider(logger *slog.Logger) (provider, error) {
+func newProvider(logger *slog.Logger) (Provider, error) {
 	binaryPath, err := ensureFocusBinary(logger)
 	if err != nil {
 		return nil, err
@@ -51,6 +54,38 @@ func (c *cmdProvider) Current() (FocusSnapshot, error) {
 	}, nil
 }
 
+// OnBattery parses `pmset -g batt`, whose first line names the active power
+// source, e.g. "Now drawing from 'Battery Power'".
+func (c *cmdProvider) OnBattery() (bool, error) {
+	output, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return false, fmt.Errorf("pmset failed: %w", err)
+	}
+	return strings.Contains(string(output), "'Battery Power'"), nil
+}
+
+// LastInput derives the last input time from HIDIdleTime, the nanoseconds
+// since the last keyboard or mouse event reported by `ioreg -c IOHIDSystem`.
+func (c *cmdProvider) LastInput() (time.Time, error) {
+	output, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ioreg failed: %w", err)
+	}
+	const key = `"HIDIdleTime" = `
+	for _, line := range strings.Split(string(output), "\n") {
+		idx := strings.Index(line, key)
+		if idx < 0 {
+			continue
+		}
+		idleNs, err := strconv.ParseInt(strings.TrimSpace(line[idx+len(key):]), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse HIDIdleTime: %w", err)
+		}
+		return time.Now().Add(-time.Duration(idleNs)), nil
+	}
+	return time.Time{}, fmt.Errorf("HIDIdleTime not reported")
+}
+
 func ensureFocusBinary(logger *slog.Logger) (string, error) {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -4,12 +4,6 @@ package focus
 
 import "log/slog"
 
-type unsupportedProvider struct{}
-
-func newProvider(_ *slog.Logger) (provider, error) {
+func newProvider(_ *slog.Logger) (Provider, error) {
 	return nil, ErrUnsupported
 }
-
-func (unsupportedProvider) Current() (FocusSnapshot, error) {
-	return FocusSnapshot{}, ErrUnsupported
-}
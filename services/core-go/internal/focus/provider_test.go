@@ -0,0 +1,143 @@
+package focus
+
+import (
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"always/core/internal/db"
+)
+
+// scriptedProvider replays a fixed sequence of snapshots, one per Current
+// call, and keeps returning the last one once the script runs out.
+type scriptedProvider struct {
+	snapshots []FocusSnapshot
+	next      int
+}
+
+func (p *scriptedProvider) Current() (FocusSnapshot, error) {
+	if len(p.snapshots) == 0 {
+		return FocusSnapshot{}, nil
+	}
+	snapshot := p.snapshots[min(p.next, len(p.snapshots)-1)]
+	p.next++
+	return snapshot, nil
+}
+
+func openTestStore(t *testing.T) *db.Store {
+	t.Helper()
+	store, err := db.Open(filepath.Join(t.TempDir(), "always.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.DB().Close() })
+	return store
+}
+
+// newScriptedMonitor returns an enabled monitor over script. Tests drive it
+// with poll rather than Start, so no goroutine or ticker is involved.
+func newScriptedMonitor(t *testing.T, script []FocusSnapshot) (*Monitor, *db.Store) {
+	t.Helper()
+	store := openTestStore(t)
+	m := NewMonitorWithProvider(store, slog.New(slog.DiscardHandler), time.Second, &scriptedProvider{snapshots: script})
+	if err := m.SetEnabled(true); err != nil {
+		t.Fatalf("enable monitor: %v", err)
+	}
+	return m, store
+}
+
+// baseMs is an arbitrary fixed start for scripted timelines.
+var baseMs = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC).UnixMilli()
+
+func at(offset time.Duration) int64 {
+	return baseMs + offset.Milliseconds()
+}
+
+func app(name string, offset time.Duration) FocusSnapshot {
+	return FocusSnapshot{TsMs: at(offset), AppName: name, BundleID: "com.example." + name, PID: len(name)}
+}
+
+func titled(snapshot FocusSnapshot, title string) FocusSnapshot {
+	snapshot.WindowTitle = title
+	return snapshot
+}
+
+func TestMonitorScriptedAppSwitches(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       []FocusSnapshot
+		wantSwitches int
+		// wantDurations are the stored event durations, oldest first; the
+		// open event is still 0.
+		wantDurations  []time.Duration
+		wantNoProgress bool
+	}{
+		{
+			name:          "single app",
+			script:        []FocusSnapshot{app("editor", 0), app("editor", time.Minute), app("editor", 2*time.Minute)},
+			wantSwitches:  0,
+			wantDurations: []time.Duration{0},
+		},
+		{
+			name:          "alternating apps",
+			script:        []FocusSnapshot{app("editor", 0), app("browser", 3*time.Minute), app("editor", 4*time.Minute)},
+			wantSwitches:  2,
+			wantDurations: []time.Duration{3 * time.Minute, time.Minute, 0},
+		},
+		{
+			name:          "switches outside the window are pruned",
+			script:        []FocusSnapshot{app("editor", 0), app("browser", time.Minute), app("editor", 12*time.Minute)},
+			wantSwitches:  1,
+			wantDurations: []time.Duration{time.Minute, 11 * time.Minute, 0},
+		},
+		{
+			name: "same title for too long is no progress",
+			script: []FocusSnapshot{
+				titled(app("editor", 0), "draft.md"),
+				titled(app("editor", 30*time.Minute), "draft.md"),
+				titled(app("editor", 46*time.Minute), "draft.md"),
+			},
+			wantDurations:  []time.Duration{0},
+			wantNoProgress: true,
+		},
+		{
+			name: "title change resets no progress",
+			script: []FocusSnapshot{
+				titled(app("editor", 0), "draft.md"),
+				titled(app("editor", 40*time.Minute), "notes.md"),
+				titled(app("editor", 50*time.Minute), "notes.md"),
+			},
+			wantDurations: []time.Duration{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, store := newScriptedMonitor(t, tt.script)
+			for range tt.script {
+				m.poll()
+			}
+
+			if got := m.SwitchCount(); got != tt.wantSwitches {
+				t.Errorf("SwitchCount = %d, want %d", got, tt.wantSwitches)
+			}
+			if got, _ := m.NoProgress(); got != tt.wantNoProgress {
+				t.Errorf("NoProgress = %v, want %v", got, tt.wantNoProgress)
+			}
+			events, err := store.ListFocusEvents(0)
+			if err != nil {
+				t.Fatalf("list events: %v", err)
+			}
+			if len(events) != len(tt.wantDurations) {
+				t.Fatalf("stored %d events, want %d", len(events), len(tt.wantDurations))
+			}
+			for i, want := range tt.wantDurations {
+				// Events are listed newest first.
+				event := events[len(events)-1-i]
+				if got := time.Duration(event.DurationMs) * time.Millisecond; got != want {
+					t.Errorf("event %d (%s) duration = %v, want %v", i, event.AppName, got, want)
+				}
+			}
+		})
+	}
+}
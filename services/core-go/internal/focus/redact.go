@@ -0,0 +1,44 @@
+package focus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// redactedTitlePrefix marks window titles stored as a hash because
+// redact_window_titles was on when they were recorded.
+const redactedTitlePrefix = "sha256:"
+
+// RedactTitle replaces title with a short stable hash. Equal titles hash
+// alike, so title-switch and no-progress detection keep working without the
+// text ever reaching the database.
+func RedactTitle(title string) string {
+	if title == "" || IsRedactedTitle(title) {
+		return title
+	}
+	sum := sha256.Sum256([]byte(title))
+	return redactedTitlePrefix + hex.EncodeToString(sum[:6])
+}
+
+// IsRedactedTitle reports whether title was produced by RedactTitle.
+func IsRedactedTitle(title string) bool {
+	return strings.HasPrefix(title, redactedTitlePrefix)
+}
+
+// RedactTitles reports whether window titles are being redacted.
+func (m *Monitor) RedactTitles() bool {
+	return m.redact.Load()
+}
+
+// SetRedactTitles toggles title redaction for snapshots recorded from now on.
+// The remembered title is dropped on a change so comparing a plain title with
+// a hashed one does not count as a title switch.
+func (m *Monitor) SetRedactTitles(enabled bool) {
+	if m.redact.Swap(enabled) == enabled {
+		return
+	}
+	m.mu.Lock()
+	m.lastWindowTitle = ""
+	m.mu.Unlock()
+}
@@ -0,0 +1,62 @@
+package focus
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactTitle(t *testing.T) {
+	a := RedactTitle("Inbox - alice@example.com")
+	if !IsRedactedTitle(a) || strings.Contains(a, "alice") {
+		t.Errorf("RedactTitle = %q, want a hash", a)
+	}
+	if b := RedactTitle("Inbox - alice@example.com"); b != a {
+		t.Errorf("equal titles hashed to %q and %q", a, b)
+	}
+	if b := RedactTitle("Inbox - bob@example.com"); b == a {
+		t.Errorf("different titles both hashed to %q", a)
+	}
+	if got := RedactTitle(a); got != a {
+		t.Errorf("redacting a hash again = %q, want it unchanged", got)
+	}
+	if got := RedactTitle(""); got != "" {
+		t.Errorf("RedactTitle(\"\") = %q", got)
+	}
+}
+
+func TestMonitorRedactsTitlesAtWriteTime(t *testing.T) {
+	script := []FocusSnapshot{
+		titled(app("mail", 0), "Inbox - alice@example.com"),
+		titled(app("mail", time.Minute), "Re: salary review"),
+		titled(app("browser", 2*time.Minute), "bank.example.com/statement"),
+	}
+	m, store := newScriptedMonitor(t, script)
+	m.SetRedactTitles(true)
+	for range script {
+		m.poll()
+	}
+
+	events, err := store.ListFocusEvents(0)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("stored %d events, want 2", len(events))
+	}
+	for _, event := range events {
+		if !IsRedactedTitle(event.WindowTitle) {
+			t.Errorf("%s stored title %q, want a hash", event.AppName, event.WindowTitle)
+		}
+	}
+	if got := m.TitleSwitchCount(); got != 1 {
+		t.Errorf("TitleSwitchCount = %d, want 1 from the hashed titles", got)
+	}
+	current, ok, err := m.Current()
+	if err != nil || !ok {
+		t.Fatalf("Current = %v, %v", ok, err)
+	}
+	if current.WindowTitle != "" {
+		t.Errorf("Current window title = %q, want it omitted", current.WindowTitle)
+	}
+}
@@ -0,0 +1,104 @@
+package focus
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"always/core/internal/db"
+	"always/core/internal/settings"
+)
+
+const (
+	// ProviderOS polls the platform's native tracker (focusd on macOS).
+	ProviderOS = "os"
+	// ProviderIngest runs without a poller; snapshots arrive through Ingest.
+	ProviderIngest = "ingest"
+	// ProviderNone disables focus tracking outright.
+	ProviderNone = "none"
+)
+
+// ProviderFactory builds a named provider. A nil provider with a nil error
+// means the monitor should run without polling.
+type ProviderFactory func(logger *slog.Logger) (Provider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]ProviderFactory{
+		ProviderOS:     newProvider,
+		ProviderIngest: func(*slog.Logger) (Provider, error) { return nil, nil },
+		ProviderNone:   func(*slog.Logger) (Provider, error) { return nil, ErrUnsupported },
+	}
+)
+
+// RegisterProvider adds or replaces a provider factory, e.g. a scripted fake
+// in tests. Names are case-insensitive.
+func RegisterProvider(name string, factory ProviderFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(strings.TrimSpace(name))] = factory
+}
+
+// HasProvider reports whether name is registered.
+func HasProvider(name string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
+// ProviderNames lists the registered provider names in sorted order.
+func ProviderNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// providerName picks the configured provider: FOCUS_PROVIDER wins over the
+// focus_provider setting, and an empty result means ProviderOS.
+func providerName(store *db.Store) string {
+	if name := strings.TrimSpace(os.Getenv("FOCUS_PROVIDER")); name != "" {
+		return strings.ToLower(name)
+	}
+	if store == nil {
+		return ProviderOS
+	}
+	name, err := settings.New(store).GetString(settings.FocusProvider, ProviderOS)
+	if err != nil {
+		return ProviderOS
+	}
+	return strings.ToLower(name)
+}
+
+// resolveProvider builds the named provider, falling back to the OS provider
+// when the name is unknown or its factory fails. When that fails too the
+// error is returned and the monitor stays disabled, reporting ErrUnsupported.
+func resolveProvider(name string, logger *slog.Logger) (Provider, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	fallback := registry[ProviderOS]
+	registryMu.RUnlock()
+
+	if !ok {
+		logger.Warn("unknown focus provider, using os", slog.String("provider", name))
+		return fallback(logger)
+	}
+	prov, err := factory(logger)
+	if err == nil || name == ProviderOS || name == ProviderNone {
+		return prov, err
+	}
+	logger.Warn("focus provider failed, using os", slog.String("provider", name), slog.Any("error", err))
+	prov, osErr := fallback(logger)
+	if osErr != nil {
+		return nil, fmt.Errorf("provider %s: %w", name, err)
+	}
+	return prov, nil
+}
@@ -0,0 +1,118 @@
+package focus
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"always/core/internal/db"
+	"always/core/internal/settings"
+)
+
+// ScoreWeights tune how much each component contributes to the focus score.
+// Only their ratios matter; they are normalised by their sum.
+type ScoreWeights struct {
+	Focus      float64 `json:"focus"`
+	Switches   float64 `json:"switch"`
+	Titles     float64 `json:"title"`
+	NoProgress float64 `json:"no_progress"`
+}
+
+var DefaultScoreWeights = ScoreWeights{
+	Focus:      0.4,
+	Switches:   0.25,
+	Titles:     0.15,
+	NoProgress: 0.2,
+}
+
+// Score maps the metrics onto 0–100, higher meaning more focused. Each
+// component is scaled against the same thresholds DeriveState uses:
+//
+//   - focus:       focus_minutes / FocusedMinutes, capped at 1
+//   - switch:      1 - switch_count / DistractedSwitches, floored at 0
+//   - title:       1 - title_switch_count / titleSwitchThreshold, floored at 0
+//   - no_progress: 1 - no_progress_duration / NoProgressThreshold, floored at 0
+//
+// and the components are averaged using the weights.
+func Score(m Metrics, weights ScoreWeights, titleSwitchThreshold int) int {
+	if titleSwitchThreshold <= 0 {
+		titleSwitchThreshold = DefaultTitleSwitchThreshold
+	}
+	total := weights.Focus + weights.Switches + weights.Titles + weights.NoProgress
+	if total <= 0 {
+		weights = DefaultScoreWeights
+		total = weights.Focus + weights.Switches + weights.Titles + weights.NoProgress
+	}
+
+	focusMinutes := m.FocusMinutes
+	if math.IsNaN(focusMinutes) {
+		focusMinutes = 0
+	}
+	noProgress := 0.0
+	if m.NoProgress {
+		noProgress = m.NoProgressDuration.Minutes() / NoProgressThreshold.Minutes()
+	}
+	components := weights.Focus*unitClamp(focusMinutes/FocusedMinutes) +
+		weights.Switches*(1-unitClamp(float64(m.SwitchCount)/DistractedSwitches)) +
+		weights.Titles*(1-unitClamp(float64(m.TitleSwitchCount)/float64(titleSwitchThreshold))) +
+		weights.NoProgress*(1-unitClamp(noProgress))
+	return int(math.Round(100 * components / total))
+}
+
+func unitClamp(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
+
+// ParseScoreWeights parses "focus=0.4,switch=0.25,title=0.15,no_progress=0.2".
+// Omitted components keep their default weight.
+func ParseScoreWeights(value string) (ScoreWeights, error) {
+	weights := DefaultScoreWeights
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			return ScoreWeights{}, fmt.Errorf("invalid weight %q", part)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || weight < 0 || math.IsInf(weight, 0) {
+			return ScoreWeights{}, fmt.Errorf("invalid weight %q", part)
+		}
+		switch strings.TrimSpace(name) {
+		case "focus":
+			weights.Focus = weight
+		case "switch":
+			weights.Switches = weight
+		case "title":
+			weights.Titles = weight
+		case "no_progress":
+			weights.NoProgress = weight
+		default:
+			return ScoreWeights{}, fmt.Errorf("unknown weight %q", name)
+		}
+	}
+	if weights.Focus+weights.Switches+weights.Titles+weights.NoProgress <= 0 {
+		return ScoreWeights{}, fmt.Errorf("weights must not all be zero")
+	}
+	return weights, nil
+}
+
+// LoadScoreWeights reads the focus_score_weights setting, falling back to the
+// defaults when unset or invalid.
+func LoadScoreWeights(store *db.Store) (ScoreWeights, error) {
+	value, err := settings.New(store).GetString(settings.FocusScoreWeights, "")
+	if err != nil {
+		return ScoreWeights{}, err
+	}
+	if value == "" {
+		return DefaultScoreWeights, nil
+	}
+	weights, err := ParseScoreWeights(value)
+	if err != nil {
+		return DefaultScoreWeights, nil
+	}
+	return weights, nil
+}
@@ -0,0 +1,62 @@
+package focus
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScore(t *testing.T) {
+	focusOnly := ScoreWeights{Focus: 1}
+	tests := []struct {
+		name    string
+		m       Metrics
+		weights ScoreWeights
+		want    int
+	}{
+		{"nothing yet", Metrics{}, DefaultScoreWeights, 60},
+		{"sustained focus", Metrics{FocusMinutes: 25}, DefaultScoreWeights, 100},
+		{"focus is capped", Metrics{FocusMinutes: 90}, DefaultScoreWeights, 100},
+		{"half focus, some switching", Metrics{FocusMinutes: 12.5, SwitchCount: 2}, DefaultScoreWeights, 74},
+		{"stuck for ten minutes", Metrics{FocusMinutes: 25, NoProgress: true, NoProgressDuration: 10 * time.Minute}, DefaultScoreWeights, 90},
+		{"no progress duration without the flag", Metrics{NoProgressDuration: 30 * time.Minute}, DefaultScoreWeights, 60},
+		{"fully distracted", Metrics{SwitchCount: 8, TitleSwitchCount: 15, NoProgress: true, NoProgressDuration: 20 * time.Minute}, DefaultScoreWeights, 0},
+		{"NaN focus minutes", Metrics{FocusMinutes: math.NaN()}, DefaultScoreWeights, 60},
+		{"focus-only weights", Metrics{FocusMinutes: 5, SwitchCount: 8}, focusOnly, 20},
+		{"all-zero weights fall back", Metrics{}, ScoreWeights{}, 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.m, tt.weights, 0); got != tt.want {
+				t.Errorf("Score(%+v) = %d, want %d", tt.m, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScoreWeights(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ScoreWeights
+		wantErr bool
+	}{
+		{value: "", want: DefaultScoreWeights},
+		{value: "focus=1, switch=0.5", want: ScoreWeights{Focus: 1, Switches: 0.5, Titles: 0.15, NoProgress: 0.2}},
+		{value: "focus=0,switch=0,title=0,no_progress=1", want: ScoreWeights{NoProgress: 1}},
+		{value: "focus=0,switch=0,title=0,no_progress=0", wantErr: true},
+		{value: "focus=-1", wantErr: true},
+		{value: "focus=Inf", wantErr: true},
+		{value: "focus", wantErr: true},
+		{value: "speed=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseScoreWeights(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseScoreWeights(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseScoreWeights(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package focus
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"always/core/internal/db"
+	"always/core/internal/settings"
+)
+
+const (
+	StateFocused    = "FOCUSED"
+	StateLight      = "LIGHT"
+	StateDistracted = "DISTRACTED"
+	StateNoProgress = "NO_PROGRESS"
+	StateMeeting    = "MEETING"
+	StateUnknown    = "UNKNOWN"
+)
+
+const (
+	// FocusedMinutes is how long a single app must hold focus to count as FOCUSED.
+	FocusedMinutes = 25.0
+	// DistractedSwitches is the app switch count within the switch window that
+	// marks the user as DISTRACTED.
+	DistractedSwitches = 8
+	// NoProgressThreshold is the minimum no-progress duration reported as
+	// NO_PROGRESS. The Monitor only raises the flag after defaultNoProgressHold,
+	// so in practice that longer hold is what gates it.
+	NoProgressThreshold = 20 * time.Minute
+	// DefaultTitleSwitchThreshold is the number of same-app window title changes
+	// within the switch window (tab flipping) that marks the user as DISTRACTED.
+	DefaultTitleSwitchThreshold = 15
+)
+
+// Metrics are the inputs to the focus state classification.
+type Metrics struct {
+	FocusMinutes       float64
+	SwitchCount        int
+	TitleSwitchCount   int
+	NoProgress         bool
+	NoProgressDuration time.Duration
+	// InMeeting is set when a meeting app has focus.
+	InMeeting bool
+}
+
+// DeriveState classifies the focus metrics. A meeting wins over everything,
+// since a call is neither work to nudge nor a distraction. No progress wins
+// over churn (app switches or title flips), which wins over sustained focus. Inputs may come
+// from clients, so negative or NaN values are treated as zero. A non-positive
+// titleSwitchThreshold falls back to DefaultTitleSwitchThreshold.
+func DeriveState(m Metrics, titleSwitchThreshold int) string {
+	if math.IsNaN(m.FocusMinutes) || m.FocusMinutes < 0 {
+		m.FocusMinutes = 0
+	}
+	m.SwitchCount = max(0, m.SwitchCount)
+	m.TitleSwitchCount = max(0, m.TitleSwitchCount)
+	m.NoProgressDuration = max(0, m.NoProgressDuration)
+	if titleSwitchThreshold <= 0 {
+		titleSwitchThreshold = DefaultTitleSwitchThreshold
+	}
+
+	if m.InMeeting {
+		return StateMeeting
+	}
+	if m.NoProgress && m.NoProgressDuration >= NoProgressThreshold {
+		return StateNoProgress
+	}
+	if m.SwitchCount >= DistractedSwitches || m.TitleSwitchCount >= titleSwitchThreshold {
+		return StateDistracted
+	}
+	if m.FocusMinutes >= FocusedMinutes {
+		return StateFocused
+	}
+	return StateLight
+}
+
+// DefaultMinutesPrecision is how many decimals focus minutes are presented
+// with; MaxMinutesPrecision caps what can be configured.
+const (
+	DefaultMinutesPrecision = 1
+	MaxMinutesPrecision     = 3
+)
+
+// RoundMinutes rounds focus minutes to precision decimals for presentation.
+// Stored values keep full precision.
+func RoundMinutes(minutes float64, precision int) float64 {
+	scale := math.Pow10(precision)
+	return math.Round(minutes*scale) / scale
+}
+
+// FormatMinutes formats focus minutes with exactly precision decimals, as
+// used for signals.
+func FormatMinutes(minutes float64, precision int) string {
+	return strconv.FormatFloat(RoundMinutes(minutes, precision), 'f', precision, 64)
+}
+
+// DefaultMeetingApps are the focus_meeting_apps patterns used while the
+// setting is unset.
+const DefaultMeetingApps = "us.zoom.xos,zoom.us,com.microsoft.teams*,microsoft teams*,com.cisco.webex*,webex*,com.apple.facetime,facetime,com.tencent.meeting,腾讯会议"
+
+// LoadMeetingApps reads the focus_meeting_apps patterns, falling back to
+// DefaultMeetingApps when unset or invalid. "none" disables detection.
+func LoadMeetingApps(store *db.Store) ([]string, error) {
+	value, err := settings.New(store).GetRaw(settings.MeetingApps, DefaultMeetingApps)
+	if err != nil {
+		return nil, err
+	}
+	if patterns, err := ParseAppPatterns(value); err == nil {
+		return patterns, nil
+	}
+	return ParseAppPatterns(DefaultMeetingApps)
+}
+
+// LoadTitleSwitchThreshold reads the title_switch_threshold setting, falling
+// back to DefaultTitleSwitchThreshold when unset or invalid.
+func LoadTitleSwitchThreshold(store *db.Store) (int, error) {
+	threshold, err := settings.New(store).GetInt(settings.TitleSwitchThreshold, DefaultTitleSwitchThreshold)
+	if err != nil {
+		return 0, err
+	}
+	if threshold == 0 {
+		return DefaultTitleSwitchThreshold, nil
+	}
+	return threshold, nil
+}
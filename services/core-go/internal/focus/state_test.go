@@ -0,0 +1,123 @@
+package focus
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"always/core/internal/settings"
+)
+
+func TestDeriveStateBoundaries(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Metrics
+		want string
+	}{
+		{"exactly 25 minutes", Metrics{FocusMinutes: 25}, StateFocused},
+		{"just under 25 minutes", Metrics{FocusMinutes: 24.99}, StateLight},
+		{"exactly 8 switches", Metrics{FocusMinutes: 30, SwitchCount: 8}, StateDistracted},
+		{"7 switches", Metrics{FocusMinutes: 30, SwitchCount: 7}, StateFocused},
+		{"title flips at the threshold", Metrics{TitleSwitchCount: DefaultTitleSwitchThreshold}, StateDistracted},
+		{"title flips below the threshold", Metrics{TitleSwitchCount: DefaultTitleSwitchThreshold - 1}, StateLight},
+		{"no progress at 20 minutes", Metrics{NoProgress: true, NoProgressDuration: 20 * time.Minute}, StateNoProgress},
+		{"no progress just under 20 minutes", Metrics{NoProgress: true, NoProgressDuration: 20*time.Minute - time.Second}, StateLight},
+		{"no progress at 45 minutes", Metrics{NoProgress: true, NoProgressDuration: 45 * time.Minute, SwitchCount: 9}, StateNoProgress},
+		{"45 minutes without the flag", Metrics{NoProgressDuration: 45 * time.Minute}, StateLight},
+		{"meeting beats no progress", Metrics{InMeeting: true, NoProgress: true, NoProgressDuration: time.Hour}, StateMeeting},
+		{"negative inputs count as zero", Metrics{FocusMinutes: -30, SwitchCount: -9, TitleSwitchCount: -20, NoProgress: true, NoProgressDuration: -time.Hour}, StateLight},
+		{"NaN focus minutes count as zero", Metrics{FocusMinutes: math.NaN()}, StateLight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeriveState(tt.m, 0); got != tt.want {
+				t.Errorf("DeriveState(%+v) = %s, want %s", tt.m, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeriveStateTitleSwitchThreshold(t *testing.T) {
+	m := Metrics{TitleSwitchCount: 5}
+	if got := DeriveState(m, 5); got != StateDistracted {
+		t.Errorf("threshold 5 = %s, want %s", got, StateDistracted)
+	}
+	if got := DeriveState(m, 6); got != StateLight {
+		t.Errorf("threshold 6 = %s, want %s", got, StateLight)
+	}
+}
+
+// The monitor only flags no progress after defaultNoProgressHold, so 20
+// minutes on one title is not enough on its own.
+func TestMonitorNoProgressHold(t *testing.T) {
+	for _, tt := range []struct {
+		after time.Duration
+		want  bool
+	}{
+		{20 * time.Minute, false},
+		{defaultNoProgressHold - time.Second, false},
+		{defaultNoProgressHold, true},
+	} {
+		m, _ := newScriptedMonitor(t, []FocusSnapshot{
+			titled(app("editor", 0), "draft.md"),
+			titled(app("editor", tt.after), "draft.md"),
+		})
+		m.poll()
+		m.poll()
+		if got, _ := m.NoProgress(); got != tt.want {
+			t.Errorf("after %v: NoProgress = %v, want %v", tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMinutes(t *testing.T) {
+	tests := []struct {
+		minutes   float64
+		precision int
+		want      string
+	}{
+		{12.5720166, 0, "13"},
+		{12.5720166, 1, "12.6"},
+		{12.5720166, 2, "12.57"},
+		{12.5720166, 3, "12.572"},
+		{0, 1, "0.0"},
+		{3, 2, "3.00"},
+		{0.05, 1, "0.1"},
+	}
+	for _, tt := range tests {
+		if got := FormatMinutes(tt.minutes, tt.precision); got != tt.want {
+			t.Errorf("FormatMinutes(%v, %d) = %q, want %q", tt.minutes, tt.precision, got, tt.want)
+		}
+		if got, want := RoundMinutes(tt.minutes, tt.precision), tt.want; strconv.FormatFloat(got, 'f', tt.precision, 64) != want {
+			t.Errorf("RoundMinutes(%v, %d) = %v, want %s", tt.minutes, tt.precision, got, want)
+		}
+	}
+}
+
+func TestLoadMeetingApps(t *testing.T) {
+	defaults, _ := ParseAppPatterns(DefaultMeetingApps)
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"unset", "", defaults},
+		{"configured", "Zoom*, meet", []string{"zoom*", "meet"}},
+		{"none disables detection", "none", nil},
+		{"invalid falls back", "[", defaults},
+	}
+	for _, tt := range tests {
+		store := openTestStore(t)
+		if tt.value != "" {
+			if err := store.UpsertSetting(settings.MeetingApps, tt.value); err != nil {
+				t.Fatalf("set meeting apps: %v", err)
+			}
+		}
+		got, err := LoadMeetingApps(store)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: LoadMeetingApps = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
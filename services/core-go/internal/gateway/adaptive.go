@@ -0,0 +1,88 @@
+package gateway
+
+import (
+	"log/slog"
+	"math"
+	"time"
+
+	"always/core/internal/settings"
+)
+
+const (
+	// adaptiveWindow bounds how far back annoyance feedback is considered.
+	adaptiveWindow = 2 * time.Hour
+	// adaptiveHalfLife is how quickly a single annoyance stops counting.
+	adaptiveHalfLife = 20 * time.Minute
+	// adaptiveStep is the cooldown increase contributed by one fresh annoyance.
+	adaptiveStep       = 0.5
+	defaultAdaptiveMax = 4.0
+	// MaxAdaptiveCooldownFactor is the largest adaptive_cooldown_max accepted.
+	MaxAdaptiveCooldownFactor = 10.0
+)
+
+// AnnoyanceSource is implemented by stores that can report when the user
+// recently ignored a suggestion or said it came too often.
+type AnnoyanceSource interface {
+	AnnoyanceTimes(sinceMs int64) ([]int64, error)
+}
+
+// EagernessSource is implemented by stores that can report when the user said
+// a silent decision should have been a nudge.
+type EagernessSource interface {
+	WantedMoreTimes(sinceMs int64) ([]int64, error)
+}
+
+// cooldownFactor scales cooldown_seconds by recent annoyance feedback. Each
+// event adds adaptiveStep, decaying with adaptiveHalfLife, so repeated ignores
+// lengthen the cooldown and it relaxes back to 1 once they stop. WANTED_MORE
+// feedback subtracts the same way, down to 1. The result is capped by
+// adaptive_cooldown_max.
+func (g *Gateway) cooldownFactor(reader settings.Reader, now time.Time) float64 {
+	enabled, _ := reader.GetBool(settings.AdaptiveCooldown, true)
+	if !enabled {
+		return 1
+	}
+	source, ok := g.store.(AnnoyanceSource)
+	if !ok {
+		return 1
+	}
+	times, err := source.AnnoyanceTimes(now.Add(-adaptiveWindow).UnixMilli())
+	if err != nil {
+		g.logger.Warn("load annoyance feedback failed", slog.Any("error", err))
+		return 1
+	}
+	factor := 1 + decayedSteps(times, now)
+	if eager, ok := g.store.(EagernessSource); ok {
+		wanted, err := eager.WantedMoreTimes(now.Add(-adaptiveWindow).UnixMilli())
+		if err != nil {
+			g.logger.Warn("load wanted-more feedback failed", slog.Any("error", err))
+		} else {
+			factor = math.Max(1, factor-decayedSteps(wanted, now))
+		}
+	}
+	ceiling, _ := reader.GetFloat(settings.AdaptiveCooldownMax, defaultAdaptiveMax)
+	ceiling = math.Max(1, math.Min(ceiling, MaxAdaptiveCooldownFactor))
+	return math.Min(factor, ceiling)
+}
+
+// decayedSteps sums adaptiveStep for each event, halved every adaptiveHalfLife
+// of age.
+func decayedSteps(times []int64, now time.Time) float64 {
+	var sum float64
+	for _, atMs := range times {
+		age := now.Sub(time.UnixMilli(atMs))
+		if age < 0 {
+			age = 0
+		}
+		sum += adaptiveStep * math.Pow(0.5, age.Minutes()/adaptiveHalfLife.Minutes())
+	}
+	return sum
+}
+
+// effectiveCooldown is cooldown_seconds after adaptive backoff.
+func (c Config) effectiveCooldown() float64 {
+	if c.CooldownFactor <= 0 {
+		return c.CooldownSeconds
+	}
+	return c.CooldownSeconds * c.CooldownFactor
+}
@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+// feedbackStore adds WANTED_MORE feedback to an annoyedStore.
+type feedbackStore struct {
+	annoyedStore
+	wanted []int64
+}
+
+func (s *feedbackStore) WantedMoreTimes(sinceMs int64) ([]int64, error) {
+	var recent []int64
+	for _, atMs := range s.wanted {
+		if atMs >= sinceMs {
+			recent = append(recent, atMs)
+		}
+	}
+	return recent, nil
+}
+
+func TestCooldownFactor(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+	ago := func(ds ...time.Duration) []int64 {
+		times := make([]int64, len(ds))
+		for i, d := range ds {
+			times[i] = now.Add(-d).UnixMilli()
+		}
+		return times
+	}
+	fresh := func(n int) []int64 {
+		return ago(make([]time.Duration, n)...)
+	}
+	tests := []struct {
+		name     string
+		settings map[string]string
+		ignored  []int64
+		wanted   []int64
+		want     float64
+	}{
+		{name: "no feedback", want: 1},
+		{name: "two fresh ignores", ignored: fresh(2), want: 2},
+		{name: "older ignores count for less", ignored: ago(0, adaptiveHalfLife), want: 1.75},
+		{name: "ignores outside the window", ignored: ago(3 * time.Hour), want: 1},
+		{name: "capped by default", ignored: fresh(20), want: defaultAdaptiveMax},
+		{name: "configured cap", settings: map[string]string{settings.AdaptiveCooldownMax: "2.5"}, ignored: fresh(20), want: 2.5},
+		{name: "cap above the maximum", settings: map[string]string{settings.AdaptiveCooldownMax: "50"}, ignored: fresh(40), want: MaxAdaptiveCooldownFactor},
+		{name: "disabled", settings: map[string]string{settings.AdaptiveCooldown: "false"}, ignored: fresh(4), want: 1},
+		{name: "wanted more offsets ignores", ignored: fresh(4), wanted: fresh(2), want: 2},
+		{name: "never below 1", ignored: fresh(1), wanted: fresh(6), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &feedbackStore{
+				annoyedStore: annoyedStore{memStore: newMemStore(tt.settings), times: tt.ignored},
+				wanted:       tt.wanted,
+			}
+			g := New(slog.New(slog.DiscardHandler), store)
+			got := g.cooldownFactor(settings.New(store), now)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("cooldownFactor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepeatedIgnoresLengthenCooldown(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)}
+	store := &annoyedStore{memStore: newMemStore(map[string]string{
+		settings.CooldownSeconds: "300",
+		settings.BudgetActive:    "100",
+	})}
+	g := New(slog.New(slog.DiscardHandler), store)
+	g.SetClock(clock)
+	evaluate := func() models.GatewayDecision {
+		_, decision := g.Evaluate(activeContext(), suggestion(models.ActionEncourage), EvalOptions{})
+		return decision
+	}
+
+	if d := evaluate(); d.Decision != models.GatewayAllow {
+		t.Fatalf("first suggestion = %s (%s)", d.Decision, d.Reason)
+	}
+	// Without feedback the 5 minute cooldown would be over after 6 minutes.
+	clock.Advance(time.Minute)
+	store.times = append(store.times, clock.now.UnixMilli(), clock.now.UnixMilli(), clock.now.UnixMilli())
+	clock.Advance(5 * time.Minute)
+	if d := evaluate(); d.Reason != ReasonCooldownActive {
+		t.Errorf("after three ignores = %s (%s), want %s", d.Decision, d.Reason, ReasonCooldownActive)
+	}
+
+	// Once the ignores have decayed the cooldown relaxes back.
+	clock.Advance(2 * time.Hour)
+	if d := evaluate(); d.Decision != models.GatewayAllow {
+		t.Errorf("after the ignores decayed = %s (%s), want ALLOW", d.Decision, d.Reason)
+	}
+}
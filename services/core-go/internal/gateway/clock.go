@@ -0,0 +1,40 @@
+package gateway
+
+import "time"
+
+// Clock supplies the current time to the Gateway. Tests can substitute one
+// that advances on demand to exercise budget recovery, cooldown expiry and
+// usage buckets without sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock replaces the clock the Gateway reads. Budget recovery restarts
+// from the new clock's current time, so the gap between the two clocks is not
+// counted as elapsed.
+func (g *Gateway) SetClock(clock Clock) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.clock = clock
+	now := g.now()
+	for mode := range g.lastUpdate {
+		g.lastUpdate[mode] = now
+	}
+	g.lastClockCheck = time.Time{}
+}
+
+// now reads the Gateway's clock, falling back to real time for gateways
+// built without New.
+func (g *Gateway) now() time.Time {
+	if g.clock == nil {
+		return time.Now()
+	}
+	return g.clock.Now()
+}
@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+func TestUsageBucketsAcrossClockJumps(t *testing.T) {
+	tests := []struct {
+		name       string
+		start      time.Time
+		jump       time.Duration
+		wantHourly int // suggestions counted in the hourly bucket afterwards
+		wantDaily  int
+		wantWarn   bool
+	}{
+		{
+			name:       "small step back keeps the hour",
+			start:      time.Date(2026, 3, 2, 11, 0, 30, 0, time.UTC),
+			jump:       -2 * time.Minute,
+			wantHourly: 2,
+			wantDaily:  2,
+		},
+		{
+			name:       "small step back over midnight keeps the day",
+			start:      time.Date(2026, 3, 3, 0, 1, 0, 0, time.UTC),
+			jump:       -3 * time.Minute,
+			wantHourly: 2,
+			wantDaily:  2,
+		},
+		{
+			name:       "large step back resets the hour",
+			start:      time.Date(2026, 3, 2, 11, 0, 30, 0, time.UTC),
+			jump:       -time.Hour,
+			wantHourly: 1,
+			wantDaily:  2,
+			wantWarn:   true,
+		},
+		{
+			name:       "step forward starts a new hour",
+			start:      time.Date(2026, 3, 2, 11, 0, 30, 0, time.UTC),
+			jump:       time.Hour,
+			wantHourly: 1,
+			wantDaily:  2,
+		},
+		{
+			name:       "step forward starts a new day",
+			start:      time.Date(2026, 3, 2, 23, 50, 0, 0, time.UTC),
+			jump:       time.Hour,
+			wantHourly: 1,
+			wantDaily:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, store, clock := newTestGateway(t, map[string]string{settings.CooldownSeconds: "0"})
+			var logs bytes.Buffer
+			g.logger = slog.New(slog.NewTextHandler(&logs, nil))
+			clock.now = tt.start
+
+			evaluate := func() {
+				t.Helper()
+				_, decision := g.Evaluate(activeContext(), suggestion(models.ActionEncourage), EvalOptions{})
+				if decision.Decision != models.GatewayAllow {
+					t.Fatalf("at %s: decision = %s (%s), want ALLOW", clock.now, decision.Decision, decision.Reason)
+				}
+			}
+			evaluate()
+			cost := store.usage.HourlyUsed
+			if cost <= 0 {
+				t.Fatalf("first suggestion charged %v to the hourly bucket", cost)
+			}
+			clock.Advance(tt.jump)
+			evaluate()
+
+			if got, want := store.usage.HourlyUsed, float64(tt.wantHourly)*cost; got != want {
+				t.Errorf("hourly used = %v, want %v", got, want)
+			}
+			if got, want := store.usage.DailyUsed, float64(tt.wantDaily)*cost; got != want {
+				t.Errorf("daily used = %v, want %v", got, want)
+			}
+			if warned := strings.Contains(logs.String(), "clock is behind"); warned != tt.wantWarn {
+				t.Errorf("warned = %v, want %v; logs:\n%s", warned, tt.wantWarn, logs.String())
+			}
+		})
+	}
+}
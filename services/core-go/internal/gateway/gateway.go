@@ -1,13 +1,16 @@
 package gateway
 
 import (
+	"fmt"
 	"log/slog"
-	"strconv"
+	"math"
 	"strings"
 	"sync"
 	"time"
 
+	"always/core/internal/focus"
 	"always/core/internal/models"
+	"always/core/internal/settings"
 )
 
 const (
@@ -16,21 +19,67 @@ const (
 )
 
 const (
-	settingInterventionBudget = "intervention_budget"
-	settingBudgetSilent       = "budget_silent"
-	settingBudgetLight        = "budget_light"
-	settingBudgetActive       = "budget_active"
-	settingDailyBudgetCap     = "daily_budget_cap"
-	settingHourlyBudgetCap    = "hourly_budget_cap"
-	settingCooldownSeconds    = "cooldown_seconds"
+	dayBucketLayout  = "2006-01-02"
+	hourBucketLayout = "2006-01-02-15"
+	// clockSkewTolerance is how far the wall clock may step back behind the
+	// start of the current usage bucket, as after an NTP correction, while
+	// usage is kept instead of being zeroed mid-hour. Larger steps are taken
+	// as a real clock change and the buckets follow the new time.
+	clockSkewTolerance = 5 * time.Minute
+	// clockJumpLogThreshold is the smallest backward jump worth logging.
+	clockJumpLogThreshold = time.Second
 )
 
+// costSettings maps each cost_* setting to the action type it prices.
+var costSettings = map[string]models.ActionType{
+	settings.CostRest:      models.ActionRestReminder,
+	settings.CostEncourage: models.ActionEncourage,
+	settings.CostTask:      models.ActionTaskBreakdown,
+	settings.CostReframe:   models.ActionReframe,
+}
+
+// repeatSettings maps each repeat_interval_* setting to the action type whose
+// minimum spacing it sets.
+var repeatSettings = map[string]models.ActionType{
+	settings.RepeatRest:      models.ActionRestReminder,
+	settings.RepeatEncourage: models.ActionEncourage,
+	settings.RepeatTask:      models.ActionTaskBreakdown,
+	settings.RepeatReframe:   models.ActionReframe,
+}
+
+// EvalOptions carries per-request adjustments to Evaluate.
+type EvalOptions struct {
+	// UserInitiated marks requests the user explicitly asked for, such as a
+	// typed message. They skip the cooldown and budget checks and are not
+	// charged, so chatting leaves the proactive-suggestion budget untouched.
+	UserInitiated bool
+	// RecentModelMessages are the messages the model proposed for the latest
+	// decisions of the session, newest first, for spotting repetition loops.
+	RecentModelMessages []string
+}
+
 type Config struct {
 	ModeBudgets     map[models.Mode]float64
 	RecoveryRate    float64 // points per minute
 	CooldownSeconds float64
-	HourlyCap       float64
-	DailyCap        float64
+	// CooldownFactor stretches CooldownSeconds after recent ignored or
+	// too-frequent feedback; 1 means no adaptive backoff.
+	CooldownFactor float64
+	HourlyCap      float64
+	DailyCap       float64
+	// MaxRisk is the highest risk level allowed through per mode.
+	MaxRisk map[models.Mode]models.RiskLevel
+	// DisabledActions are action types the user never wants to receive.
+	DisabledActions map[models.ActionType]bool
+	// Costs is the budget charged per action type.
+	Costs map[models.ActionType]float64
+	// UrgentBypass lets urgent actions through an active cooldown. They are
+	// still charged and still subject to the caps.
+	UrgentBypass bool
+	// RepeatIntervals is the minimum number of seconds between two allowed
+	// suggestions of the same action type; 0, the default, disables the
+	// check. Unlike the cooldown it is not cleared by feedback.
+	RepeatIntervals map[models.ActionType]float64
 }
 
 type SettingsStore interface {
@@ -42,16 +91,24 @@ type SettingsStore interface {
 type Gateway struct {
 	mu               sync.Mutex
 	logger           *slog.Logger
+	clock            Clock
 	store            SettingsStore
 	config           Config
 	currentBudget    map[models.Mode]float64
 	lastIntervention time.Time
-	lastUpdate       map[models.Mode]time.Time
-	dailyUsed        float64
-	hourlyUsed       float64
-	dayBucket        string
-	hourBucket       string
-	usageLoaded      bool
+	// lastByAction records when each action type was last allowed through.
+	lastByAction map[models.ActionType]time.Time
+	lastUpdate   map[models.Mode]time.Time
+	dailyUsed    float64
+	hourlyUsed   float64
+	dayBucket    string
+	hourBucket   string
+	usageLoaded  bool
+	// lastClockCheck is the previous bucket check, kept with its monotonic
+	// reading to detect wall-clock jumps.
+	lastClockCheck time.Time
+	// lastSnapshot is when the budget was last written to history.
+	lastSnapshot time.Time
 }
 
 func New(logger *slog.Logger, store SettingsStore) *Gateway {
@@ -59,8 +116,12 @@ func New(logger *slog.Logger, store SettingsStore) *Gateway {
 		ModeBudgets:     defaultModeBudgets(),
 		RecoveryRate:    0.5, // Recover 1 point every 2 mins
 		CooldownSeconds: 300, // 5 minutes cooldown
+		MaxRisk:         defaultMaxRisk(),
+		Costs:           defaultCosts(),
+		RepeatIntervals: map[models.ActionType]float64{},
 	}
-	now := time.Now()
+	clock := realClock{}
+	now := clock.Now()
 	current := map[models.Mode]float64{}
 	lastUpdate := map[models.Mode]time.Time{}
 	for mode, max := range cfg.ModeBudgets {
@@ -69,9 +130,11 @@ func New(logger *slog.Logger, store SettingsStore) *Gateway {
 	}
 	return &Gateway{
 		logger:        logger,
+		clock:         clock,
 		store:         store,
 		config:        cfg,
 		currentBudget: current,
+		lastByAction:  map[models.ActionType]time.Time{},
 		lastUpdate:    lastUpdate,
 	}
 }
@@ -84,6 +147,25 @@ func defaultModeBudgets() map[models.Mode]float64 {
 	}
 }
 
+func defaultCosts() map[models.ActionType]float64 {
+	return map[models.ActionType]float64{
+		models.ActionDoNotDisturb:  0,
+		models.ActionRestReminder:  2.0,
+		models.ActionEncourage:     1.5,
+		models.ActionTaskBreakdown: 3.0,
+		models.ActionReframe:       2.5,
+	}
+}
+
+// defaultMaxRisk blocks HIGH risk actions in every mode.
+func defaultMaxRisk() map[models.Mode]models.RiskLevel {
+	return map[models.Mode]models.RiskLevel{
+		models.ModeSilent: models.RiskMedium,
+		models.ModeLight:  models.RiskMedium,
+		models.ModeActive: models.RiskMedium,
+	}
+}
+
 func (g *Gateway) refreshConfigLocked() {
 	cfg := Config{
 		ModeBudgets:     defaultModeBudgets(),
@@ -91,40 +173,54 @@ func (g *Gateway) refreshConfigLocked() {
 		CooldownSeconds: g.config.CooldownSeconds,
 		HourlyCap:       g.config.HourlyCap,
 		DailyCap:        g.config.DailyCap,
-	}
-
-	if g.store != nil {
-		if value, ok, err := g.store.GetSetting(settingInterventionBudget); err == nil && ok {
-			applyInterventionBudget(cfg.ModeBudgets, value)
+		MaxRisk:         defaultMaxRisk(),
+		DisabledActions: map[models.ActionType]bool{},
+		Costs:           defaultCosts(),
+		RepeatIntervals: map[models.ActionType]float64{},
+	}
+
+	// Read errors leave the default in place, like an unset key.
+	reader := settings.New(g.store)
+	if value, _ := reader.GetString(settings.InterventionBudget, ""); value != "" {
+		applyInterventionBudget(cfg.ModeBudgets, value)
+	}
+	for key, mode := range map[string]models.Mode{
+		settings.BudgetSilent: models.ModeSilent,
+		settings.BudgetLight:  models.ModeLight,
+		settings.BudgetActive: models.ModeActive,
+	} {
+		cfg.ModeBudgets[mode], _ = reader.GetFloat(key, cfg.ModeBudgets[mode])
+	}
+	cfg.HourlyCap, _ = reader.GetFloat(settings.HourlyBudgetCap, cfg.HourlyCap)
+	cfg.DailyCap, _ = reader.GetFloat(settings.DailyBudgetCap, cfg.DailyCap)
+	cooldown, _ := reader.GetDuration(settings.CooldownSeconds, time.Second, time.Duration(cfg.CooldownSeconds*float64(time.Second)))
+	cfg.CooldownSeconds = cooldown.Seconds()
+	cfg.CooldownFactor = g.cooldownFactor(reader, g.now())
+	cfg.UrgentBypass, _ = reader.GetBool(settings.UrgentBypass, false)
+	if allow, _ := reader.GetBool(settings.AllowHighRisk, false); allow {
+		cfg.MaxRisk[models.ModeActive] = models.RiskHigh
+	}
+	for key, mode := range map[string]models.Mode{
+		settings.MaxRiskSilent: models.ModeSilent,
+		settings.MaxRiskLight:  models.ModeLight,
+		settings.MaxRiskActive: models.ModeActive,
+	} {
+		value, _ := reader.GetString(key, "")
+		if level, ok := parseRiskSetting(value); ok {
+			cfg.MaxRisk[mode] = level
 		}
-		if value, ok, err := g.store.GetSetting(settingBudgetSilent); err == nil && ok {
-			if parsed, ok := parseFloatSetting(value); ok {
-				cfg.ModeBudgets[models.ModeSilent] = parsed
-			}
-		}
-		if value, ok, err := g.store.GetSetting(settingBudgetLight); err == nil && ok {
-			if parsed, ok := parseFloatSetting(value); ok {
-				cfg.ModeBudgets[models.ModeLight] = parsed
-			}
-		}
-		if value, ok, err := g.store.GetSetting(settingBudgetActive); err == nil && ok {
-			if parsed, ok := parseFloatSetting(value); ok {
-				cfg.ModeBudgets[models.ModeActive] = parsed
-			}
-		}
-		if value, ok, err := g.store.GetSetting(settingHourlyBudgetCap); err == nil && ok {
-			if parsed, ok := parseFloatSetting(value); ok {
-				cfg.HourlyCap = parsed
-			}
-		}
-		if value, ok, err := g.store.GetSetting(settingDailyBudgetCap); err == nil && ok {
-			if parsed, ok := parseFloatSetting(value); ok {
-				cfg.DailyCap = parsed
-			}
-		}
-		if value, ok, err := g.store.GetSetting(settingCooldownSeconds); err == nil && ok {
-			if parsed, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && parsed >= 0 {
-				cfg.CooldownSeconds = float64(parsed)
+	}
+	for key, actionType := range costSettings {
+		cfg.Costs[actionType], _ = reader.GetFloat(key, cfg.Costs[actionType])
+	}
+	for key, actionType := range repeatSettings {
+		interval, _ := reader.GetDuration(key, time.Second, 0)
+		cfg.RepeatIntervals[actionType] = interval.Seconds()
+	}
+	if value, _ := reader.GetString(settings.DisabledActions, ""); value != "" {
+		if actions, err := ParseDisabledActions(value); err == nil {
+			for _, actionType := range actions {
+				cfg.DisabledActions[actionType] = true
 			}
 		}
 	}
@@ -138,7 +234,7 @@ func (g *Gateway) refreshConfigLocked() {
 			g.currentBudget[mode] = maxBudget
 		}
 		if _, ok := g.lastUpdate[mode]; !ok {
-			g.lastUpdate[mode] = time.Now()
+			g.lastUpdate[mode] = g.now()
 		}
 	}
 }
@@ -156,12 +252,12 @@ func applyInterventionBudget(budgets map[models.Mode]float64, value string) {
 	}
 }
 
-func parseFloatSetting(value string) (float64, bool) {
-	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
-	if err != nil || parsed < 0 {
-		return 0, false
+func parseRiskSetting(value string) (models.RiskLevel, bool) {
+	level := models.RiskLevel(strings.ToUpper(strings.TrimSpace(value)))
+	if !isValidRiskLevel(level) {
+		return "", false
 	}
-	return parsed, true
+	return level, true
 }
 
 func (g *Gateway) loadUsageLocked(now time.Time) {
@@ -181,10 +277,17 @@ func (g *Gateway) loadUsageLocked(now time.Time) {
 }
 
 func (g *Gateway) resetUsageBucketsLocked(now time.Time) {
-	currentDay := now.Format("2006-01-02")
-	currentHour := now.Format("2006-01-02-15")
+	g.noteClockJumpLocked(now)
+	currentDay := now.Format(dayBucketLayout)
+	currentHour := now.Format(hourBucketLayout)
 	changed := false
 
+	if g.dayBucket != currentDay && g.keepBucketLocked(dayBucketLayout, g.dayBucket, now) {
+		currentDay = g.dayBucket
+	}
+	if g.hourBucket != currentHour && g.keepBucketLocked(hourBucketLayout, g.hourBucket, now) {
+		currentHour = g.hourBucket
+	}
 	if g.dayBucket != currentDay {
 		g.dayBucket = currentDay
 		g.dailyUsed = 0
@@ -200,6 +303,39 @@ func (g *Gateway) resetUsageBucketsLocked(now time.Time) {
 	}
 }
 
+// keepBucketLocked reports whether now falls shortly before the start of the
+// stored bucket, meaning the clock stepped back within clockSkewTolerance and
+// the bucket's usage should carry on. A larger step back is logged.
+func (g *Gateway) keepBucketLocked(layout, bucket string, now time.Time) bool {
+	start, err := time.ParseInLocation(layout, bucket, now.Location())
+	if err != nil || !now.Before(start) {
+		return false
+	}
+	behind := start.Sub(now)
+	if behind <= clockSkewTolerance {
+		return true
+	}
+	g.logger.Warn("clock is behind the budget usage bucket, resetting usage",
+		slog.String("bucket", bucket),
+		slog.Duration("behind", behind),
+	)
+	return false
+}
+
+// noteClockJumpLocked logs when the wall clock moved backward since the last
+// check. time.Now carries a monotonic reading, so the monotonic elapsed time
+// minus the wall-clock elapsed time is the size of the jump.
+func (g *Gateway) noteClockJumpLocked(now time.Time) {
+	last := g.lastClockCheck
+	g.lastClockCheck = now
+	if last.IsZero() {
+		return
+	}
+	if jump := now.Sub(last) - now.Round(0).Sub(last.Round(0)); jump >= clockJumpLogThreshold {
+		g.logger.Warn("wall clock moved backward", slog.Duration("jump", jump))
+	}
+}
+
 func (g *Gateway) persistUsageLocked() {
 	if g.store == nil {
 		return
@@ -222,15 +358,29 @@ func (g *Gateway) modeMaxBudget(mode models.Mode) float64 {
 	return g.config.ModeBudgets[models.ModeLight]
 }
 
-func (g *Gateway) Evaluate(ctx models.Context, action models.Action) (models.Action, models.GatewayDecision) {
+func (g *Gateway) modeMaxRisk(mode models.Mode) models.RiskLevel {
+	if level, ok := g.config.MaxRisk[mode]; ok {
+		return level
+	}
+	return models.RiskMedium
+}
+
+func (g *Gateway) Evaluate(ctx models.Context, action models.Action, opts EvalOptions) (models.Action, models.GatewayDecision) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	now := time.Now()
+	now := g.now()
 	g.refreshConfigLocked()
 	g.loadUsageLocked(now)
 	g.replenishBudgetLocked(ctx.Mode, now)
 
+	final, decision := g.evaluateLocked(ctx, action, now, opts)
+	decision.RiskPolicy = "max_risk:" + string(g.modeMaxRisk(ctx.Mode))
+	g.snapshotBudgetLocked(now)
+	return final, decision
+}
+
+func (g *Gateway) evaluateLocked(ctx models.Context, action models.Action, now time.Time, opts EvalOptions) (models.Action, models.GatewayDecision) {
 	original := action
 	decision := models.GatewayDecision{Decision: models.GatewayAllow, Reason: "allow"}
 
@@ -238,26 +388,57 @@ func (g *Gateway) Evaluate(ctx models.Context, action models.Action) (models.Act
 	if reason, invalid := ruleInvalidAction(action); invalid {
 		return overrideAction(original, models.GatewayOverride, reason)
 	}
-	if ruleHighRisk(action) {
+	if ruleActionDisabled(action, g.config.DisabledActions) {
+		return overrideAction(original, models.GatewayOverride, ReasonActionDisabled)
+	}
+	if ruleHighRisk(action, g.modeMaxRisk(ctx.Mode)) {
 		return overrideAction(original, models.GatewayDeny, ReasonHighRiskBlocked)
 	}
 	if ruleLowQuality(action) {
 		return overrideAction(original, models.GatewayOverride, ReasonLowQualityAction)
 	}
+	if ruleRepetition(action, opts.RecentModelMessages) {
+		g.logger.Warn("model repetition",
+			slog.String("action_type", string(action.ActionType)),
+			slog.String("message", action.Message))
+		return overrideAction(original, models.GatewayOverride, ReasonModelRepetition)
+	}
+	if ruleInMeeting(ctx, action) {
+		return overrideAction(original, models.GatewayOverride, ReasonInMeeting)
+	}
 	if ruleSilentOverride(ctx, action) {
 		return overrideAction(original, models.GatewayOverride, ReasonModeSilentOverride)
 	}
 
 	// 2. Dynamic Rules (Stateful) - Only check if action is NOT DoNotDisturb
-	if action.ActionType != models.ActionDoNotDisturb {
-		cost := calculateCost(action)
-
-		// Check Cooldown
-		if g.config.CooldownSeconds > 0 && time.Since(g.lastIntervention).Seconds() < g.config.CooldownSeconds {
-			g.logger.Info("gateway cooldown active",
-				slog.Float64("since_last", time.Since(g.lastIntervention).Seconds()),
-				slog.Float64("cooldown", g.config.CooldownSeconds))
-			return overrideAction(original, models.GatewayOverride, ReasonCooldownActive)
+	if opts.UserInitiated {
+		decision.Reason = "allow: user initiated, cooldown and budget bypassed"
+	} else if action.ActionType != models.ActionDoNotDisturb {
+		cost := g.config.actionCost(action.ActionType)
+
+		// Check Cooldown, which urgent actions may skip when the bypass is on
+		cooldownBypassed := false
+		if cooldown := g.config.effectiveCooldown(); cooldown > 0 && now.Sub(g.lastIntervention).Seconds() < cooldown {
+			if !g.config.UrgentBypass || !ruleUrgent(ctx, action) {
+				g.logger.Info("gateway cooldown active",
+					slog.Float64("since_last", now.Sub(g.lastIntervention).Seconds()),
+					slog.Float64("cooldown", cooldown),
+					slog.Float64("cooldown_factor", g.config.CooldownFactor))
+				return overrideAction(original, models.GatewayOverride, ReasonCooldownActive)
+			}
+			cooldownBypassed = true
+			g.logger.Info("gateway cooldown bypassed for urgent action",
+				slog.String("action_type", string(action.ActionType)),
+				slog.Float64("since_last", now.Sub(g.lastIntervention).Seconds()))
+		}
+
+		// Check Repeat Interval
+		if remaining := g.repeatRemainingLocked(action.ActionType, now); remaining > 0 {
+			g.logger.Info("gateway action repeated too soon",
+				slog.String("action_type", string(action.ActionType)),
+				slog.Float64("remaining", remaining),
+				slog.Float64("interval", g.config.RepeatIntervals[action.ActionType]))
+			return overrideAction(original, models.GatewayOverride, ReasonActionRepeatTooSoon)
 		}
 
 		// Check Budget Caps
@@ -285,43 +466,329 @@ func (g *Gateway) Evaluate(ctx models.Context, action models.Action) (models.Act
 		// Apply Cost
 		g.currentBudget[ctx.Mode] -= cost
 		g.lastIntervention = now
+		g.lastByAction[action.ActionType] = now
 		g.hourlyUsed += cost
 		g.dailyUsed += cost
 		g.persistUsageLocked()
 		g.logger.Info("gateway intervention allowed",
 			slog.Float64("cost", cost),
 			slog.Float64("remaining", g.currentBudget[ctx.Mode]))
+		decision.CostApplied = cost
+		decision.Reason = g.allowReasonLocked(ctx.Mode, cost, cooldownBypassed)
+	} else {
+		decision.Reason = "allow: do_not_disturb, no cost"
 	}
 
 	return action, decision
 }
 
+// allowReasonLocked summarises the state every stateful check passed with,
+// e.g. "allow: budget 4.5/10.0, cost 1.5, cooldown ok, hourly 1.5/5.0".
+func (g *Gateway) allowReasonLocked(mode models.Mode, cost float64, cooldownBypassed bool) string {
+	cooldown := "cooldown ok"
+	if cooldownBypassed {
+		cooldown = "cooldown bypassed (urgent)"
+	}
+	parts := []string{
+		fmt.Sprintf("budget %.1f/%.1f", g.currentBudget[mode], g.modeMaxBudget(mode)),
+		fmt.Sprintf("cost %.1f", cost),
+		cooldown,
+	}
+	if g.config.HourlyCap > 0 {
+		parts = append(parts, fmt.Sprintf("hourly %.1f/%.1f", g.hourlyUsed, g.config.HourlyCap))
+	}
+	if g.config.DailyCap > 0 {
+		parts = append(parts, fmt.Sprintf("daily %.1f/%.1f", g.dailyUsed, g.config.DailyCap))
+	}
+	return "allow: " + strings.Join(parts, ", ")
+}
+
 func (g *Gateway) CanIntervene(ctx models.Context, cost float64) (bool, string) {
+	for _, gate := range g.InterventionGates(ctx, cost) {
+		if !gate.Passed {
+			return false, gate.Reason
+		}
+	}
+	return true, "allow"
+}
+
+// InterventionGates evaluates every stateful gate an intervention of cost
+// would face in ctx's mode, in the order Evaluate applies them, without
+// charging anything.
+func (g *Gateway) InterventionGates(ctx models.Context, cost float64) []models.GateCheck {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	now := time.Now()
+	now := g.now()
 	g.refreshConfigLocked()
 	g.loadUsageLocked(now)
 	g.replenishBudgetLocked(ctx.Mode, now)
 
-	if g.config.CooldownSeconds > 0 && time.Since(g.lastIntervention).Seconds() < g.config.CooldownSeconds {
-		return false, ReasonCooldownActive
+	cooldown := g.config.effectiveCooldown()
+	cooldownDetails := map[string]any{
+		"cooldown_seconds":           g.config.CooldownSeconds,
+		"effective_cooldown_seconds": cooldown,
+	}
+	cooldownPassed := true
+	if !g.lastIntervention.IsZero() {
+		since := now.Sub(g.lastIntervention).Seconds()
+		cooldownPassed = cooldown <= 0 || since >= cooldown
+		cooldownDetails["since_last_seconds"] = since
+		cooldownDetails["remaining_seconds"] = math.Max(cooldown-since, 0)
+	}
+	hourlyPassed := g.config.HourlyCap <= 0 || g.hourlyUsed+cost <= g.config.HourlyCap
+	dailyPassed := g.config.DailyCap <= 0 || g.dailyUsed+cost <= g.config.DailyCap
+	gates := []models.GateCheck{
+		{Gate: "cooldown", Passed: cooldownPassed, Details: cooldownDetails},
+		{Gate: "budget_cap", Passed: hourlyPassed && dailyPassed, Details: map[string]any{
+			"cost":        cost,
+			"hourly_used": g.hourlyUsed,
+			"hourly_cap":  g.config.HourlyCap,
+			"daily_used":  g.dailyUsed,
+			"daily_cap":   g.config.DailyCap,
+		}},
+		{Gate: "mode_budget", Passed: g.currentBudget[ctx.Mode] >= cost, Details: map[string]any{
+			"mode":       ctx.Mode,
+			"cost":       cost,
+			"budget":     g.currentBudget[ctx.Mode],
+			"max_budget": g.modeMaxBudget(ctx.Mode),
+		}},
+	}
+	for i := range gates {
+		if !gates[i].Passed {
+			gates[i].Reason = ReasonBudgetExhausted
+		}
 	}
-	if g.config.HourlyCap > 0 && g.hourlyUsed+cost > g.config.HourlyCap {
-		return false, ReasonBudgetExhausted
+	if !cooldownPassed {
+		gates[0].Reason = ReasonCooldownActive
 	}
-	if g.config.DailyCap > 0 && g.dailyUsed+cost > g.config.DailyCap {
-		return false, ReasonBudgetExhausted
+	return gates
+}
+
+// Rules returns the ordered rule set built from the live config used by Evaluate.
+func (g *Gateway) Rules() []RuleInfo {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.refreshConfigLocked()
+	cfg := g.config
+
+	modeBudgets := map[string]float64{}
+	for mode, budget := range cfg.ModeBudgets {
+		modeBudgets[string(mode)] = budget
+	}
+	actionCosts := map[string]float64{}
+	repeatIntervals := map[string]float64{}
+	for _, actionType := range interventionActions {
+		actionCosts[string(actionType)] = cfg.actionCost(actionType)
+		repeatIntervals[string(actionType)] = cfg.RepeatIntervals[actionType]
+	}
+	disabled := []models.ActionType{}
+	for _, actionType := range interventionActions {
+		if cfg.DisabledActions[actionType] {
+			disabled = append(disabled, actionType)
+		}
 	}
-	if g.currentBudget[ctx.Mode] < cost {
-		return false, ReasonBudgetExhausted
+	maxRisk := map[string]models.RiskLevel{}
+	for mode, level := range cfg.MaxRisk {
+		maxRisk[string(mode)] = level
+	}
+
+	return []RuleInfo{
+		{
+			Name:     "invalid_action",
+			Enabled:  true,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonInvalidActionType + "|" + ReasonInvalidRiskLevel + "|" + ReasonInvalidConfidence,
+			Thresholds: map[string]any{
+				"min_confidence": 0.0,
+				"max_confidence": 1.0,
+			},
+		},
+		{
+			Name:     "action_disabled",
+			Enabled:  len(disabled) > 0,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonActionDisabled,
+			Thresholds: map[string]any{
+				"disabled_actions": disabled,
+			},
+		},
+		{
+			Name:     "high_risk",
+			Enabled:  true,
+			Decision: models.GatewayDeny,
+			Reason:   ReasonHighRiskBlocked,
+			Thresholds: map[string]any{
+				"max_risk": maxRisk,
+			},
+		},
+		{
+			Name:     "low_quality",
+			Enabled:  true,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonLowQualityAction,
+			Thresholds: map[string]any{
+				"min_confidence": minActionConfidence,
+			},
+		},
+		{
+			Name:     "model_repetition",
+			Enabled:  true,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonModelRepetition,
+			Thresholds: map[string]any{
+				"window":  RepetitionWindow,
+				"repeats": repetitionLimit,
+			},
+		},
+		{
+			Name:     "in_meeting",
+			Enabled:  true,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonInMeeting,
+			Thresholds: map[string]any{
+				"signal":      InMeetingSignal,
+				"focus_state": focus.StateMeeting,
+			},
+		},
+		{
+			Name:     "silent_override",
+			Enabled:  true,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonModeSilentOverride,
+			Thresholds: map[string]any{
+				"mode": models.ModeSilent,
+			},
+		},
+		{
+			Name:     "cooldown",
+			Enabled:  cfg.CooldownSeconds > 0,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonCooldownActive,
+			Thresholds: map[string]any{
+				"cooldown_seconds":   cfg.CooldownSeconds,
+				"cooldown_factor":    cfg.CooldownFactor,
+				"effective_cooldown": cfg.effectiveCooldown(),
+				"urgent_bypass":      cfg.UrgentBypass,
+			},
+		},
+		{
+			Name:     "action_repeat",
+			Enabled:  cfg.repeatEnabled(),
+			Decision: models.GatewayOverride,
+			Reason:   ReasonActionRepeatTooSoon,
+			Thresholds: map[string]any{
+				"repeat_interval_seconds": repeatIntervals,
+			},
+		},
+		{
+			Name:     "hourly_cap",
+			Enabled:  cfg.HourlyCap > 0,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonBudgetExhausted,
+			Thresholds: map[string]any{
+				"cap":  cfg.HourlyCap,
+				"used": g.hourlyUsed,
+			},
+		},
+		{
+			Name:     "daily_cap",
+			Enabled:  cfg.DailyCap > 0,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonBudgetExhausted,
+			Thresholds: map[string]any{
+				"cap":  cfg.DailyCap,
+				"used": g.dailyUsed,
+			},
+		},
+		{
+			Name:     "mode_budget",
+			Enabled:  true,
+			Decision: models.GatewayOverride,
+			Reason:   ReasonBudgetExhausted,
+			Thresholds: map[string]any{
+				"mode_budgets":  modeBudgets,
+				"recovery_rate": cfg.RecoveryRate,
+				"action_costs":  actionCosts,
+			},
+		},
 	}
-	return true, "allow"
 }
 
-func MaxActionCost() float64 {
-	return 3.0
+// MaxActionCost is the most expensive intervention under the live cost table,
+// i.e. the most Evaluate can charge for a single action.
+func (g *Gateway) MaxActionCost() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.refreshConfigLocked()
+	return g.config.maxActionCost()
+}
+
+// ActionCost is what Evaluate charges for an allowed actionType suggestion
+// under the live cost table.
+func (g *Gateway) ActionCost(actionType models.ActionType) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.refreshConfigLocked()
+	return g.config.actionCost(actionType)
+}
+
+func (c Config) maxActionCost() float64 {
+	highest := 0.0
+	for _, actionType := range interventionActions {
+		highest = math.Max(highest, c.actionCost(actionType))
+	}
+	return highest
+}
+
+// minActionCost is the cheapest enabled intervention. It reports false when
+// every intervention is disabled.
+func (c Config) minActionCost() (float64, bool) {
+	lowest, found := 0.0, false
+	for _, actionType := range interventionActions {
+		if c.DisabledActions[actionType] {
+			continue
+		}
+		cost := c.actionCost(actionType)
+		if !found || cost < lowest {
+			lowest, found = cost, true
+		}
+	}
+	return lowest, found
+}
+
+// ConfigWarnings flags budget settings that are individually valid but
+// together make interventions impossible or a cap meaningless, so the
+// settings API can surface them without rejecting the change.
+func (g *Gateway) ConfigWarnings() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.refreshConfigLocked()
+	cfg := g.config
+	warnings := []string{}
+	if cfg.HourlyCap > 0 && cfg.DailyCap > 0 && cfg.HourlyCap > cfg.DailyCap {
+		warnings = append(warnings, fmt.Sprintf("hourly_budget_cap %.1f exceeds daily_budget_cap %.1f; the daily cap always binds first", cfg.HourlyCap, cfg.DailyCap))
+	}
+	cheapest, ok := cfg.minActionCost()
+	if !ok || cheapest <= 0 {
+		return warnings
+	}
+	if cfg.HourlyCap > 0 && cfg.HourlyCap < cheapest {
+		warnings = append(warnings, fmt.Sprintf("hourly_budget_cap %.1f is below the cheapest action cost %.1f; no action can be allowed", cfg.HourlyCap, cheapest))
+	}
+	if cfg.DailyCap > 0 && cfg.DailyCap < cheapest {
+		warnings = append(warnings, fmt.Sprintf("daily_budget_cap %.1f is below the cheapest action cost %.1f; no action can be allowed", cfg.DailyCap, cheapest))
+	}
+	for _, mode := range []models.Mode{models.ModeSilent, models.ModeLight, models.ModeActive} {
+		if budget := cfg.ModeBudgets[mode]; budget < cheapest {
+			warnings = append(warnings, fmt.Sprintf("%s budget %.1f is below the cheapest action cost %.1f; no action can be allowed in that mode", mode, budget, cheapest))
+		}
+	}
+	return warnings
 }
 
 func (g *Gateway) replenishBudgetLocked(mode models.Mode, now time.Time) {
@@ -351,27 +818,36 @@ func (g *Gateway) ClearCooldown() {
 	defer g.mu.Unlock()
 
 	// Set lastIntervention to a time in the past to bypass cooldown
-	g.lastIntervention = time.Now().Add(-time.Duration(g.config.CooldownSeconds+1) * time.Second)
+	g.lastIntervention = g.now().Add(-time.Duration(g.config.effectiveCooldown()+1) * time.Second)
 	g.logger.Info("gateway cooldown cleared, interaction enabled")
 }
 
-func calculateCost(action models.Action) float64 {
-	// Base cost by action type
-	// Can be enhanced to use action.Cost from AI if reliable
-	switch action.ActionType {
-	case models.ActionDoNotDisturb:
+// repeatRemainingLocked returns how many seconds must still pass before
+// actionType may be suggested again, or 0 when it is free to go.
+func (g *Gateway) repeatRemainingLocked(actionType models.ActionType, now time.Time) float64 {
+	interval := g.config.RepeatIntervals[actionType]
+	last, ok := g.lastByAction[actionType]
+	if interval <= 0 || !ok {
 		return 0
-	case models.ActionRestReminder:
-		return 2.0
-	case models.ActionEncourage:
-		return 1.5
-	case models.ActionTaskBreakdown:
-		return 3.0
-	case models.ActionReframe:
-		return 2.5
-	default:
-		return 1.0
 	}
+	return math.Max(interval-now.Sub(last).Seconds(), 0)
+}
+
+func (c Config) repeatEnabled() bool {
+	for _, interval := range c.RepeatIntervals {
+		if interval > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// actionCost returns the budget charged for actionType. Unknown types cost 1.
+func (c Config) actionCost(actionType models.ActionType) float64 {
+	if cost, ok := c.Costs[actionType]; ok {
+		return cost
+	}
+	return 1.0
 }
 
 func overrideAction(original models.Action, decisionType models.GatewayDecisionType, reason string) (models.Action, models.GatewayDecision) {
@@ -400,12 +876,20 @@ func defaultOverrideMessage(reason string) string {
 		return "当前建议质量不足，已降级为勿扰模式。"
 	case ReasonHighRiskBlocked:
 		return "高风险动作已被权限网关拦截。"
+	case ReasonActionDisabled:
+		return "该类建议已被关闭，已降级为勿扰模式。"
 	case ReasonInvalidActionType, ReasonInvalidRiskLevel, ReasonInvalidConfidence:
 		return "动作不合法，已降级为勿扰模式。"
 	case ReasonBudgetExhausted:
 		return "干预预算不足，已降级为勿扰模式。"
 	case ReasonCooldownActive:
 		return "处于冷却期，已降级为勿扰模式。"
+	case ReasonActionRepeatTooSoon:
+		return "同类建议刚刚出现过，已降级为勿扰模式。"
+	case ReasonModelRepetition:
+		return "模型反复给出相同建议，已降级为勿扰模式。"
+	case ReasonInMeeting:
+		return "会议中，已降级为勿扰模式。"
 	default:
 		return "已降级为勿扰模式。"
 	}
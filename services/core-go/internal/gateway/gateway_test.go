@@ -0,0 +1,332 @@
+package gateway
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"always/core/internal/focus"
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+// memStore is an in-memory SettingsStore.
+type memStore struct {
+	settings map[string]string
+	usage    models.BudgetUsage
+}
+
+func newMemStore(settings map[string]string) *memStore {
+	if settings == nil {
+		settings = map[string]string{}
+	}
+	return &memStore{settings: settings}
+}
+
+func (s *memStore) GetSetting(key string) (string, bool, error) {
+	value, ok := s.settings[key]
+	return value, ok, nil
+}
+
+func (s *memStore) GetBudgetUsage() (models.BudgetUsage, error) {
+	return s.usage, nil
+}
+
+func (s *memStore) SetBudgetUsage(usage models.BudgetUsage) error {
+	s.usage = usage
+	return nil
+}
+
+// fakeClock only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestGateway(t *testing.T, settings map[string]string) (*Gateway, *memStore, *fakeClock) {
+	t.Helper()
+	store := newMemStore(settings)
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)}
+	g := New(slog.New(slog.DiscardHandler), store)
+	g.SetClock(clock)
+	return g, store, clock
+}
+
+func suggestion(actionType models.ActionType) models.Action {
+	return models.Action{
+		ActionType: actionType,
+		Message:    "take a short break",
+		Confidence: 0.9,
+		RiskLevel:  models.RiskLow,
+	}
+}
+
+func activeContext() models.Context {
+	return models.Context{Mode: models.ModeActive, Signals: map[string]string{}}
+}
+
+func TestRepeatIntervalOffByDefault(t *testing.T) {
+	g, _, clock := newTestGateway(t, map[string]string{settings.CooldownSeconds: "0"})
+	for i := 0; i < 2; i++ {
+		_, decision := g.Evaluate(activeContext(), suggestion(models.ActionTaskBreakdown), EvalOptions{})
+		if decision.Decision != models.GatewayAllow {
+			t.Fatalf("suggestion %d = %s (%s), want ALLOW", i, decision.Decision, decision.Reason)
+		}
+		clock.Advance(90 * time.Second)
+	}
+}
+
+func TestRepeatIntervalSequences(t *testing.T) {
+	type step struct {
+		after      time.Duration
+		actionType models.ActionType
+		want       string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "back-to-back same type",
+			steps: []step{
+				{actionType: models.ActionTaskBreakdown, want: "allow"},
+				{after: 90 * time.Second, actionType: models.ActionTaskBreakdown, want: ReasonActionRepeatTooSoon},
+				{after: 510 * time.Second, actionType: models.ActionTaskBreakdown, want: "allow"},
+			},
+		},
+		{
+			name: "mixed types",
+			steps: []step{
+				{actionType: models.ActionTaskBreakdown, want: "allow"},
+				{after: 90 * time.Second, actionType: models.ActionEncourage, want: "allow"},
+				{after: 90 * time.Second, actionType: models.ActionTaskBreakdown, want: ReasonActionRepeatTooSoon},
+				{after: 90 * time.Second, actionType: models.ActionEncourage, want: "allow"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _, clock := newTestGateway(t, map[string]string{
+				settings.CooldownSeconds: "0",
+				settings.RepeatTask:      "600",
+			})
+			for i, s := range tt.steps {
+				clock.Advance(s.after)
+				_, decision := g.Evaluate(activeContext(), suggestion(s.actionType), EvalOptions{})
+				if got := reasonCategory(decision.Reason); got != s.want {
+					t.Fatalf("step %d (%s) reason = %q, want %q", i, s.actionType, decision.Reason, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxActionCostMatchesCharges(t *testing.T) {
+	for name, costs := range map[string]map[string]string{
+		"defaults":   {},
+		"configured": {settings.CostTask: "4.5", settings.CostRest: "0.5"},
+		"cheap":      {settings.CostRest: "0.2", settings.CostEncourage: "0.2", settings.CostTask: "0.3", settings.CostReframe: "0.1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := map[string]string{settings.CooldownSeconds: "0", settings.BudgetActive: "50"}
+			for key, value := range costs {
+				cfg[key] = value
+			}
+			highest := 0.0
+			for _, actionType := range interventionActions {
+				g, store, _ := newTestGateway(t, cfg)
+				_, decision := g.Evaluate(activeContext(), suggestion(actionType), EvalOptions{})
+				if decision.Decision != models.GatewayAllow {
+					t.Fatalf("%s: decision = %s (%s), want ALLOW", actionType, decision.Decision, decision.Reason)
+				}
+				highest = max(highest, store.usage.HourlyUsed)
+			}
+			g, _, _ := newTestGateway(t, cfg)
+			if got := g.MaxActionCost(); got != highest {
+				t.Errorf("MaxActionCost = %v, want the highest charge %v", got, highest)
+			}
+		})
+	}
+}
+
+func TestUserInitiatedLeavesAutoSuggestionStateAlone(t *testing.T) {
+	g, store, clock := newTestGateway(t, map[string]string{settings.CooldownSeconds: "600"})
+	auto := func() models.GatewayDecision {
+		_, decision := g.Evaluate(activeContext(), suggestion(models.ActionEncourage), EvalOptions{})
+		return decision
+	}
+	chat := func() models.GatewayDecision {
+		_, decision := g.Evaluate(activeContext(), suggestion(models.ActionEncourage), EvalOptions{UserInitiated: true})
+		return decision
+	}
+
+	if d := auto(); d.Decision != models.GatewayAllow {
+		t.Fatalf("first auto-suggestion = %s (%s), want ALLOW", d.Decision, d.Reason)
+	}
+	charged := store.usage.HourlyUsed
+
+	clock.Advance(time.Minute)
+	d := chat()
+	if d.Decision != models.GatewayAllow || d.CostApplied != 0 {
+		t.Fatalf("user-initiated during cooldown = %s (%s), cost %v; want a free ALLOW", d.Decision, d.Reason, d.CostApplied)
+	}
+	if store.usage.HourlyUsed != charged {
+		t.Errorf("user-initiated request charged usage: %v, want %v", store.usage.HourlyUsed, charged)
+	}
+
+	clock.Advance(time.Minute)
+	if d := auto(); d.Reason != ReasonCooldownActive {
+		t.Errorf("auto-suggestion after a chat = %s (%s), want the cooldown still active", d.Decision, d.Reason)
+	}
+
+	// 10 minutes after the first auto-suggestion but only 9 after the chat:
+	// the chat must not have restarted the cooldown.
+	clock.Advance(8 * time.Minute)
+	if d := auto(); d.Decision != models.GatewayAllow {
+		t.Errorf("auto-suggestion after the cooldown = %s (%s), want ALLOW", d.Decision, d.Reason)
+	}
+}
+
+func TestUserInitiatedBypassesExhaustedBudget(t *testing.T) {
+	g, _, _ := newTestGateway(t, map[string]string{settings.CooldownSeconds: "0", settings.CostEncourage: "1", settings.HourlyBudgetCap: "1"})
+	for i, want := range []string{"allow", ReasonBudgetExhausted} {
+		_, d := g.Evaluate(activeContext(), suggestion(models.ActionEncourage), EvalOptions{})
+		if got := reasonCategory(d.Reason); got != want {
+			t.Fatalf("auto-suggestion %d reason = %q, want %q", i, d.Reason, want)
+		}
+	}
+	if _, d := g.Evaluate(activeContext(), suggestion(models.ActionEncourage), EvalOptions{UserInitiated: true}); d.Decision != models.GatewayAllow {
+		t.Errorf("user-initiated with the hourly cap spent = %s (%s), want ALLOW", d.Decision, d.Reason)
+	}
+}
+
+func TestRuleUrgent(t *testing.T) {
+	stuck := models.Context{FocusState: focus.StateNoProgress}
+	urgent := suggestion(models.ActionEncourage)
+	urgent.Urgent = true
+	highRisk := suggestion(models.ActionTaskBreakdown)
+	highRisk.RiskLevel = models.RiskHigh
+
+	tests := []struct {
+		name   string
+		ctx    models.Context
+		action models.Action
+		want   bool
+	}{
+		{"stuck task breakdown", stuck, suggestion(models.ActionTaskBreakdown), true},
+		{"stuck marked urgent", stuck, urgent, true},
+		{"stuck but not urgent", stuck, suggestion(models.ActionEncourage), false},
+		{"not stuck", models.Context{FocusState: focus.StateFocused}, suggestion(models.ActionTaskBreakdown), false},
+		{"high risk", stuck, highRisk, false},
+	}
+	for _, tt := range tests {
+		if got := ruleUrgent(tt.ctx, tt.action); got != tt.want {
+			t.Errorf("%s: ruleUrgent = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUrgentCooldownBypass(t *testing.T) {
+	stuck := activeContext()
+	stuck.FocusState = focus.StateNoProgress
+	focused := activeContext()
+	focused.FocusState = focus.StateFocused
+
+	tests := []struct {
+		name   string
+		bypass string
+		ctx    models.Context
+		action models.ActionType
+		want   string
+	}{
+		{"bypass off", "false", stuck, models.ActionTaskBreakdown, ReasonCooldownActive},
+		{"bypass on, not stuck", "true", focused, models.ActionTaskBreakdown, ReasonCooldownActive},
+		{"bypass on, not urgent", "true", stuck, models.ActionEncourage, ReasonCooldownActive},
+		{"bypass on, urgent", "true", stuck, models.ActionTaskBreakdown, "allow"},
+	}
+	for _, tt := range tests {
+		g, _, clock := newTestGateway(t, map[string]string{
+			settings.CooldownSeconds: "600",
+			settings.UrgentBypass:    tt.bypass,
+		})
+		if _, d := g.Evaluate(activeContext(), suggestion(models.ActionEncourage), EvalOptions{}); d.Decision != models.GatewayAllow {
+			t.Fatalf("%s: first suggestion = %s (%s)", tt.name, d.Decision, d.Reason)
+		}
+		clock.Advance(time.Minute)
+		_, d := g.Evaluate(tt.ctx, suggestion(tt.action), EvalOptions{})
+		if got := reasonCategory(d.Reason); got != tt.want {
+			t.Errorf("%s: reason = %q, want %q", tt.name, d.Reason, tt.want)
+		}
+		if tt.want == "allow" && !strings.Contains(d.Reason, "cooldown bypassed (urgent)") {
+			t.Errorf("%s: reason %q does not say the cooldown was bypassed", tt.name, d.Reason)
+		}
+	}
+}
+
+func TestUrgentBypassStillChargesBudget(t *testing.T) {
+	g, store, _ := newTestGateway(t, map[string]string{
+		settings.CooldownSeconds: "600",
+		settings.UrgentBypass:    "true",
+		settings.CostEncourage:   "1",
+		settings.CostTask:        "1",
+		settings.HourlyBudgetCap: "2",
+	})
+	stuck := activeContext()
+	stuck.FocusState = focus.StateNoProgress
+
+	if _, d := g.Evaluate(stuck, suggestion(models.ActionEncourage), EvalOptions{}); d.Decision != models.GatewayAllow {
+		t.Fatalf("first suggestion = %s (%s)", d.Decision, d.Reason)
+	}
+	_, d := g.Evaluate(stuck, suggestion(models.ActionTaskBreakdown), EvalOptions{})
+	if d.Decision != models.GatewayAllow || d.CostApplied != 1 {
+		t.Fatalf("urgent during cooldown = %s cost %.1f (%s), want ALLOW costing 1", d.Decision, d.CostApplied, d.Reason)
+	}
+	if store.usage.HourlyUsed != 2 {
+		t.Errorf("hourly usage = %.1f, want 2", store.usage.HourlyUsed)
+	}
+	if _, d := g.Evaluate(stuck, suggestion(models.ActionTaskBreakdown), EvalOptions{}); reasonCategory(d.Reason) != ReasonBudgetExhausted {
+		t.Errorf("urgent with the hourly cap spent = %q, want %q", d.Reason, ReasonBudgetExhausted)
+	}
+}
+
+func TestRuleRepetition(t *testing.T) {
+	action := suggestion(models.ActionEncourage)
+	tests := []struct {
+		name   string
+		action models.Action
+		recent []string
+		want   bool
+	}{
+		{"no history", action, nil, false},
+		{"repeated once", action, []string{"take a short break", "stretch"}, false},
+		{"repeated twice", action, []string{"take a short break", "stretch", "take a short break"}, true},
+		{"near-identical", action, []string{"Take a short break!", "take a  short break."}, true},
+		{"outside the window", action, []string{"take a short break", "a", "b", "take a short break"}, false},
+		{"do not disturb", suggestion(models.ActionDoNotDisturb), []string{"take a short break", "take a short break"}, false},
+	}
+	for _, tt := range tests {
+		if got := ruleRepetition(tt.action, tt.recent); got != tt.want {
+			t.Errorf("%s: ruleRepetition = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestModelRepetitionDowngrades(t *testing.T) {
+	g, _, _ := newTestGateway(t, map[string]string{settings.CooldownSeconds: "0"})
+	recent := []string{"take a short break", "take a short break"}
+	final, d := g.Evaluate(activeContext(), suggestion(models.ActionEncourage), EvalOptions{RecentModelMessages: recent})
+	if final.ActionType != models.ActionDoNotDisturb || d.Reason != ReasonModelRepetition {
+		t.Errorf("repeated message = %s (%s), want DO_NOT_DISTURB (%s)", final.ActionType, d.Reason, ReasonModelRepetition)
+	}
+	if d.CostApplied != 0 {
+		t.Errorf("repeated message charged %.1f, want nothing", d.CostApplied)
+	}
+}
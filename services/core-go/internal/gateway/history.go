@@ -0,0 +1,47 @@
+package gateway
+
+import (
+	"log/slog"
+	"time"
+
+	"always/core/internal/models"
+)
+
+// budgetSnapshotInterval is the least time between two recorded budget
+// snapshots, so the history stays cheap however often Evaluate runs.
+const budgetSnapshotInterval = time.Minute
+
+// BudgetHistoryStore is implemented by stores that keep budget snapshots for
+// charting how the per-mode budget moves over time.
+type BudgetHistoryStore interface {
+	InsertBudgetSnapshot(models.BudgetSnapshot) error
+}
+
+// snapshotBudgetLocked records every mode's budget, recovered up to now, when
+// the store keeps history and the last snapshot is old enough.
+func (g *Gateway) snapshotBudgetLocked(now time.Time) {
+	history, ok := g.store.(BudgetHistoryStore)
+	if !ok {
+		return
+	}
+	if since := now.Sub(g.lastSnapshot); !g.lastSnapshot.IsZero() && since >= 0 && since < budgetSnapshotInterval {
+		return
+	}
+	g.lastSnapshot = now
+
+	snapshot := models.BudgetSnapshot{
+		TsMs:       now.UnixMilli(),
+		Budgets:    map[models.Mode]float64{},
+		MaxBudgets: map[models.Mode]float64{},
+		HourlyUsed: g.hourlyUsed,
+		DailyUsed:  g.dailyUsed,
+	}
+	for mode := range g.config.ModeBudgets {
+		g.replenishBudgetLocked(mode, now)
+		snapshot.Budgets[mode] = g.currentBudget[mode]
+		snapshot.MaxBudgets[mode] = g.modeMaxBudget(mode)
+	}
+	if err := history.InsertBudgetSnapshot(snapshot); err != nil {
+		g.logger.Warn("persist budget snapshot failed", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,132 @@
+package gateway
+
+import (
+	"log/slog"
+	"maps"
+	"strings"
+	"time"
+
+	"always/core/internal/models"
+)
+
+type ReplayRecord struct {
+	Context   models.Context
+	RawAction models.Action
+	Original  models.GatewayDecision
+	AtMs      int64
+}
+
+type ReplayResult struct {
+	Total              int            `json:"total"`
+	Changed            int            `json:"changed"`
+	ByDecision         map[string]int `json:"by_decision"`
+	ByReason           map[string]int `json:"by_reason"`
+	OriginalByDecision map[string]int `json:"original_by_decision"`
+}
+
+// replayStore serves the live settings but starts from empty usage and never
+// writes it back, so a replay cannot touch the real budget.
+type replayStore struct {
+	settings SettingsStore
+}
+
+func (s replayStore) GetSetting(key string) (string, bool, error) {
+	return s.settings.GetSetting(key)
+}
+
+func (replayStore) GetBudgetUsage() (models.BudgetUsage, error) {
+	return models.BudgetUsage{}, nil
+}
+
+func (replayStore) SetBudgetUsage(models.BudgetUsage) error {
+	return nil
+}
+
+// Replay runs past raw actions, oldest first, through a throwaway gateway
+// built from the current config and reports how the decisions would come out.
+// Each record is evaluated at its original timestamp so cooldowns and budget
+// recovery play out as they would have.
+func (g *Gateway) Replay(records []ReplayRecord) ReplayResult {
+	g.mu.Lock()
+	cfg := g.config
+	clock := g.clock
+	g.mu.Unlock()
+
+	replay := &Gateway{
+		logger:        slog.New(slog.DiscardHandler),
+		clock:         clock,
+		config:        cfg,
+		currentBudget: map[models.Mode]float64{},
+		lastByAction:  map[models.ActionType]time.Time{},
+		lastUpdate:    map[models.Mode]time.Time{},
+	}
+	if g.store != nil {
+		replay.store = replayStore{settings: g.store}
+	}
+	replay.refreshConfigLocked()
+	// refreshConfigLocked stamps every mode with the current time, which is
+	// after every record. Left unset, each mode's recovery clock starts at
+	// the first record replayed in it instead.
+	clear(replay.lastUpdate)
+
+	result := ReplayResult{
+		ByDecision:         map[string]int{},
+		ByReason:           map[string]int{},
+		OriginalByDecision: map[string]int{},
+	}
+	for _, record := range records {
+		at := time.UnixMilli(record.AtMs)
+		replay.loadUsageLocked(at)
+		replay.replenishBudgetLocked(record.Context.Mode, at)
+		_, decision := replay.evaluateLocked(record.Context, record.RawAction, at, EvalOptions{UserInitiated: record.Context.UserText != ""})
+
+		result.Total++
+		result.ByDecision[string(decision.Decision)]++
+		result.ByReason[reasonCategory(decision.Reason)]++
+		result.OriginalByDecision[string(record.Original.Decision)]++
+		if decision.Decision != record.Original.Decision {
+			result.Changed++
+		}
+	}
+	return result
+}
+
+// DryRun evaluates action against a copy of the live budget, cooldown and
+// usage state. Nothing is charged, persisted or snapshotted, so the next real
+// Evaluate sees the gateway exactly as before.
+func (g *Gateway) DryRun(ctx models.Context, action models.Action, opts EvalOptions) (models.Action, models.GatewayDecision) {
+	g.mu.Lock()
+	now := g.now()
+	g.refreshConfigLocked()
+	g.loadUsageLocked(now)
+	dry := &Gateway{
+		logger:           slog.New(slog.DiscardHandler),
+		clock:            g.clock,
+		config:           g.config,
+		currentBudget:    maps.Clone(g.currentBudget),
+		lastIntervention: g.lastIntervention,
+		lastByAction:     maps.Clone(g.lastByAction),
+		lastUpdate:       maps.Clone(g.lastUpdate),
+		dailyUsed:        g.dailyUsed,
+		hourlyUsed:       g.hourlyUsed,
+		dayBucket:        g.dayBucket,
+		hourBucket:       g.hourBucket,
+		usageLoaded:      true,
+	}
+	if g.store != nil {
+		dry.store = replayStore{settings: g.store}
+	}
+	g.mu.Unlock()
+
+	dry.replenishBudgetLocked(ctx.Mode, now)
+	final, decision := dry.evaluateLocked(ctx, action, now, opts)
+	decision.RiskPolicy = "max_risk:" + string(dry.modeMaxRisk(ctx.Mode))
+	return final, decision
+}
+
+// reasonCategory strips the detail from descriptive reasons such as
+// "allow: budget 4.5/10.0, ..." so they can be counted.
+func reasonCategory(reason string) string {
+	category, _, _ := strings.Cut(reason, ":")
+	return category
+}
@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+func TestReplayRecoversBudgetBetweenRecords(t *testing.T) {
+	g, _, _ := newTestGateway(t, map[string]string{
+		settings.BudgetActive: "3",
+	})
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	allowed := models.GatewayDecision{Decision: models.GatewayAllow}
+	records := []ReplayRecord{
+		{Context: activeContext(), RawAction: suggestion(models.ActionTaskBreakdown), Original: allowed, AtMs: start.UnixMilli()},
+		// An hour later the 3-point budget has long recovered at 0.5/min.
+		{Context: activeContext(), RawAction: suggestion(models.ActionTaskBreakdown), Original: allowed, AtMs: start.Add(time.Hour).UnixMilli()},
+	}
+
+	result := g.Replay(records)
+	if result.Total != 2 {
+		t.Fatalf("Total = %d, want 2", result.Total)
+	}
+	if got := result.ByDecision[string(models.GatewayAllow)]; got != 2 {
+		t.Fatalf("allowed = %d, want 2 (by reason %v)", got, result.ByReason)
+	}
+	if result.Changed != 0 {
+		t.Errorf("Changed = %d, want 0", result.Changed)
+	}
+}
+
+func TestReplayExhaustsBudgetForCloseRecords(t *testing.T) {
+	g, _, _ := newTestGateway(t, map[string]string{
+		settings.BudgetActive:    "3",
+		settings.CooldownSeconds: "0",
+	})
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	records := []ReplayRecord{
+		{Context: activeContext(), RawAction: suggestion(models.ActionTaskBreakdown), AtMs: start.UnixMilli()},
+		{Context: activeContext(), RawAction: suggestion(models.ActionEncourage), AtMs: start.Add(time.Minute).UnixMilli()},
+	}
+
+	result := g.Replay(records)
+	if got := result.ByDecision[string(models.GatewayAllow)]; got != 1 {
+		t.Errorf("allowed = %d, want 1", got)
+	}
+	if got := result.ByReason[ReasonBudgetExhausted]; got != 1 {
+		t.Errorf("budget_exhausted = %d, want 1 (by reason %v)", got, result.ByReason)
+	}
+}
+
+func TestReplayLeavesLiveStateUntouched(t *testing.T) {
+	g, store, _ := newTestGateway(t, map[string]string{settings.BudgetActive: "3"})
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	g.Replay([]ReplayRecord{
+		{Context: activeContext(), RawAction: suggestion(models.ActionTaskBreakdown), AtMs: start.UnixMilli()},
+	})
+
+	if store.usage != (models.BudgetUsage{}) {
+		t.Errorf("usage written during replay: %+v", store.usage)
+	}
+	_, decision := g.Evaluate(activeContext(), suggestion(models.ActionTaskBreakdown), EvalOptions{})
+	if decision.Decision != models.GatewayAllow {
+		t.Errorf("live Evaluate = %s (%s), want ALLOW", decision.Decision, decision.Reason)
+	}
+}
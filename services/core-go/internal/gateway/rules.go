@@ -1,14 +1,39 @@
 package gateway
 
-import "always/core/internal/models"
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"always/core/internal/focus"
+	"always/core/internal/models"
+)
 
 const (
-	ReasonInvalidActionType  = "invalid_action_type"
-	ReasonInvalidRiskLevel   = "invalid_risk_level"
-	ReasonInvalidConfidence  = "invalid_confidence"
-	ReasonModeSilentOverride = "mode_silent_override"
-	ReasonLowQualityAction   = "low_quality_action"
-	ReasonHighRiskBlocked    = "high_risk_blocked"
+	ReasonInvalidActionType   = "invalid_action_type"
+	ReasonInvalidRiskLevel    = "invalid_risk_level"
+	ReasonInvalidConfidence   = "invalid_confidence"
+	ReasonModeSilentOverride  = "mode_silent_override"
+	ReasonLowQualityAction    = "low_quality_action"
+	ReasonHighRiskBlocked     = "high_risk_blocked"
+	ReasonActionDisabled      = "action_disabled"
+	ReasonActionRepeatTooSoon = "action_repeat_too_soon"
+	ReasonModelRepetition     = "model_repetition"
+	ReasonInMeeting           = "in_meeting"
+)
+
+// InMeetingSignal is the calendar signal clients set while the user is in a
+// meeting.
+const InMeetingSignal = "in_meeting"
+
+const minActionConfidence = 0.5
+
+// RepetitionWindow is how many of the model's latest messages ruleRepetition
+// compares against; repetitionLimit near-identical ones among them mean the
+// model is stuck in a loop.
+const (
+	RepetitionWindow = 3
+	repetitionLimit  = 2
 )
 
 func ruleInvalidAction(action models.Action) (string, bool) {
@@ -24,12 +49,69 @@ func ruleInvalidAction(action models.Action) (string, bool) {
 	return "", false
 }
 
-func ruleHighRisk(action models.Action) bool {
-	return action.RiskLevel == models.RiskHigh
+func ruleActionDisabled(action models.Action, disabled map[models.ActionType]bool) bool {
+	return disabled[action.ActionType]
+}
+
+// ruleUrgent reports whether action counts as urgent: the user is stuck
+// (NO_PROGRESS) and the action is marked urgent by the model or is a task
+// breakdown. HIGH risk actions never are.
+func ruleUrgent(ctx models.Context, action models.Action) bool {
+	if ctx.FocusState != focus.StateNoProgress || action.RiskLevel == models.RiskHigh {
+		return false
+	}
+	return action.Urgent || action.ActionType == models.ActionTaskBreakdown
+}
+
+func ruleHighRisk(action models.Action, maxRisk models.RiskLevel) bool {
+	return riskRank(action.RiskLevel) > riskRank(maxRisk)
 }
 
 func ruleLowQuality(action models.Action) bool {
-	return action.Message == "" || action.Confidence < 0.5
+	return action.Message == "" || action.Confidence < minActionConfidence
+}
+
+// ruleRepetition reports whether action repeats a message the model already
+// proposed repetitionLimit times among recent, newest first. Silent actions
+// are never a loop worth stopping.
+func ruleRepetition(action models.Action, recent []string) bool {
+	if action.ActionType == models.ActionDoNotDisturb {
+		return false
+	}
+	message := normalizeMessage(action.Message)
+	if message == "" {
+		return false
+	}
+	matches := 0
+	for i, previous := range recent {
+		if i == RepetitionWindow {
+			break
+		}
+		if normalizeMessage(previous) == message {
+			matches++
+		}
+	}
+	return matches >= repetitionLimit
+}
+
+// normalizeMessage folds case and drops spaces and punctuation, so messages
+// that differ only in those count as the same.
+func normalizeMessage(message string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, message)
+}
+
+// ruleInMeeting reports whether action would interrupt a meeting, known
+// either from the calendar signal or from a meeting app holding focus.
+func ruleInMeeting(ctx models.Context, action models.Action) bool {
+	if action.ActionType == models.ActionDoNotDisturb {
+		return false
+	}
+	return ctx.FocusState == focus.StateMeeting || ctx.Signals[InMeetingSignal] == "true"
 }
 
 func ruleSilentOverride(ctx models.Context, action models.Action) bool {
@@ -49,6 +131,45 @@ func isValidActionType(actionType models.ActionType) bool {
 	}
 }
 
+// ParseDisabledActions parses a comma-separated list of action types that may be
+// switched off. "none" clears the list; DO_NOT_DISTURB cannot be disabled.
+func ParseDisabledActions(value string) ([]models.ActionType, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" || strings.EqualFold(trimmed, "none") {
+		return nil, nil
+	}
+	seen := map[models.ActionType]bool{}
+	var actions []models.ActionType
+	for _, part := range strings.Split(trimmed, ",") {
+		actionType := models.ActionType(strings.ToUpper(strings.TrimSpace(part)))
+		if actionType == "" {
+			continue
+		}
+		if !isValidActionType(actionType) || actionType == models.ActionDoNotDisturb {
+			return nil, fmt.Errorf("unknown action type %q", part)
+		}
+		if seen[actionType] {
+			continue
+		}
+		seen[actionType] = true
+		actions = append(actions, actionType)
+	}
+	return actions, nil
+}
+
+func riskRank(level models.RiskLevel) int {
+	switch level {
+	case models.RiskLow:
+		return 1
+	case models.RiskMedium:
+		return 2
+	case models.RiskHigh:
+		return 3
+	default:
+		return 0
+	}
+}
+
 func isValidRiskLevel(level models.RiskLevel) bool {
 	switch level {
 	case models.RiskLow, models.RiskMedium, models.RiskHigh:
@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"math"
+
+	"always/core/internal/models"
+)
+
+// interventionActions lists the action types that consume budget, in display order.
+var interventionActions = []models.ActionType{
+	models.ActionEncourage,
+	models.ActionRestReminder,
+	models.ActionReframe,
+	models.ActionTaskBreakdown,
+}
+
+// ActionSimulation is the most interventions of one action type a period can
+// hold. A nil MaxPerHour or MaxPerDay means nothing limits it, with the
+// matching LimitedBy set to "unlimited"; 0 means the type is never allowed.
+type ActionSimulation struct {
+	ActionType            models.ActionType `json:"action_type"`
+	Cost                  float64           `json:"cost"`
+	RepeatIntervalSeconds float64           `json:"repeat_interval_seconds"`
+	MaxPerHour            *int              `json:"max_per_hour"`
+	MaxPerDay             *int              `json:"max_per_day"`
+	HourLimitedBy         string            `json:"hour_limited_by"`
+	DayLimitedBy          string            `json:"day_limited_by"`
+}
+
+type Simulation struct {
+	Mode            models.Mode `json:"mode"`
+	ModeBudget      float64     `json:"mode_budget"`
+	RecoveryRate    float64     `json:"recovery_rate"`
+	CooldownSeconds float64     `json:"cooldown_seconds"`
+	// CooldownFactor is the adaptive backoff currently stretching the
+	// cooldown; the simulation uses the stretched value.
+	CooldownFactor           float64            `json:"cooldown_factor"`
+	EffectiveCooldownSeconds float64            `json:"effective_cooldown_seconds"`
+	HourlyCap                float64            `json:"hourly_cap"`
+	DailyCap                 float64            `json:"daily_cap"`
+	Actions                  []ActionSimulation `json:"actions"`
+}
+
+// Simulate computes the theoretical maximum interventions for mode under the live config.
+func (g *Gateway) Simulate(mode models.Mode) Simulation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.refreshConfigLocked()
+	return simulate(g.config, mode, g.modeMaxBudget(mode))
+}
+
+func simulate(cfg Config, mode models.Mode, modeBudget float64) Simulation {
+	result := Simulation{
+		Mode:                     mode,
+		ModeBudget:               modeBudget,
+		RecoveryRate:             cfg.RecoveryRate,
+		CooldownSeconds:          cfg.CooldownSeconds,
+		CooldownFactor:           cfg.CooldownFactor,
+		EffectiveCooldownSeconds: cfg.effectiveCooldown(),
+		HourlyCap:                cfg.HourlyCap,
+		DailyCap:                 cfg.DailyCap,
+		Actions:                  make([]ActionSimulation, 0, len(interventionActions)),
+	}
+	for _, actionType := range interventionActions {
+		cost := cfg.actionCost(actionType)
+		repeat := cfg.RepeatIntervals[actionType]
+		sim := ActionSimulation{ActionType: actionType, Cost: cost, RepeatIntervalSeconds: repeat}
+		blockedBy := ""
+		switch {
+		case cfg.DisabledActions[actionType]:
+			blockedBy = "action_disabled"
+		case mode == models.ModeSilent:
+			blockedBy = "silent_override"
+		}
+		if blockedBy != "" {
+			none := 0
+			sim.MaxPerHour, sim.MaxPerDay = &none, &none
+			sim.HourLimitedBy, sim.DayLimitedBy = blockedBy, blockedBy
+			result.Actions = append(result.Actions, sim)
+			continue
+		}
+		sim.MaxPerHour, sim.HourLimitedBy = maxInterventions(cfg, modeBudget, cost, repeat, 60)
+		sim.MaxPerDay, sim.DayLimitedBy = maxInterventions(cfg, modeBudget, cost, repeat, 24*60)
+		result.Actions = append(result.Actions, sim)
+	}
+	return result
+}
+
+// maxInterventions returns how many actions of the given cost and repeat
+// interval fit into a period starting from a full mode budget, and which
+// limit binds first, or nil when nothing does. Back-to-back actions of one
+// type are spaced by the longer of the effective cooldown and the repeat
+// interval.
+func maxInterventions(cfg Config, modeBudget, cost, repeatSeconds float64, periodMinutes float64) (*int, string) {
+	best := math.MaxInt
+	limitedBy := "unlimited"
+	consider := func(count int, name string) {
+		if count < best {
+			best = count
+			limitedBy = name
+		}
+	}
+
+	if cost > 0 {
+		consider(int(math.Floor((modeBudget+cfg.RecoveryRate*periodMinutes)/cost)), "mode_budget")
+		if cfg.HourlyCap > 0 {
+			consider(int(math.Floor(cfg.HourlyCap/cost))*int(math.Ceil(periodMinutes/60)), "hourly_cap")
+		}
+		if cfg.DailyCap > 0 {
+			consider(int(math.Floor(cfg.DailyCap/cost))*int(math.Ceil(periodMinutes/(24*60))), "daily_cap")
+		}
+	}
+	cooldown := cfg.effectiveCooldown()
+	if cooldown > 0 {
+		consider(int(math.Floor(periodMinutes*60/cooldown)), "cooldown")
+	}
+	if repeatSeconds > cooldown {
+		consider(int(math.Floor(periodMinutes*60/repeatSeconds)), "repeat_interval")
+	}
+	if best == math.MaxInt {
+		return nil, limitedBy
+	}
+	return &best, limitedBy
+}
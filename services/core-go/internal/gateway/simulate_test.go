@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+func simulatedAction(t *testing.T, sim Simulation, actionType models.ActionType) ActionSimulation {
+	t.Helper()
+	for _, action := range sim.Actions {
+		if action.ActionType == actionType {
+			return action
+		}
+	}
+	t.Fatalf("%s missing from simulation", actionType)
+	return ActionSimulation{}
+}
+
+func checkMaxPerHour(t *testing.T, sim ActionSimulation, want int, limitedBy string) {
+	t.Helper()
+	if sim.MaxPerHour == nil {
+		t.Errorf("%s per hour = unlimited, want %d (%s)", sim.ActionType, want, limitedBy)
+		return
+	}
+	if *sim.MaxPerHour != want || sim.HourLimitedBy != limitedBy {
+		t.Errorf("%s per hour = %d (%s), want %d (%s)", sim.ActionType, *sim.MaxPerHour, sim.HourLimitedBy, want, limitedBy)
+	}
+}
+
+func TestSimulateRepeatInterval(t *testing.T) {
+	g, _, _ := newTestGateway(t, map[string]string{
+		settings.CooldownSeconds: "300",
+		settings.RepeatTask:      "1200",
+		settings.BudgetActive:    "100",
+	})
+	sim := g.Simulate(models.ModeActive)
+
+	checkMaxPerHour(t, simulatedAction(t, sim, models.ActionTaskBreakdown), 3, "repeat_interval")
+	checkMaxPerHour(t, simulatedAction(t, sim, models.ActionEncourage), 12, "cooldown")
+}
+
+func TestSimulateDisabledAction(t *testing.T) {
+	g, _, _ := newTestGateway(t, map[string]string{settings.DisabledActions: "REFRAME"})
+	sim := g.Simulate(models.ModeActive)
+
+	reframe := simulatedAction(t, sim, models.ActionReframe)
+	checkMaxPerHour(t, reframe, 0, "action_disabled")
+	if reframe.MaxPerDay == nil || *reframe.MaxPerDay != 0 {
+		t.Errorf("disabled action per day = %v, want 0", reframe.MaxPerDay)
+	}
+}
+
+func TestSimulateUnlimited(t *testing.T) {
+	g, _, _ := newTestGateway(t, map[string]string{
+		settings.CooldownSeconds: "0",
+		settings.CostEncourage:   "0",
+	})
+	sim := g.Simulate(models.ModeActive)
+
+	encourage := simulatedAction(t, sim, models.ActionEncourage)
+	if encourage.MaxPerHour != nil || encourage.HourLimitedBy != "unlimited" {
+		t.Errorf("free action without cooldown per hour = %v (%s), want unlimited", encourage.MaxPerHour, encourage.HourLimitedBy)
+	}
+	if encourage.MaxPerDay != nil {
+		t.Errorf("free action without cooldown per day = %d, want unlimited", *encourage.MaxPerDay)
+	}
+}
+
+// annoyedStore reports an ignored suggestion at each of times.
+type annoyedStore struct {
+	*memStore
+	times []int64
+}
+
+func (s *annoyedStore) AnnoyanceTimes(sinceMs int64) ([]int64, error) {
+	var recent []int64
+	for _, atMs := range s.times {
+		if atMs >= sinceMs {
+			recent = append(recent, atMs)
+		}
+	}
+	return recent, nil
+}
+
+func TestSimulateAppliesCooldownFactor(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+	store := &annoyedStore{
+		memStore: newMemStore(map[string]string{
+			settings.CooldownSeconds: "300",
+			settings.BudgetActive:    "100",
+		}),
+		// Two fresh ignores add 0.5 each: a factor of 2.
+		times: []int64{now.UnixMilli(), now.UnixMilli()},
+	}
+	g := New(slog.New(slog.DiscardHandler), store)
+	g.SetClock(&fakeClock{now: now})
+
+	sim := g.Simulate(models.ModeActive)
+	if sim.CooldownFactor != 2 || sim.EffectiveCooldownSeconds != 600 {
+		t.Errorf("cooldown factor %v, effective %v, want 2 and 600", sim.CooldownFactor, sim.EffectiveCooldownSeconds)
+	}
+	checkMaxPerHour(t, simulatedAction(t, sim, models.ActionEncourage), 6, "cooldown")
+}
@@ -9,3 +9,12 @@ type OverrideResult struct {
 	FinalAction     models.Action
 	GatewayDecision models.GatewayDecision
 }
+
+// RuleInfo describes one gateway rule in the order Evaluate applies it.
+type RuleInfo struct {
+	Name       string                     `json:"name"`
+	Enabled    bool                       `json:"enabled"`
+	Decision   models.GatewayDecisionType `json:"decision"`
+	Reason     string                     `json:"reason"`
+	Thresholds map[string]any             `json:"thresholds,omitempty"`
+}
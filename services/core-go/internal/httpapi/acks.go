@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"always/core/internal/models"
+)
+
+const defaultDismissStatsWindow = 7 * 24 * time.Hour
+
+// handleDecisionAck records when the user dismissed a decision's suggestion,
+// along with how long it stayed up. The first dismissal wins.
+func (h *Handler) handleDecisionAck(w http.ResponseWriter, r *http.Request) {
+	requestID := chi.URLParam(r, "request_id")
+	var req models.AckRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	dismissedAtMs := req.DismissedAtMs
+	if dismissedAtMs == 0 {
+		dismissedAtMs = time.Now().UnixMilli()
+	} else if dismissedAtMs < 1_000_000_000_000 || dismissedAtMs > 10_000_000_000_000 {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "dismissed_at_ms must be milliseconds")
+		return
+	}
+
+	decision, exists, err := h.store.GetDecisionResponse(requestID)
+	if err != nil {
+		h.logger.Error("check request_id failed", slog.String("request_id", requestID), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, codeNotFound, "request_id not found")
+		return
+	}
+	if dismissedAtMs < decision.CreatedAtMs {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "dismissed_at_ms before decision")
+		return
+	}
+	ack, err := h.store.AckDecision(requestID, dismissedAtMs, dismissedAtMs-decision.CreatedAtMs)
+	if err != nil {
+		h.logger.Error("ack decision failed", slog.String("request_id", requestID), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	respondJSON(w, http.StatusOK, ack)
+}
+
+// handleDismissStats reports average time-to-dismiss per action type for
+// decisions made in the range, by default the last 7 days.
+func (h *Handler) handleDismissStats(w http.ResponseWriter, r *http.Request) {
+	sinceMs, untilMs, err := parseTimeRange(r, defaultDismissStatsWindow)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	actions, err := h.store.DismissStats(sinceMs, untilMs)
+	if err != nil {
+		h.logger.Error("dismiss stats failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"since_ms": sinceMs,
+		"until_ms": untilMs,
+		"actions":  actions,
+	})
+}
@@ -0,0 +1,27 @@
+package httpapi
+
+import (
+	_ "embed"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// adminPage is a single static page that drives the /v1 API from the
+// browser: settings, recent decisions and the focus state.
+//
+//go:embed admin/index.html
+var adminPage []byte
+
+// serveAdmin reads SERVE_ADMIN, which mounts the admin page at /.
+func serveAdmin() bool {
+	enabled, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("SERVE_ADMIN")))
+	return enabled
+}
+
+func handleAdmin(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(adminPage)
+}
@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"always/core/internal/focus"
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+// breakReminderPolicy is the policy version logged for reminders issued by
+// the break check instead of the model.
+const breakReminderPolicy = "break_reminder"
+
+const defaultBreakAfter = 50 * time.Minute
+
+// RunBreakReminders checks every interval whether a user has been focused
+// long enough to be sent a rest reminder, independently of any client
+// polling for decisions.
+func (h *Handler) RunBreakReminders(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.checkBreakReminders(time.Now())
+	}
+}
+
+func (h *Handler) checkBreakReminders(now time.Time) {
+	userIDs, err := h.users.store.UserIDs()
+	if err != nil {
+		h.logger.Error("list users failed", slog.Any("error", err))
+		userIDs = []string{models.DefaultUserID}
+	}
+	for _, userID := range userIDs {
+		if _, _, err := h.forUser(userID).remindBreak(now); err != nil {
+			h.logger.Error("break reminder check failed", slog.String("user_id", userID), slog.Any("error", err))
+		}
+	}
+}
+
+// remindBreak sends a deterministic REST_REMINDER through the gateway when
+// break reminders are on, it is not quiet hours, the current focus stretch
+// has reached break_after_minutes and no rest reminder got through within
+// that span. A reminder the gateway lets through is stored in the decision
+// log and returned; one it holds back is dropped, so the check can retry on
+// the next tick.
+func (h *Handler) remindBreak(now time.Time) (models.DecisionResponse, bool, error) {
+	reader := settings.New(h.store)
+	enabled, err := reader.GetBool(settings.BreakReminder, false)
+	if err != nil || !enabled {
+		return models.DecisionResponse{}, false, err
+	}
+	breakAfter, err := reader.GetDuration(settings.BreakAfterMinutes, time.Minute, defaultBreakAfter)
+	if err != nil {
+		return models.DecisionResponse{}, false, err
+	}
+	if breakAfter <= 0 {
+		breakAfter = defaultBreakAfter
+	}
+	quietHours, err := reader.GetString(settings.QuietHours, "")
+	if err != nil {
+		return models.DecisionResponse{}, false, err
+	}
+	if quietHours != "" && withinQuietHours(now.In(userLocation(h.store)), quietHours) {
+		return models.DecisionResponse{}, false, nil
+	}
+	reading, ok := readFocusState(h.store, h.focus)
+	if !ok || reading.Current == nil || reading.Current.FocusMinutes < breakAfter.Minutes() {
+		return models.DecisionResponse{}, false, nil
+	}
+	// The decision log, unlike the gateway, remembers reminders across
+	// restarts.
+	if _, recent, err := h.store.LastAllowedAction(models.ActionRestReminder, now.Add(-breakAfter).UnixMilli()); err != nil || recent {
+		return models.DecisionResponse{}, false, err
+	}
+
+	ctx := models.Context{
+		Timestamp:  now.UnixMilli(),
+		FocusState: reading.State,
+		Signals: map[string]string{
+			"focus_minutes": focus.FormatMinutes(reading.Current.FocusMinutes, h.minutesPrec),
+		},
+	}
+	if err := h.applyDefaultMode(&ctx); err != nil {
+		return models.DecisionResponse{}, false, err
+	}
+	if ctx.Mode == "" {
+		ctx.Mode = models.ModeLight
+	}
+	fallback := fmt.Sprintf("已连续专注 %d 分钟，起身活动一下、看看远处吧。", int(reading.Current.FocusMinutes))
+	action := models.Action{
+		ActionType: models.ActionRestReminder,
+		Message:    h.renderMessage(settings.MessageRestTemplate, ctx, "", fallback),
+		Confidence: 1,
+		Cost:       h.gateway.ActionCost(models.ActionRestReminder),
+		RiskLevel:  models.RiskLow,
+	}
+	finalAction, decision := h.evaluate(ctx, action)
+	if decision.Decision != models.GatewayAllow {
+		h.logger.Debug("break reminder held back", slog.String("reason", decision.Reason))
+		return models.DecisionResponse{}, false, nil
+	}
+
+	entry := models.DecisionLogEntry{
+		RequestID:       uuid.NewString(),
+		Context:         ctx,
+		RawAction:       action,
+		FinalAction:     finalAction,
+		GatewayDecision: decision,
+		PolicyVersion:   breakReminderPolicy,
+		ModelVersion:    "n/a",
+		CreatedAt:       now,
+		CreatedAtMs:     now.UnixMilli(),
+	}
+	if err := h.store.InsertDecision(entry); err != nil {
+		return models.DecisionResponse{}, false, err
+	}
+	h.logger.Info("break reminder sent",
+		slog.String("request_id", entry.RequestID),
+		slog.Float64("focus_minutes", reading.Current.FocusMinutes))
+	return models.DecisionResponse{
+		RequestID:       entry.RequestID,
+		Context:         ctx,
+		Action:          finalAction,
+		PolicyVersion:   breakReminderPolicy,
+		ModelVersion:    "n/a",
+		CreatedAt:       now,
+		CreatedAtMs:     entry.CreatedAtMs,
+		GatewayDecision: decision,
+	}, true, nil
+}
@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"always/core/internal/db"
+	"always/core/internal/focus"
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+// newBreakHandler returns a handler whose user has been in the same app for
+// an hour, with break reminders on and no cooldown in the way.
+func newBreakHandler(t *testing.T, extra map[string]string) (*Handler, *db.Store) {
+	t.Helper()
+	h, store, _ := newTestHandler(t)
+	h.focus = focus.NewMonitorWithProvider(store, slog.New(slog.DiscardHandler), time.Second, nil)
+	h.focus.SetIngestEnabled(true)
+	_, err := store.InsertFocusEvent(models.FocusEvent{
+		TsMs:       time.Now().Add(-time.Hour).UnixMilli(),
+		AppName:    "editor",
+		DurationMs: time.Hour.Milliseconds(),
+	})
+	if err != nil {
+		t.Fatalf("insert focus event: %v", err)
+	}
+	values := map[string]string{
+		settings.BreakReminder:   "true",
+		settings.CooldownSeconds: "0",
+		settings.Timezone:        "UTC",
+	}
+	for key, value := range extra {
+		values[key] = value
+	}
+	for key, value := range values {
+		if err := store.UpsertSetting(key, value); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	return h, store
+}
+
+func TestBreakReminderSentInTheBackground(t *testing.T) {
+	h, store := newBreakHandler(t, map[string]string{settings.CostRest: "3"})
+	now := time.Now()
+
+	h.checkBreakReminders(now)
+	logs, err := store.ListLogs(10)
+	if err != nil {
+		t.Fatalf("list logs: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("decision log has %d entries after the check, want the reminder", len(logs))
+	}
+	reminder := logs[0]
+	if reminder.PolicyVersion != breakReminderPolicy || reminder.FinalAction.ActionType != models.ActionRestReminder {
+		t.Fatalf("logged %s by %q, want REST_REMINDER by %q", reminder.FinalAction.ActionType, reminder.PolicyVersion, breakReminderPolicy)
+	}
+	if reminder.RawAction.Cost != 3 || reminder.GatewayDecision.CostApplied != 3 {
+		t.Errorf("reminder cost %.1f, charged %.1f, want both to be cost_rest 3", reminder.RawAction.Cost, reminder.GatewayDecision.CostApplied)
+	}
+
+	if _, sent, err := h.remindBreak(now.Add(time.Minute)); err != nil || sent {
+		t.Errorf("second check sent = %v (%v), want the recent reminder to hold it back", sent, err)
+	}
+	// A restarted service reads the last reminder back from the decision log.
+	restarted := NewHandler(store, &fakeAI{}, h.focus, h.memory, time.Now(), slog.New(slog.DiscardHandler), nil)
+	if _, sent, err := restarted.remindBreak(now.Add(2 * time.Minute)); err != nil || sent {
+		t.Errorf("check after a restart sent = %v (%v), want the logged reminder to hold it back", sent, err)
+	}
+}
+
+func TestBreakReminderHeldBack(t *testing.T) {
+	now := time.Now().UTC()
+	quiet := now.Add(-time.Hour).Format("15:04") + "-" + now.Add(time.Hour).Format("15:04")
+	tests := []struct {
+		name     string
+		settings map[string]string
+	}{
+		{"disabled", map[string]string{settings.BreakReminder: "false"}},
+		{"not long enough", map[string]string{settings.BreakAfterMinutes: "90"}},
+		{"quiet hours", map[string]string{settings.QuietHours: quiet}},
+		{"over budget", map[string]string{settings.CostRest: "3", settings.HourlyBudgetCap: "2"}},
+	}
+	for _, tt := range tests {
+		h, store := newBreakHandler(t, tt.settings)
+		if _, sent, err := h.remindBreak(now); err != nil || sent {
+			t.Errorf("%s: sent = %v (%v), want no reminder", tt.name, sent, err)
+		}
+		if logs, err := store.ListLogs(10); err != nil || len(logs) != 0 {
+			t.Errorf("%s: decision log = %d entries (%v), want none", tt.name, len(logs), err)
+		}
+	}
+}
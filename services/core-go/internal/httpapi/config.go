@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"always/core/internal/settings"
+)
+
+const (
+	configSourceEnv     = "env"
+	configSourceDB      = "db"
+	configSourceDefault = "default"
+)
+
+const redactedValue = "[redacted]"
+
+// ConfigEntry is one resolved configuration value and where it came from.
+type ConfigEntry struct {
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// RuntimeConfig collects the env-derived values the process actually runs
+// with, keyed by environment variable, for GET /v1/config.
+type RuntimeConfig map[string]ConfigEntry
+
+// Set records the resolved value for key. The source is "env" when the
+// variable is set, otherwise the value is the built-in default. Secrets are
+// stored redacted.
+func (c RuntimeConfig) Set(key string, value any) {
+	source := configSourceDefault
+	if strings.TrimSpace(os.Getenv(key)) != "" {
+		source = configSourceEnv
+	}
+	if isSecretConfigKey(key) {
+		if source == configSourceEnv {
+			value = redactedValue
+		} else {
+			value = ""
+		}
+	}
+	c[key] = ConfigEntry{Value: value, Source: source}
+}
+
+func isSecretConfigKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, marker := range []string{"KEY", "TOKEN", "SECRET", "PASSWORD"} {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// settingEnvOverrides are settings an environment variable takes precedence
+// over.
+var settingEnvOverrides = map[string]string{
+	settings.AIBackend:     "AI_BACKEND",
+	settings.FocusProvider: "FOCUS_PROVIDER",
+}
+
+// handleConfig reports the resolved environment and, for every client-facing
+// setting, its effective value and source. Settings left at their built-in
+// default have an empty value.
+func (h *Handler) handleConfig(w http.ResponseWriter, _ *http.Request) {
+	stored, err := h.listSettings("")
+	if err != nil {
+		h.logger.Error("list settings failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	dbValues := map[string]string{}
+	for _, item := range stored {
+		dbValues[item.Key] = item.Value
+	}
+
+	keys := make([]string, 0, len(allowedSettings))
+	for key := range allowedSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	effective := make(map[string]ConfigEntry, len(keys))
+	for _, key := range keys {
+		if envKey, ok := settingEnvOverrides[key]; ok {
+			if value := strings.TrimSpace(os.Getenv(envKey)); value != "" {
+				effective[key] = ConfigEntry{Value: value, Source: configSourceEnv}
+				continue
+			}
+		}
+		if value, ok := dbValues[key]; ok && value != "none" {
+			effective[key] = ConfigEntry{Value: value, Source: configSourceDB}
+			continue
+		}
+		effective[key] = ConfigEntry{Value: "", Source: configSourceDefault}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"env":      h.runtime,
+		"settings": effective,
+	})
+}
@@ -0,0 +1,311 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"always/core/internal/ai"
+	"always/core/internal/gateway"
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+func TestDecisionRequestIDReusedByAnotherUser(t *testing.T) {
+	h, _, backend := newTestHandler(t)
+	requestID := uuid.NewString()
+
+	rec := serve(t, h, http.MethodPost, "/v1/decision", decisionBody(requestID, models.ModeActive), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first decision = %d %s", rec.Code, rec.Body)
+	}
+	rec = serve(t, h, http.MethodPost, "/v1/decision", decisionBody(requestID, models.ModeActive), map[string]string{userIDHeader: "alice"})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("reused request_id for another user = %d %s, want 409", rec.Code, rec.Body)
+	}
+	if got := errorOf(t, rec).Code; got != codeConflict {
+		t.Errorf("code = %q, want %q", got, codeConflict)
+	}
+	if backend.calls != 1 {
+		t.Errorf("model called %d times, want 1", backend.calls)
+	}
+}
+
+func TestDecisionRetryReplaysStoredResponse(t *testing.T) {
+	h, _, backend := newTestHandler(t)
+	requestID := uuid.NewString()
+
+	first := serve(t, h, http.MethodPost, "/v1/decision", decisionBody(requestID, models.ModeActive), nil)
+	if first.Code != http.StatusOK {
+		t.Fatalf("first decision = %d %s", first.Code, first.Body)
+	}
+	retry := serve(t, h, http.MethodPost, "/v1/decision", decisionBody(requestID, models.ModeActive), nil)
+	if retry.Code != http.StatusOK {
+		t.Fatalf("retry = %d %s, want 200", retry.Code, retry.Body)
+	}
+	var want, got models.DecisionResponse
+	decodeBody(t, first, &want)
+	decodeBody(t, retry, &got)
+	if got.RequestID != requestID || got.Action != want.Action || got.CreatedAtMs != want.CreatedAtMs {
+		t.Errorf("retry response = %+v, want the stored %+v", got, want)
+	}
+	if backend.calls != 1 {
+		t.Errorf("model called %d times, want 1", backend.calls)
+	}
+
+	changed := decisionBody(requestID, models.ModeActive)
+	changed["context"].(map[string]any)["user_text"] = "something else"
+	rec := serve(t, h, http.MethodPost, "/v1/decision", changed, nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("same request_id with a different context = %d %s, want 409", rec.Code, rec.Body)
+	}
+	if backend.calls != 1 {
+		t.Errorf("model called %d times after a conflict, want 1", backend.calls)
+	}
+}
+
+func TestDecodeErrorsNameTheProblem(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"unknown field", `{"request_id":"` + uuid.NewString() + `","contxt":{}}`, `unknown field "contxt"`},
+		{"unknown nested field", `{"context":{"mode":"ACTIVE","timestamp":1760000000000,"user_txt":"hi"}}`, `unknown field "user_txt"`},
+		{"wrong type", `{"context":{"mode":"ACTIVE","timestamp":"soon"}}`, `invalid type for field "context.timestamp"`},
+		{"malformed", `{"context":`, "invalid json"},
+		{"not an object", `[1, 2]`, "invalid json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, h, http.MethodPost, "/v1/decision", tt.body, nil)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d %s, want 400", rec.Code, rec.Body)
+			}
+			if got := errorOf(t, rec); got.Code != codeInvalidJSON || got.Error != tt.want {
+				t.Errorf("error = %+v, want %s %q", got, codeInvalidJSON, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecisionDefaultMode(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	post := func(mode any) *httptest.ResponseRecorder {
+		t.Helper()
+		body := decisionBody(uuid.NewString(), "")
+		ctx := body["context"].(map[string]any)
+		delete(ctx, "mode")
+		if mode != nil {
+			ctx["mode"] = mode
+		}
+		return serve(t, h, http.MethodPost, "/v1/decision", body, nil)
+	}
+
+	if rec := post(nil); rec.Code != http.StatusBadRequest || errorOf(t, rec).Error != "invalid mode" {
+		t.Errorf("omitted mode without default_mode = %d %s, want 400 invalid mode", rec.Code, rec.Body)
+	}
+	if err := store.UpsertSetting(settings.DefaultMode, string(models.ModeLight)); err != nil {
+		t.Fatalf("set default_mode: %v", err)
+	}
+	for _, tt := range []struct {
+		mode     any
+		wantMode models.Mode
+	}{
+		{nil, models.ModeLight},
+		{"", models.ModeLight},
+		{models.ModeActive, models.ModeActive},
+	} {
+		rec := post(tt.mode)
+		if rec.Code != http.StatusOK {
+			t.Errorf("mode %v: status = %d %s, want 200", tt.mode, rec.Code, rec.Body)
+			continue
+		}
+		var resp models.DecisionResponse
+		decodeBody(t, rec, &resp)
+		if resp.Context.Mode != tt.wantMode {
+			t.Errorf("mode %v: decided in %s, want %s", tt.mode, resp.Context.Mode, tt.wantMode)
+		}
+	}
+	if rec := post("FOO"); rec.Code != http.StatusBadRequest {
+		t.Errorf("explicit invalid mode = %d %s, want 400", rec.Code, rec.Body)
+	}
+
+	// A retry is compared after defaulting, so spelling out the default
+	// mode replays the original decision instead of conflicting.
+	requestID := uuid.NewString()
+	omitted := decisionBody(requestID, "")
+	delete(omitted["context"].(map[string]any), "mode")
+	if rec := serve(t, h, http.MethodPost, "/v1/decision", omitted, nil); rec.Code != http.StatusOK {
+		t.Fatalf("decision without mode = %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, h, http.MethodPost, "/v1/decision", decisionBody(requestID, models.ModeLight), nil); rec.Code != http.StatusOK {
+		t.Errorf("retry with the default mode spelled out = %d %s, want 200", rec.Code, rec.Body)
+	}
+}
+
+func TestRepeatedModelMessageIsDowngraded(t *testing.T) {
+	h, _, backend := newTestHandler(t)
+	decide := func(session string) models.DecisionResponse {
+		t.Helper()
+		body := decisionBody(uuid.NewString(), models.ModeActive)
+		body["context"].(map[string]any)["signals"] = map[string]string{"session_id": session}
+		rec := serve(t, h, http.MethodPost, "/v1/decision", body, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("decision = %d %s", rec.Code, rec.Body)
+		}
+		var resp models.DecisionResponse
+		decodeBody(t, rec, &resp)
+		return resp
+	}
+
+	for i := range 2 {
+		if resp := decide("s1"); resp.Action.ActionType != models.ActionEncourage {
+			t.Fatalf("decision %d = %s (%s), want the model's ENCOURAGE", i, resp.Action.ActionType, resp.GatewayDecision.Reason)
+		}
+	}
+	resp := decide("s1")
+	if resp.Action.ActionType != models.ActionDoNotDisturb || resp.GatewayDecision.Reason != gateway.ReasonModelRepetition {
+		t.Errorf("third repeat = %s (%s), want DO_NOT_DISTURB (%s)", resp.Action.ActionType, resp.GatewayDecision.Reason, gateway.ReasonModelRepetition)
+	}
+	if resp := decide("s2"); resp.Action.ActionType != models.ActionEncourage {
+		t.Errorf("same message in another session = %s (%s), want ENCOURAGE", resp.Action.ActionType, resp.GatewayDecision.Reason)
+	}
+	backend.action.Message = "try the next step"
+	if resp := decide("s1"); resp.Action.ActionType != models.ActionEncourage {
+		t.Errorf("new message = %s (%s), want ENCOURAGE", resp.Action.ActionType, resp.GatewayDecision.Reason)
+	}
+}
+
+func TestInvalidAIResponseFallsBackToRules(t *testing.T) {
+	h, _, backend := newTestHandler(t)
+	backend.err = ai.InvalidResponseError{Raw: `{"action_type": `, Err: errors.New("unexpected end of JSON input")}
+
+	rec := serve(t, h, http.MethodPost, "/v1/decision", decisionBody(uuid.NewString(), models.ModeActive), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("invalid model output = %d %s, want 200", rec.Code, rec.Body)
+	}
+	var resp models.DecisionResponse
+	decodeBody(t, rec, &resp)
+	if resp.Action.ActionType != models.ActionDoNotDisturb || resp.PolicyVersion != aiFallbackPolicy {
+		t.Errorf("invalid model output answered %s by %q, want DO_NOT_DISTURB by %q", resp.Action.ActionType, resp.PolicyVersion, aiFallbackPolicy)
+	}
+
+	backend.err = errors.New("connection refused")
+	rec = serve(t, h, http.MethodPost, "/v1/decision", decisionBody(uuid.NewString(), models.ModeActive), nil)
+	if rec.Code != http.StatusBadGateway || errorOf(t, rec).Code != codeAIUnavailable {
+		t.Errorf("unreachable model = %d %s, want 502 %s", rec.Code, rec.Body, codeAIUnavailable)
+	}
+}
+
+// gatedAI answers like fakeAI, but the first call blocks until release is
+// closed, after running onCall.
+type gatedAI struct {
+	fakeAI
+	entered chan struct{}
+	release chan struct{}
+	onCall  func()
+	calls   atomic.Int32
+}
+
+func newGatedAI(backend *fakeAI) *gatedAI {
+	return &gatedAI{fakeAI: *backend, entered: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (g *gatedAI) Decide(models.Context, string) (models.Action, string, string, error) {
+	if g.calls.Add(1) == 1 {
+		if g.onCall != nil {
+			g.onCall()
+		}
+		close(g.entered)
+		<-g.release
+	}
+	return g.action, "policy_v0", "fake", g.err
+}
+
+func TestConcurrentDecisionRetryCallsModelOnce(t *testing.T) {
+	h, _, backend := newTestHandler(t)
+	gated := newGatedAI(backend)
+	h.ai = gated
+	body := decisionBody(uuid.NewString(), models.ModeActive)
+
+	recs := make([]*httptest.ResponseRecorder, 2)
+	var wg sync.WaitGroup
+	send := func(i int) {
+		defer wg.Done()
+		recs[i] = serve(t, h, http.MethodPost, "/v1/decision", body, nil)
+	}
+	wg.Add(2)
+	go send(0)
+	<-gated.entered
+	go send(1)
+	// Let the retry reach the request_id before the original finishes.
+	time.Sleep(50 * time.Millisecond)
+	close(gated.release)
+	wg.Wait()
+
+	var first, second models.DecisionResponse
+	for i, rec := range recs {
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d = %d %s, want 200", i, rec.Code, rec.Body)
+		}
+	}
+	decodeBody(t, recs[0], &first)
+	decodeBody(t, recs[1], &second)
+	if first.CreatedAtMs != second.CreatedAtMs {
+		t.Errorf("retry created at %d, want the original's %d replayed", second.CreatedAtMs, first.CreatedAtMs)
+	}
+	if calls := gated.calls.Load(); calls != 1 {
+		t.Errorf("model called %d times, want 1", calls)
+	}
+}
+
+func TestDecisionStoredConcurrentlyIsReplayed(t *testing.T) {
+	tests := []struct {
+		name     string
+		userText string
+		wantCode int
+	}{
+		{"retry", "help me plan", http.StatusOK},
+		{"different request", "something else", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		h, store, backend := newTestHandler(t)
+		gated := newGatedAI(backend)
+		h.ai = gated
+		requestID := uuid.NewString()
+		const storedAtMs = 1_760_000_123_000
+		// Another process stores the same request_id while the model answers.
+		gated.onCall = func() {
+			err := store.InsertDecision(models.DecisionLogEntry{
+				RequestID:   requestID,
+				Context:     models.Context{UserText: tt.userText, Mode: models.ModeActive, Timestamp: 1_760_000_000_000},
+				CreatedAtMs: storedAtMs,
+			})
+			if err != nil {
+				t.Errorf("%s: insert decision: %v", tt.name, err)
+			}
+		}
+		close(gated.release)
+
+		rec := serve(t, h, http.MethodPost, "/v1/decision", decisionBody(requestID, models.ModeActive), nil)
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d %s, want %d", tt.name, rec.Code, rec.Body, tt.wantCode)
+			continue
+		}
+		if tt.wantCode == http.StatusOK {
+			var resp models.DecisionResponse
+			decodeBody(t, rec, &resp)
+			if resp.CreatedAtMs != storedAtMs {
+				t.Errorf("%s: created at %d, want the stored %d", tt.name, resp.CreatedAtMs, storedAtMs)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package httpapi
+
+import "net/http"
+
+// errorCode is the stable, machine-readable companion to an error message.
+// Clients branch on the code; the message is for humans and may change.
+type errorCode string
+
+const (
+	codeInvalidRequest     errorCode = "invalid_request"
+	codeInvalidJSON        errorCode = "invalid_json"
+	codeBodyTooLarge       errorCode = "body_too_large"
+	codeUnsupportedSetting errorCode = "unsupported_setting"
+	codeNotFound           errorCode = "not_found"
+	codeConflict           errorCode = "conflict"
+	codeIngestDisabled     errorCode = "ingest_disabled"
+	codeDebugDisabled      errorCode = "debug_disabled"
+	codeAIUnavailable      errorCode = "ai_unavailable"
+	codeFocusUnavailable   errorCode = "focus_unavailable"
+	codeDBError            errorCode = "db_error"
+	codeSettingsError      errorCode = "settings_error"
+	codeFocusError         errorCode = "focus_error"
+	codeMemoryError        errorCode = "memory_error"
+	codeBudgetError        errorCode = "budget_error"
+	codeInternalError      errorCode = "internal_error"
+)
+
+// errorResponse is the body of every non-2xx JSON response.
+type errorResponse struct {
+	Error string    `json:"error"`
+	Code  errorCode `json:"code"`
+}
+
+func respondError(w http.ResponseWriter, status int, code errorCode, message string) {
+	respondJSON(w, status, errorResponse{Error: message, Code: code})
+}
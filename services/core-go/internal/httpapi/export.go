@@ -0,0 +1,58 @@
+package httpapi
+
+import (
+	"fmt"
+	"strings"
+
+	"always/core/internal/models"
+)
+
+// exportFields are the names ?fields= on /v1/export accepts. Besides the
+// record's top-level fields they include a few narrower cuts, so a pipeline
+// can take the signals without the rest of the context (and its user_text).
+var exportFields = map[string]func(models.ExportRecord) any{
+	"request_id":        func(r models.ExportRecord) any { return r.RequestID },
+	"context":           func(r models.ExportRecord) any { return r.Context },
+	"signals":           func(r models.ExportRecord) any { return r.Context.Signals },
+	"mode":              func(r models.ExportRecord) any { return r.Context.Mode },
+	"focus_state":       func(r models.ExportRecord) any { return r.Context.FocusState },
+	"user_text":         func(r models.ExportRecord) any { return r.Context.UserText },
+	"raw_action":        func(r models.ExportRecord) any { return r.RawAction },
+	"raw_action_type":   func(r models.ExportRecord) any { return r.RawAction.ActionType },
+	"final_action":      func(r models.ExportRecord) any { return r.FinalAction },
+	"final_action_type": func(r models.ExportRecord) any { return r.FinalAction.ActionType },
+	"gateway_decision":  func(r models.ExportRecord) any { return r.GatewayDecision },
+	"user_feedback":     func(r models.ExportRecord) any { return r.UserFeedback },
+	"policy_version":    func(r models.ExportRecord) any { return r.PolicyVersion },
+	"model_version":     func(r models.ExportRecord) any { return r.ModelVersion },
+	"latency_ms":        func(r models.ExportRecord) any { return r.LatencyMs },
+	"created_at_ms":     func(r models.ExportRecord) any { return r.CreatedAtMs },
+}
+
+// parseExportFields reads a comma-separated ?fields= list. An empty list
+// means the full record and returns nil.
+func parseExportFields(raw string) ([]string, error) {
+	var fields []string
+	seen := map[string]bool{}
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if _, ok := exportFields[field]; !ok {
+			return nil, fmt.Errorf("unknown export field: %s", field)
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// projectExport keeps only fields of record.
+func projectExport(record models.ExportRecord, fields []string) map[string]any {
+	projected := make(map[string]any, len(fields))
+	for _, field := range fields {
+		projected[field] = exportFields[field](record)
+	}
+	return projected
+}
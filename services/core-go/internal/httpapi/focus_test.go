@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"always/core/internal/focus"
+	"always/core/internal/models"
+)
+
+func TestFocusRecentOmitsTitlesWhenRedacting(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	h.focus = focus.NewMonitorWithProvider(store, slog.New(slog.DiscardHandler), time.Second, nil)
+	// A title recorded before redaction was switched on.
+	_, err := store.InsertFocusEvent(models.FocusEvent{
+		TsMs:        time.Now().UnixMilli(),
+		AppName:     "mail",
+		WindowTitle: "Inbox - alice@example.com",
+	})
+	if err != nil {
+		t.Fatalf("insert focus event: %v", err)
+	}
+
+	titles := func() []string {
+		t.Helper()
+		rec := serve(t, h, http.MethodGet, "/v1/focus/recent", nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("focus recent = %d %s", rec.Code, rec.Body)
+		}
+		var events []models.FocusEvent
+		decodeBody(t, rec, &events)
+		var titles []string
+		for _, event := range events {
+			titles = append(titles, event.WindowTitle)
+		}
+		return titles
+	}
+	if got := titles(); len(got) != 1 || got[0] == "" {
+		t.Fatalf("titles without redaction = %q, want the stored title", got)
+	}
+	h.focus.SetRedactTitles(true)
+	if got := titles(); len(got) != 1 || got[0] != "" {
+		t.Errorf("titles with redaction = %q, want them omitted", got)
+	}
+}
+
+func TestFocusMinutesPrecision(t *testing.T) {
+	tests := map[string]int{
+		"":    focus.DefaultMinutesPrecision,
+		"0":   0,
+		" 2 ": 2,
+		"3":   3,
+		"4":   focus.DefaultMinutesPrecision,
+		"-1":  focus.DefaultMinutesPrecision,
+		"two": focus.DefaultMinutesPrecision,
+	}
+	for value, want := range tests {
+		t.Setenv("FOCUS_MINUTES_PRECISION", value)
+		if got := focusMinutesPrecision(); got != want {
+			t.Errorf("FOCUS_MINUTES_PRECISION=%q gives %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestFocusMinutesArePresentedRounded(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	h.focus = focus.NewMonitorWithProvider(store, slog.New(slog.DiscardHandler), time.Second, nil)
+	h.focus.SetIngestEnabled(true)
+	const durationMs = 754_321 // 12.5720166 minutes
+	_, err := store.InsertFocusEvent(models.FocusEvent{
+		TsMs:       time.Now().Add(-time.Hour).UnixMilli(),
+		AppName:    "editor",
+		DurationMs: durationMs,
+	})
+	if err != nil {
+		t.Fatalf("insert focus event: %v", err)
+	}
+
+	for _, tt := range []struct {
+		precision int
+		want      float64
+		signal    string
+	}{
+		{1, 12.6, "12.6"},
+		{2, 12.57, "12.57"},
+	} {
+		h.minutesPrec = tt.precision
+
+		var current models.FocusCurrent
+		decodeBody(t, serve(t, h, http.MethodGet, "/v1/focus/current", nil, nil), &current)
+		if current.FocusMinutes != tt.want {
+			t.Errorf("precision %d: focus/current minutes = %v, want %v", tt.precision, current.FocusMinutes, tt.want)
+		}
+		var reading models.FocusStateReading
+		decodeBody(t, serve(t, h, http.MethodGet, "/v1/focus/state", nil, nil), &reading)
+		if reading.FocusMinutes != tt.want || reading.Current == nil || reading.Current.FocusMinutes != tt.want {
+			t.Errorf("precision %d: focus/state minutes = %+v, want %v", tt.precision, reading, tt.want)
+		}
+
+		ctx := models.Context{Signals: map[string]string{}}
+		if err := enrichSignals(store, h.focus, &ctx, time.UTC, tt.precision); err != nil {
+			t.Fatalf("enrich signals: %v", err)
+		}
+		if got := ctx.Signals["focus_minutes"]; got != tt.signal {
+			t.Errorf("precision %d: focus_minutes signal = %q, want %q", tt.precision, got, tt.signal)
+		}
+	}
+
+	event, ok, err := store.LatestFocusEvent()
+	if err != nil || !ok || event.DurationMs != durationMs {
+		t.Errorf("stored event = %+v (%v, %v), want its full %d ms duration kept", event, ok, err, durationMs)
+	}
+}
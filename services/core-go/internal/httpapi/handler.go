@@ -2,20 +2,27 @@ package httpapi
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"math/rand/v2"
 	"net/http"
-	"net/url"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"always/core/internal/ai"
 	"always/core/internal/db"
@@ -23,54 +30,138 @@ import (
 	"always/core/internal/gateway"
 	"always/core/internal/memory"
 	"always/core/internal/models"
+	"always/core/internal/settings"
 )
 
+var allowedSettings = map[string]bool{
+	settings.QuietHours:           true,
+	settings.InterventionBudget:   true,
+	settings.FocusMonitorEnabled:  true,
+	settings.FocusIngestEnabled:   true,
+	settings.FocusProvider:        true,
+	settings.Timezone:             true,
+	settings.OllamaModel:          true,
+	settings.AgentEnabled:         true,
+	settings.RuleOnlyMode:         true,
+	settings.BudgetSilent:         true,
+	settings.BudgetLight:          true,
+	settings.BudgetActive:         true,
+	settings.DailyBudgetCap:       true,
+	settings.HourlyBudgetCap:      true,
+	settings.CooldownSeconds:      true,
+	settings.AdaptiveCooldown:     true,
+	settings.AdaptiveCooldownMax:  true,
+	settings.UrgentBypass:         true,
+	settings.AllowHighRisk:        true,
+	settings.MaxRiskSilent:        true,
+	settings.MaxRiskLight:         true,
+	settings.MaxRiskActive:        true,
+	settings.AutoJitterPercent:    true,
+	settings.DisabledActions:      true,
+	settings.HalfLifeDays:         true,
+	settings.DebugPrompts:         true,
+	settings.PolicyVariants:       true,
+	settings.BreakReminder:        true,
+	settings.BreakAfterMinutes:    true,
+	settings.DefaultMode:          true,
+	settings.WarmupSeconds:        true,
+	settings.NegativeWeight:       true,
+	settings.PositiveWeight:       true,
+	settings.FocusContext:         true,
+	settings.EventOrder:           true,
+	settings.AIBackend:            true,
+	settings.StrictSignals:        true,
+	settings.ActiveHours:          true,
+	settings.AutoMode:             true,
+	settings.TitleSwitchThreshold: true,
+	settings.FocusScoreWeights:    true,
+	settings.FocusPollMs:          true,
+	settings.MaxAutoPerDay:        true,
+	settings.BatteryBackoff:       true,
+	settings.BatteryPollFactor:    true,
+	settings.RedactWindowTitles:   true,
+	settings.FocusAppBlocklist:    true,
+	settings.FocusAppAllowlist:    true,
+	settings.MeetingApps:          true,
+	settings.NoProgressInputReset: true,
+	settings.CostRest:             true,
+	settings.CostEncourage:        true,
+	settings.CostTask:             true,
+	settings.CostReframe:          true,
+	settings.RepeatRest:           true,
+	settings.RepeatEncourage:      true,
+	settings.RepeatTask:           true,
+	settings.RepeatReframe:        true,
+
+	settings.MessageRestTemplate:       true,
+	settings.MessageQuietHoursTemplate: true,
+	settings.MessageAutoGuardTemplate:  true,
+	settings.MessageOverrideTemplate:   true,
+}
+
+// budgetSettings are the keys whose combination handleSettingsPost checks
+// with gateway.ConfigWarnings.
+var budgetSettings = map[string]bool{
+	settings.InterventionBudget: true,
+	settings.BudgetSilent:       true,
+	settings.BudgetLight:        true,
+	settings.BudgetActive:       true,
+	settings.HourlyBudgetCap:    true,
+	settings.DailyBudgetCap:     true,
+	settings.DisabledActions:    true,
+	settings.CostRest:           true,
+	settings.CostEncourage:      true,
+	settings.CostTask:           true,
+	settings.CostReframe:        true,
+}
+
+const defaultForgetSuppression = 30 * time.Minute
+
+const aiProgressInterval = 5 * time.Second
+
+// aiFallbackPolicy is the policy version of decisions answered by the rules
+// because the model's output failed validation.
+const aiFallbackPolicy = "rule_fallback"
+
+// maxLoggedAIResponse caps how much of an invalid model response is logged.
+const maxLoggedAIResponse = 2000
+
+const exportWriteTimeout = 5 * time.Minute
+
 const (
-	settingQuietHours         = "quiet_hours"
-	settingInterventionBudget = "intervention_budget"
-	settingFocusMonitor       = "focus_monitor_enabled"
-	settingOllamaModel        = "ollama_model"
-	settingAgentEnabled       = "agent_enabled"
-	settingRuleOnlyMode       = "rule_only_mode"
-	settingBudgetSilent       = "budget_silent"
-	settingBudgetLight        = "budget_light"
-	settingBudgetActive       = "budget_active"
-	settingDailyBudgetCap     = "daily_budget_cap"
-	settingHourlyBudgetCap    = "hourly_budget_cap"
-	settingCooldownSeconds    = "cooldown_seconds"
-	settingLastAutoSuggestMs  = "last_auto_suggestion_ms"
+	autoSuggestionWindow = 10 * time.Minute
+	maxAutoJitterPercent = 50.0
 )
 
-var allowedSettings = map[string]bool{
-	settingQuietHours:         true,
-	settingInterventionBudget: true,
-	settingFocusMonitor:       true,
-	settingOllamaModel:        true,
-	settingAgentEnabled:       true,
-	settingRuleOnlyMode:       true,
-	settingBudgetSilent:       true,
-	settingBudgetLight:        true,
-	settingBudgetActive:       true,
-	settingDailyBudgetCap:     true,
-	settingHourlyBudgetCap:    true,
-	settingCooldownSeconds:    true,
-}
-
-const autoSuggestionWindow = 10 * time.Minute
-
 type Handler struct {
 	store   *db.Store
-	ai      *ai.Client
+	ai      ai.AIBackend
 	focus   *focus.Monitor
 	memory  *memory.Service
 	gateway *gateway.Gateway
 	started time.Time
 	logger  *slog.Logger
+
+	ollamaModels *ollamaModelCache
+	maxLimit     int
+	maxBodyBytes int64
+	logExclude   map[string]bool
+	logSample    float64
+	serveAdmin   bool
+	minutesPrec  int
+	decisions    *requestReservations
+	runtime      RuntimeConfig
+	users        *userScopes
 }
 
-func NewHandler(store *db.Store, aiClient *ai.Client, focusMonitor *focus.Monitor, memoryService *memory.Service, started time.Time, logger *slog.Logger) *Handler {
+// NewHandler wires the API. runtime holds the env-derived values resolved in
+// main; the handler adds its own before serving them from /v1/config.
+func NewHandler(store *db.Store, aiClient ai.AIBackend, focusMonitor *focus.Monitor, memoryService *memory.Service, started time.Time, logger *slog.Logger, runtime RuntimeConfig) *Handler {
 	gw := gateway.New(logger, store)
-	return &Handler{
+	if runtime == nil {
+		runtime = RuntimeConfig{}
+	}
+	h := &Handler{
 		store:   store,
 		ai:      aiClient,
 		focus:   focusMonitor,
@@ -78,7 +169,27 @@ func NewHandler(store *db.Store, aiClient *ai.Client, focusMonitor *focus.Monito
 		gateway: gw,
 		started: started,
 		logger:  logger,
-	}
+
+		ollamaModels: newOllamaModelCache(ollamaModelsTTL()),
+		maxLimit:     maxListLimit(),
+		maxBodyBytes: maxBodyBytes(),
+		logExclude:   logExcludePaths(),
+		logSample:    logExcludedSampleRate(),
+		serveAdmin:   serveAdmin(),
+		minutesPrec:  focusMinutesPrecision(),
+		decisions:    newRequestReservations(),
+		runtime:      runtime,
+		users:        newUserScopes(logger, store, gw),
+	}
+	runtime.Set("OLLAMA_URL", ollamaTagsURL())
+	runtime.Set("OLLAMA_MODELS_TTL_SECONDS", int(ollamaModelsTTL().Seconds()))
+	runtime.Set("MAX_LIST_LIMIT", h.maxLimit)
+	runtime.Set("MAX_BODY_BYTES", h.maxBodyBytes)
+	runtime.Set("LOG_EXCLUDE_PATHS", sortedPaths(h.logExclude))
+	runtime.Set("LOG_EXCLUDED_SAMPLE_RATE", h.logSample)
+	runtime.Set("SERVE_ADMIN", h.serveAdmin)
+	runtime.Set("FOCUS_MINUTES_PRECISION", h.minutesPrec)
+	return h
 }
 
 func (h *Handler) Router() chi.Router {
@@ -86,27 +197,65 @@ func (h *Handler) Router() chi.Router {
 	r.Use(corsMiddleware)
 	r.Use(h.loggingMiddleware)
 	r.Get("/v1/health", h.handleHealth)
-	r.Post("/v1/decision", h.handleDecision)
-	r.Post("/v1/feedback", h.handleFeedback)
-	r.Post("/v1/memory/reset", h.handleMemoryReset)
-	r.Get("/v1/logs", h.handleLogs)
-	r.Get("/v1/focus/current", h.handleFocusCurrent)
-	r.Get("/v1/focus/recent", h.handleFocusRecent)
-	r.Get("/v1/export", h.handleExport)
-	r.Get("/v1/ollama/models", h.handleOllamaModels)
-	r.Get("/v1/settings", h.handleSettingsGet)
-	r.Post("/v1/settings", h.handleSettingsPost)
-	r.Get("/v1/profile", h.handleProfile)
-	r.Get("/v1/learning/explanations", h.handleLearningExplanations)
-	r.Get("/v1/state/history", h.handleStateHistory)
+	r.Get("/v1/ping", handlePing)
+	r.Head("/v1/ping", handlePing)
+	r.Get("/v1/openapi.json", h.handleOpenAPI)
+	r.Post("/v1/decision", h.scoped((*Handler).handleDecision))
+	r.Post("/v1/decision/debug", h.scoped((*Handler).handleDecisionDebug))
+	r.Post("/v1/selftest", h.scoped((*Handler).handleSelfTest))
+	r.Post("/v1/decision/{request_id}/tags", h.scoped((*Handler).handleDecisionTags))
+	r.Post("/v1/decision/{request_id}/ack", h.scoped((*Handler).handleDecisionAck))
+	r.Post("/v1/feedback", h.scoped((*Handler).handleFeedback))
+	r.Post("/v1/memory/reset", h.scoped((*Handler).handleMemoryReset))
+	r.Get("/v1/memory/export", h.scoped((*Handler).handleMemoryExport))
+	r.Post("/v1/memory/import", h.scoped((*Handler).handleMemoryImport))
+	r.Post("/v1/memory/forget", h.scoped((*Handler).handleMemoryForget))
+	r.Post("/v1/memory/preview-feedback", h.scoped((*Handler).handleMemoryPreviewFeedback))
+	r.Get("/v1/logs", h.scoped((*Handler).handleLogs))
+	r.Get("/v1/logs/unrated", h.scoped((*Handler).handleLogsUnrated))
+	r.Get("/v1/logs/enriched", h.scoped((*Handler).handleLogsEnriched))
+	r.Get("/v1/focus/current", h.scoped((*Handler).handleFocusCurrent))
+	r.Get("/v1/focus/recent", h.scoped((*Handler).handleFocusRecent))
+	r.Get("/v1/focus/state", h.scoped((*Handler).handleFocusState))
+	r.Get("/v1/focus/status", h.scoped((*Handler).handleFocusStatus))
+	r.Post("/v1/focus/event", h.scoped((*Handler).handleFocusEvent))
+	r.Post("/v1/focus/events", h.scoped((*Handler).handleFocusEvents))
+	r.Delete("/v1/focus/events", h.scoped((*Handler).handleFocusPurge))
+	r.Get("/v1/focus/analytics", h.scoped((*Handler).handleFocusAnalytics))
+	r.Get("/v1/export", h.scoped((*Handler).handleExport))
+	r.Get("/v1/ollama/models", h.scoped((*Handler).handleOllamaModels))
+	r.Get("/v1/settings", h.scoped((*Handler).handleSettingsGet))
+	r.Get("/v1/config", h.scoped((*Handler).handleConfig))
+	r.Post("/v1/settings", h.scoped((*Handler).handleSettingsPost))
+	r.Get("/v1/profile", h.scoped((*Handler).handleProfile))
+	r.Get("/v1/learning/explanations", h.scoped((*Handler).handleLearningExplanations))
+	r.Get("/v1/state/history", h.scoped((*Handler).handleStateHistory))
+	r.Get("/v1/gateway/rules", h.scoped((*Handler).handleGatewayRules))
+	r.Get("/v1/gateway/simulate", h.scoped((*Handler).handleGatewaySimulate))
+	r.Post("/v1/gateway/replay", h.scoped((*Handler).handleGatewayReplay))
+	r.Get("/v1/gateway/budget-history", h.scoped((*Handler).handleBudgetHistory))
+	r.Get("/v1/summary/daily", h.scoped((*Handler).handleDailySummary))
+	r.Get("/v1/stats/weekly", h.scoped((*Handler).handleWeeklyStats))
+	r.Get("/v1/stats/feedback", h.scoped((*Handler).handleFeedbackStats))
+	r.Get("/v1/stats/variants", h.scoped((*Handler).handleVariantStats))
+	r.Get("/v1/stats/dismissals", h.scoped((*Handler).handleDismissStats))
+	if h.serveAdmin {
+		r.Get("/", handleAdmin)
+	}
 	return r
 }
 
+var tracer = otel.Tracer("always/core/httpapi")
+
 func (h *Handler) handleDecision(w http.ResponseWriter, r *http.Request) {
+	traceCtx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	traceCtx, span := tracer.Start(traceCtx, "decision")
+	defer span.End()
+
 	var req models.DecisionRequest
-	if err := decodeJSON(r, &req); err != nil {
+	if err := h.decodeJSON(w, r, &req); err != nil {
 		h.logger.Error("decode request failed", slog.Any("error", err))
-		respondError(w, http.StatusBadRequest, "invalid json")
+		respondDecodeError(w, err)
 		return
 	}
 
@@ -116,18 +265,31 @@ func (h *Handler) handleDecision(w http.ResponseWriter, r *http.Request) {
 	}
 	if req.RequestID != "" {
 		if _, err := uuid.Parse(req.RequestID); err != nil {
-			respondError(w, http.StatusBadRequest, "invalid request_id")
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, "invalid request_id")
+			return
+		}
+		// The stored requested_mode has the default applied, so a retry that
+		// omits mode has to be compared after defaulting too.
+		if err := h.applyDefaultMode(&req.Context); err != nil {
+			h.logger.Error("settings read failed", slog.Any("error", err))
+			respondError(w, http.StatusInternalServerError, codeSettingsError, "settings error")
+			return
+		}
+		// Hold the request_id until the decision is stored, so a retry sent
+		// while the model is still answering waits and then replays it rather
+		// than calling the model and charging the budget again.
+		release, err := h.decisions.reserve(r.Context(), req.RequestID)
+		if err != nil {
+			respondError(w, http.StatusConflict, codeConflict, "request_id still in progress")
+			return
+		}
+		defer release()
+		if h.replayDecision(w, req.RequestID, req.Context) {
 			return
 		}
 	}
-	if req.Context.Timestamp == 0 {
-		req.Context.Timestamp = time.Now().UnixMilli()
-	}
-	if req.Context.Signals == nil {
-		req.Context.Signals = map[string]string{}
-	}
-	if err := validateContext(req.Context); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+	clientContext := req.Context
+	if !h.prepareContext(w, &req.Context) {
 		return
 	}
 
@@ -136,25 +298,31 @@ func (h *Handler) handleDecision(w http.ResponseWriter, r *http.Request) {
 		requestID = uuid.NewString()
 	}
 
-	// If user actively inputs text, clear cooldown to allow conversation
-	if req.Context.UserText != "" {
-		h.gateway.ClearCooldown()
-		h.logger.Info("user text detected, cooldown cleared for conversation")
+	span.SetAttributes(attribute.String("request_id", requestID), attribute.String("mode", string(req.Context.Mode)))
+	if err := h.assignPolicyVariant(&req.Context, requestID); err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
 	}
 
-	if err := enrichSignals(h.store, h.focus, &req.Context); err != nil {
+	loc := userLocation(h.store)
+	var breakdown models.LatencyBreakdown
+	enrichStart := time.Now()
+	_, enrichSpan := tracer.Start(traceCtx, "enrich_signals")
+	if err := h.enrichContext(&req.Context, loc); err != nil {
+		enrichSpan.RecordError(err)
+		enrichSpan.SetStatus(codes.Error, "settings error")
+		enrichSpan.End()
 		h.logger.Error("settings read failed", slog.String("request_id", requestID), slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "settings error")
+		respondError(w, http.StatusInternalServerError, codeSettingsError, "settings error")
 		return
 	}
-	// Inject Memory
-	req.Context.ProfileSummary = h.memory.GetProfileSummary()
-	req.Context.MemorySummary = h.memory.GetRecentEvents(5)
+	enrichSpan.End()
+	breakdown.EnrichMs = time.Since(enrichStart).Milliseconds()
 
 	decisionSettings, err := loadDecisionSettings(h.store)
 	if err != nil {
 		h.logger.Error("settings read failed", slog.String("request_id", requestID), slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "settings error")
+		respondError(w, http.StatusInternalServerError, codeSettingsError, "settings error")
 		return
 	}
 	if !decisionSettings.AgentEnabled || decisionSettings.RuleOnly {
@@ -165,58 +333,98 @@ func (h *Handler) handleDecision(w http.ResponseWriter, r *http.Request) {
 			Cost:       0,
 			RiskLevel:  models.RiskLow,
 		}
-		h.respondWithAction(w, requestID, req.Context, action, decisionSettings.policyVersion(), "n/a", 0)
+		h.respondWithAction(w, requestID, clientContext, req.Context, action, decisionSettings.policyVersion(), "n/a", breakdown, nil)
 		return
 	}
 
 	quietHours := req.Context.Signals["quiet_hours"]
 	if quietHours == "" {
-		if value, ok, err := h.store.GetSetting(settingQuietHours); err == nil && ok {
-			quietHours = value
+		quietHours, _ = settings.New(h.store).GetString(settings.QuietHours, "")
+	}
+	now := time.Now().In(loc)
+
+	var auto *autoSuggestionCheck
+	if req.Context.UserText == "" {
+		check, err := h.shouldAllowAutoSuggestion(req.Context, now, quietHours)
+		if err != nil {
+			h.logger.Error("auto suggestion check failed", slog.String("request_id", requestID), slog.Any("error", err))
+			respondError(w, http.StatusInternalServerError, codeBudgetError, "auto suggestion error")
+			return
 		}
+		auto = &check
 	}
-	if quietHours != "" && withinQuietHours(time.Now(), quietHours) {
+
+	if quietHours != "" && withinQuietHours(now, quietHours) {
 		action := models.Action{
 			ActionType: models.ActionDoNotDisturb,
-			Message:    "安静时段内，已暂停提示。",
+			Message:    h.renderMessage(settings.MessageQuietHoursTemplate, req.Context, "quiet_hours", "安静时段内，已暂停提示。"),
 			Confidence: 1,
 			Cost:       0,
 			RiskLevel:  models.RiskLow,
 		}
-		h.respondWithAction(w, requestID, req.Context, action, "quiet_hours", "n/a", 0)
+		h.respondWithAction(w, requestID, clientContext, req.Context, action, "quiet_hours", "n/a", breakdown, auto)
 		return
 	}
-
-	if req.Context.UserText == "" {
-		allowed, reason, err := h.shouldAllowAutoSuggestion(req.Context)
-		if err != nil {
-			h.logger.Error("auto suggestion check failed", slog.String("request_id", requestID), slog.Any("error", err))
-			respondError(w, http.StatusInternalServerError, "auto suggestion error")
-			return
-		}
-		if !allowed {
-			action := models.Action{
-				ActionType: models.ActionDoNotDisturb,
-				Message:    autoSuggestionMessage(reason),
-				Confidence: 1,
-				Cost:       0,
-				RiskLevel:  models.RiskLow,
-			}
-			h.respondWithAction(w, requestID, req.Context, action, "auto_guard", "n/a", 0)
-			return
+	if auto != nil && !auto.Allowed {
+		action := models.Action{
+			ActionType: models.ActionDoNotDisturb,
+			Message:    h.renderMessage(settings.MessageAutoGuardTemplate, req.Context, auto.Reason, autoSuggestionMessage(auto.Reason)),
+			Confidence: 1,
+			Cost:       0,
+			RiskLevel:  models.RiskLow,
 		}
+		h.respondWithAction(w, requestID, clientContext, req.Context, action, "auto_guard", "n/a", breakdown, auto)
+		return
 	}
 
+	_, aiSpan := tracer.Start(traceCtx, "ai.decide", trace.WithAttributes(attribute.String("mode", string(req.Context.Mode))))
 	start := time.Now()
+	stopProgress := h.logAIProgress(r.Context(), requestID, start)
 	rawAction, policyVersion, modelVersion, err := h.ai.Decide(req.Context, requestID)
+	stopProgress()
+	policyVersion = models.WithPolicyVariant(policyVersion, req.Context.Signals[policyVariantSignal])
 	latency := time.Since(start).Milliseconds()
+	breakdown.AIMs = latency
+	var invalid ai.InvalidResponseError
+	if errors.As(err, &invalid) {
+		aiSpan.RecordError(err)
+		aiSpan.End()
+		h.logger.Warn("ai response invalid, falling back to rules", slog.String("request_id", requestID), slog.Any("error", err))
+		h.logger.Debug("invalid ai response", slog.String("request_id", requestID), slog.String("raw", truncateUTF8(invalid.Raw, maxLoggedAIResponse)))
+		action := models.Action{
+			ActionType: models.ActionDoNotDisturb,
+			Message:    "AI 返回结果无效，已暂停提示。",
+			Confidence: 1,
+			Cost:       0,
+			RiskLevel:  models.RiskLow,
+		}
+		h.respondWithAction(w, requestID, clientContext, req.Context, action, aiFallbackPolicy, "n/a", breakdown, auto)
+		return
+	}
 	if err != nil {
+		aiSpan.RecordError(err)
+		aiSpan.SetStatus(codes.Error, "ai service unavailable")
+		aiSpan.End()
 		h.logger.Error("ai decide failed", slog.String("request_id", requestID), slog.Any("error", err))
-		respondError(w, http.StatusBadGateway, "ai service unavailable")
+		respondError(w, http.StatusBadGateway, codeAIUnavailable, "ai service unavailable")
 		return
 	}
+	aiSpan.SetAttributes(attribute.String("action_type", string(rawAction.ActionType)), attribute.String("model_version", modelVersion))
+	aiSpan.End()
 
-	finalAction, gatewayDecision := h.gateway.Evaluate(req.Context, rawAction)
+	_, gatewaySpan := tracer.Start(traceCtx, "gateway.evaluate", trace.WithAttributes(attribute.String("mode", string(req.Context.Mode))))
+	gatewayStart := time.Now()
+	finalAction, gatewayDecision := h.evaluateModel(req.Context, rawAction)
+	breakdown.GatewayMs = time.Since(gatewayStart).Milliseconds()
+	gatewaySpan.SetAttributes(
+		attribute.String("action_type", string(finalAction.ActionType)),
+		attribute.String("decision", string(gatewayDecision.Decision)),
+	)
+	gatewaySpan.End()
+	span.SetAttributes(
+		attribute.String("action_type", string(finalAction.ActionType)),
+		attribute.String("decision", string(gatewayDecision.Decision)),
+	)
 	createdAt := time.Now()
 
 	resp := models.DecisionResponse{
@@ -230,6 +438,9 @@ func (h *Handler) handleDecision(w http.ResponseWriter, r *http.Request) {
 		CreatedAtMs:     createdAt.UnixMilli(),
 		GatewayDecision: gatewayDecision,
 	}
+	if auto != nil {
+		resp.AutoSuggestionsRemaining = auto.Remaining
+	}
 
 	logEntry := models.DecisionLogEntry{
 		RequestID:       requestID,
@@ -244,16 +455,34 @@ func (h *Handler) handleDecision(w http.ResponseWriter, r *http.Request) {
 		CreatedAtMs:     createdAt.UnixMilli(),
 	}
 
+	_, persistSpan := tracer.Start(traceCtx, "store.insert_decision")
+	persistStart := time.Now()
 	if err := h.store.InsertDecision(logEntry); err != nil {
+		persistSpan.RecordError(err)
+		persistSpan.SetStatus(codes.Error, "db error")
+		persistSpan.End()
+		if errors.Is(err, db.ErrDuplicateRequestID) {
+			h.respondDuplicateDecision(w, requestID, clientContext)
+			return
+		}
 		h.logger.Error("insert decision failed", slog.String("request_id", requestID), slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "db error")
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
 		return
 	}
+	persistSpan.End()
+	breakdown.PersistMs = time.Since(persistStart).Milliseconds()
+	breakdown.TotalMs = breakdown.EnrichMs + breakdown.AIMs + breakdown.GatewayMs + breakdown.PersistMs
+	resp.LatencyBreakdown = breakdown
 
 	h.logger.Info(
 		"decision",
 		slog.String("request_id", requestID),
+		slog.String("requested_mode", requestedMode(req.Context)),
+		slog.String("effective_mode", string(req.Context.Mode)),
 		slog.Int64("latency_ms", latency),
+		slog.Int64("enrich_ms", breakdown.EnrichMs),
+		slog.Int64("gateway_ms", breakdown.GatewayMs),
+		slog.Int64("persist_ms", breakdown.PersistMs),
 		slog.String("policy_version", policyVersion),
 		slog.String("model_version", modelVersion),
 		slog.String("gateway_decision", string(gatewayDecision.Decision)),
@@ -269,38 +498,27 @@ func (h *Handler) handleDecision(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) handleFeedback(w http.ResponseWriter, r *http.Request) {
 	var req models.FeedbackRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid json")
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
 		return
 	}
 	if err := validateFeedback(req); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	exists, err := h.store.DecisionExists(req.RequestID)
-	if err != nil {
-		h.logger.Error("check request_id failed", slog.String("request_id", req.RequestID), slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "db error")
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
 		return
 	}
-	if !exists {
-		respondError(w, http.StatusNotFound, "request_id not found")
+	if !h.checkFeedbackTarget(w, req) {
 		return
 	}
 
-	feedbackValue := string(req.Feedback)
-	if req.FeedbackText != "" {
-		feedbackValue = string(req.Feedback) + ": " + req.FeedbackText
-	}
+	feedbackValue := formatFeedback(req)
 
-	if err := h.store.RecordFeedback(req.RequestID, feedbackValue); err != nil {
+	if err := h.store.RecordFeedback(req.RequestID, feedbackValue, req.Rating); err != nil {
 		h.logger.Error("record feedback failed", slog.String("request_id", req.RequestID), slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "db error")
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
 		return
 	}
-	if isImplicitFeedback(req.Feedback) {
-		if err := h.store.RecordImplicitFeedback(req.RequestID, string(req.Feedback), req.FeedbackText); err != nil {
+	if isImplicitFeedback(req.Feedback) || req.ReasonCode != "" {
+		if err := h.store.RecordImplicitFeedback(req.RequestID, string(req.Feedback), req.FeedbackText, string(req.ReasonCode)); err != nil {
 			h.logger.Error("record implicit feedback failed", slog.String("request_id", req.RequestID), slog.Any("error", err))
 		}
 	}
@@ -309,7 +527,7 @@ func (h *Handler) handleFeedback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update Memory
-	if err := h.memory.ProcessFeedback(req.RequestID, feedbackValue); err != nil {
+	if err := h.memory.ProcessFeedback(req.RequestID, feedbackValue, req.ReasonCode, req.Rating); err != nil {
 		h.logger.Error("process feedback failed", slog.String("request_id", req.RequestID), slog.Any("error", err))
 	}
 
@@ -320,6 +538,7 @@ func (h *Handler) handleFeedback(w http.ResponseWriter, r *http.Request) {
 		slog.String("request_id", req.RequestID),
 		slog.String("type", string(req.Feedback)),
 		slog.String("text", req.FeedbackText),
+		slog.Int("rating", req.Rating),
 	)
 
 	// If feedback has text, generate AI response for conversation
@@ -334,16 +553,18 @@ func (h *Handler) handleFeedback(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Enrich context
-		if err := enrichSignals(h.store, h.focus, &req.Context); err != nil {
+		loc := userLocation(h.store)
+		if err := enrichSignals(h.store, h.focus, &req.Context, loc, h.minutesPrec); err != nil {
 			h.logger.Warn("failed to enrich signals for reply", slog.Any("error", err))
 		}
-		req.Context.ProfileSummary = h.memory.GetProfileSummary()
-		req.Context.MemorySummary = h.memory.GetRecentEvents(5)
+		h.injectMemory(&req.Context, loc)
 
 		// Generate reply
 		newRequestID := uuid.NewString()
 		start := time.Now()
+		stopProgress := h.logAIProgress(r.Context(), newRequestID, start)
 		rawAction, policyVersion, modelVersion, err := h.ai.Decide(req.Context, newRequestID)
+		stopProgress()
 		latency := time.Since(start).Milliseconds()
 
 		if err != nil {
@@ -352,7 +573,7 @@ func (h *Handler) handleFeedback(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		finalAction, gatewayDecision := h.gateway.Evaluate(req.Context, rawAction)
+		finalAction, gatewayDecision := h.evaluateModel(req.Context, rawAction)
 		createdAt := time.Now()
 
 		resp := models.DecisionResponse{
@@ -399,32 +620,160 @@ func (h *Handler) handleFeedback(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-func (h *Handler) handleLogs(w http.ResponseWriter, r *http.Request) {
-	limit := 50
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := parseInt(l); err == nil {
-			limit = parsed
-		}
+// applyDefaultMode fills an omitted mode from default_mode. An explicit mode
+// always wins.
+func (h *Handler) applyDefaultMode(ctx *models.Context) error {
+	if ctx.Mode != "" {
+		return nil
 	}
-	var sinceMs int64
-	if s := r.URL.Query().Get("since_ms"); s != "" {
-		if parsed, err := parseInt64(s); err == nil {
-			sinceMs = parsed
-		}
+	mode, err := settings.New(h.store).GetString(settings.DefaultMode, "")
+	if err != nil {
+		return err
+	}
+	if mode != "none" {
+		ctx.Mode = models.Mode(mode)
+	}
+	return nil
+}
+
+// prepareContext fills defaults into a client-supplied decision context and
+// validates it, responding with the error and returning false when invalid.
+func (h *Handler) prepareContext(w http.ResponseWriter, ctx *models.Context) bool {
+	if ctx.Timestamp == 0 {
+		ctx.Timestamp = time.Now().UnixMilli()
+	}
+	if ctx.Signals == nil {
+		ctx.Signals = map[string]string{}
+	}
+	if err := h.applyDefaultMode(ctx); err != nil {
+		h.logger.Error("settings read failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeSettingsError, "settings error")
+		return false
+	}
+	if err := validateContext(*ctx); err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return false
+	}
+	strictSignals, err := settings.New(h.store).GetBool(settings.StrictSignals, false)
+	if err != nil {
+		h.logger.Error("settings read failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeSettingsError, "settings error")
+		return false
+	}
+	dropped, err := sanitizeSignals(ctx.Signals, strictSignals)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return false
+	}
+	if len(dropped) > 0 {
+		h.logger.Debug("unknown signals dropped", slog.Any("keys", dropped))
+	}
+	return true
+}
+
+// enrichContext adds the server-side signals, auto mode and memory the model
+// sees on top of what the client sent.
+func (h *Handler) enrichContext(ctx *models.Context, loc *time.Location) error {
+	if err := enrichSignals(h.store, h.focus, ctx, loc, h.minutesPrec); err != nil {
+		return err
+	}
+	if err := applyAutoMode(h.store, ctx); err != nil {
+		return err
+	}
+	h.injectMemory(ctx, loc)
+	return nil
+}
+
+// handleDecisionDebug returns the context a decision request would be
+// enriched into and, when the backend renders its prompt in-process, the
+// prompt itself. Nothing is sent to the model, logged or charged. It exposes
+// memory and focus data, so it is off unless debug_prompts_enabled is set.
+func (h *Handler) handleDecisionDebug(w http.ResponseWriter, r *http.Request) {
+	enabled, err := settings.New(h.store).GetBool(settings.DebugPrompts, false)
+	if err != nil {
+		h.logger.Error("settings read failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeSettingsError, "settings error")
+		return
+	}
+	if !enabled {
+		respondError(w, http.StatusForbidden, codeDebugDisabled, "decision debug disabled")
+		return
+	}
+	var req models.DecisionRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	if !h.prepareContext(w, &req.Context) {
+		return
+	}
+	if err := h.assignPolicyVariant(&req.Context, uuid.NewString()); err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if err := h.enrichContext(&req.Context, userLocation(h.store)); err != nil {
+		h.logger.Error("settings read failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeSettingsError, "settings error")
+		return
 	}
-	var untilMs int64
-	if s := r.URL.Query().Get("until_ms"); s != "" {
-		if parsed, err := parseInt64(s); err == nil {
-			untilMs = parsed
+	resp := models.DecisionDebugResponse{Context: req.Context}
+	if renderer, ok := h.ai.(ai.PromptRenderer); ok {
+		prompt, err := renderer.RenderPrompt(req.Context)
+		if err != nil {
+			h.logger.Error("render prompt failed", slog.Any("error", err))
+			respondError(w, http.StatusInternalServerError, codeInternalError, "render prompt failed")
+			return
 		}
+		resp.Prompt = prompt
+		resp.PromptAvailable = true
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) injectMemory(ctx *models.Context, loc *time.Location) {
+	ctx.ProfileSummary = h.memory.GetProfileSummary()
+	if enabled, _ := settings.New(h.store).GetBool(settings.FocusContext, false); enabled {
+		ctx.MemorySummary = h.memory.GetRecentEventsWithFocus(5, time.Now())
+	} else {
+		ctx.MemorySummary = h.memory.GetRecentEvents(5)
+	}
+	if tolerance, ok := h.memory.HourTolerance(time.Now().In(loc).Hour()); ok {
+		ctx.Signals["hour_tolerance"] = tolerance
+	}
+}
+
+func (h *Handler) handleLogs(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parsePagination(w, r, 50)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
 	}
+	limit, sinceMs, untilMs := params.Limit, params.SinceMs, params.UntilMs
 	aggregate := r.URL.Query().Get("aggregate")
-	logs, err := h.store.ListLogsRange(limit, sinceMs, untilMs)
+	paged := wantsPage(r)
+	fetchLimit := limit
+	if paged {
+		fetchLimit = limit + 1
+	}
+	var logs []models.EventLog
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		if !isValidTag(tag) {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, "invalid tag")
+			return
+		}
+		logs, err = h.store.ListByTag(tag, fetchLimit, sinceMs, untilMs)
+	} else {
+		logs, err = h.store.ListLogsRange(fetchLimit, sinceMs, untilMs)
+	}
 	if err != nil {
 		h.logger.Error("list logs failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "db error")
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
 		return
 	}
+	hasMore := paged && len(logs) > limit
+	if hasMore {
+		logs = logs[:limit]
+	}
 	if aggregate == "1" || strings.EqualFold(aggregate, "true") {
 		respondJSON(w, http.StatusOK, map[string]any{
 			"logs":      logs,
@@ -432,266 +781,993 @@ func (h *Handler) handleLogs(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if paged {
+		respondJSON(w, http.StatusOK, newPage(logs, len(logs), limit, hasMore))
+		return
+	}
 	respondJSON(w, http.StatusOK, logs)
 }
 
-func (h *Handler) handleFocusCurrent(w http.ResponseWriter, r *http.Request) {
-	if h.focus == nil || !h.focus.Enabled() {
-		respondJSON(w, http.StatusOK, models.FocusCurrent{})
+// handleLogsUnrated lists the newest decisions still waiting for feedback,
+// for building a review queue.
+func (h *Handler) handleLogsUnrated(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parsePagination(w, r, 50)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
 		return
 	}
-	current, ok, err := h.focus.Current()
+	paged := wantsPage(r)
+	fetchLimit := params.Limit
+	if paged {
+		fetchLimit = params.Limit + 1
+	}
+	logs, err := h.store.ListUnratedDecisions(fetchLimit, params.SinceMs, params.UntilMs)
 	if err != nil {
-		h.logger.Error("focus current failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "focus error")
+		h.logger.Error("list unrated logs failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
 		return
 	}
-	if !ok {
-		respondJSON(w, http.StatusOK, models.FocusCurrent{})
+	if paged {
+		hasMore := len(logs) > params.Limit
+		if hasMore {
+			logs = logs[:params.Limit]
+		}
+		respondJSON(w, http.StatusOK, newPage(logs, len(logs), params.Limit, hasMore))
 		return
 	}
-	respondJSON(w, http.StatusOK, current)
+	respondJSON(w, http.StatusOK, logs)
 }
 
-func (h *Handler) handleFocusRecent(w http.ResponseWriter, r *http.Request) {
-	limit := 200
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := parseInt(l); err == nil {
-			limit = parsed
-		}
-	}
-	events, err := h.store.ListFocusEvents(limit)
+// handleLogsEnriched is /v1/logs with each decision's explicit feedback
+// history and implicit events inlined.
+func (h *Handler) handleLogsEnriched(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parsePagination(w, r, 50)
 	if err != nil {
-		h.logger.Error("focus recent failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "db error")
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
 		return
 	}
-	respondJSON(w, http.StatusOK, events)
-}
-
-func (h *Handler) handleExport(w http.ResponseWriter, r *http.Request) {
-	limit := 1000
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := parseInt(l); err == nil {
-			limit = parsed
-		}
+	tag := r.URL.Query().Get("tag")
+	if tag != "" && !isValidTag(tag) {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "invalid tag")
+		return
 	}
-	var sinceMs int64
-	if s := r.URL.Query().Get("since_ms"); s != "" {
-		if parsed, err := parseInt64(s); err == nil {
-			sinceMs = parsed
-		}
+	paged := wantsPage(r)
+	fetchLimit := params.Limit
+	if paged {
+		fetchLimit = params.Limit + 1
 	}
-
-	records, err := h.store.ExportRecords(limit, sinceMs)
+	logs, err := h.store.ListEnrichedLogs(tag, fetchLimit, params.SinceMs, params.UntilMs)
 	if err != nil {
-		h.logger.Error("export logs failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "db error")
+		h.logger.Error("list enriched logs failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/x-ndjson")
-	w.WriteHeader(http.StatusOK)
-	writer := bufio.NewWriter(w)
-	encoder := json.NewEncoder(writer)
-	for _, record := range records {
-		if err := encoder.Encode(record); err != nil {
-			h.logger.Error("export encode failed", slog.Any("error", err))
-			break
+	if paged {
+		hasMore := len(logs) > params.Limit
+		if hasMore {
+			logs = logs[:params.Limit]
 		}
+		respondJSON(w, http.StatusOK, newPage(logs, len(logs), params.Limit, hasMore))
+		return
 	}
-	_ = writer.Flush()
+	respondJSON(w, http.StatusOK, logs)
 }
 
-func (h *Handler) handleSettingsGet(w http.ResponseWriter, r *http.Request) {
-	settings, err := h.store.ListSettings()
-	if err != nil {
-		h.logger.Error("list settings failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "db error")
+func (h *Handler) handleFocusStatus(w http.ResponseWriter, _ *http.Request) {
+	if h.focus == nil {
+		respondJSON(w, http.StatusOK, models.FocusStatus{})
 		return
 	}
-	respondJSON(w, http.StatusOK, settings)
+	respondJSON(w, http.StatusOK, h.focus.Status())
 }
 
-func (h *Handler) handleSettingsPost(w http.ResponseWriter, r *http.Request) {
-	var req models.SettingRequest
-	if err := decodeJSON(r, &req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid json")
+func (h *Handler) handleFocusCurrent(w http.ResponseWriter, r *http.Request) {
+	if h.focus == nil || !h.focus.Enabled() {
+		respondJSON(w, http.StatusOK, models.FocusCurrent{})
 		return
 	}
-	if strings.TrimSpace(req.Key) == "" {
-		respondError(w, http.StatusBadRequest, "key required")
+	if _, ok, err := h.focus.Current(); err != nil {
+		h.logger.Error("focus current failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeFocusError, "focus error")
 		return
-	}
-	if strings.TrimSpace(req.Value) == "" {
-		respondError(w, http.StatusBadRequest, "value required")
+	} else if !ok {
+		respondJSON(w, http.StatusOK, models.FocusCurrent{})
 		return
 	}
-	if !allowedSettings[req.Key] {
-		respondError(w, http.StatusBadRequest, "unsupported setting key")
+	reading, ok := readFocusState(h.store, h.focus)
+	if !ok || reading.Current == nil {
+		respondError(w, http.StatusInternalServerError, codeFocusError, "focus error")
 		return
 	}
-	normalizedValue, err := normalizeSettingValue(req.Key, req.Value)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+	current := *reading.Current
+	current.FocusMinutes = focus.RoundMinutes(current.FocusMinutes, h.minutesPrec)
+	respondJSON(w, http.StatusOK, current)
+}
+
+func (h *Handler) handleFocusEvent(w http.ResponseWriter, r *http.Request) {
+	var req models.FocusEventRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
 		return
 	}
-	req.Value = normalizedValue
-
-	if err := h.store.UpsertSetting(req.Key, req.Value); err != nil {
-		h.logger.Error("update setting failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "db error")
+	if err := validateFocusEvent(req); err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
 		return
 	}
-	if req.Key == settingFocusMonitor && h.focus != nil {
-		enabled := req.Value == "true"
-		if err := h.focus.SetEnabled(enabled); err != nil && !errors.Is(err, focus.ErrUnsupported) {
-			h.logger.Error("focus toggle failed", slog.Any("error", err))
-		}
+	if h.focus == nil {
+		respondError(w, http.StatusServiceUnavailable, codeFocusUnavailable, "focus monitor unavailable")
+		return
 	}
-	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-}
-
-func (h *Handler) handleHealth(w http.ResponseWriter, _ *http.Request) {
-	now := time.Now()
-	respondJSON(w, http.StatusOK, map[string]any{
-		"status":     "ok",
-		"started_at": h.started.Format(time.RFC3339Nano),
-		"uptime_ms":  now.Sub(h.started).Milliseconds(),
-	})
-}
-
-func (h *Handler) handleMemoryReset(w http.ResponseWriter, _ *http.Request) {
-	if err := h.memory.Reset(); err != nil {
-		h.logger.Error("memory reset failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "memory reset failed")
+	err := h.focus.Ingest(focusSnapshot(req))
+	if errors.Is(err, focus.ErrIngestDisabled) {
+		respondError(w, http.StatusForbidden, codeIngestDisabled, "focus ingestion disabled")
 		return
 	}
-	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-}
-
-func (h *Handler) handleProfile(w http.ResponseWriter, _ *http.Request) {
-	profiles, err := h.memory.ListProfiles()
 	if err != nil {
-		h.logger.Error("list profiles failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "profiles error")
+		h.logger.Error("focus ingest failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeFocusError, "focus error")
 		return
 	}
-	respondJSON(w, http.StatusOK, map[string]any{
-		"summary":  h.memory.GetProfileSummary(),
-		"profiles": profiles,
-	})
+	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-func (h *Handler) handleLearningExplanations(w http.ResponseWriter, r *http.Request) {
-	limit := 20
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := parseInt(l); err == nil {
-			limit = parsed
-		}
+// maxFocusBatch bounds how many snapshots one POST /v1/focus/events applies.
+const maxFocusBatch = 1000
+
+// handleFocusEvents ingests snapshots buffered by an offline tracker. They
+// are applied in order in one transaction, timed by their own ts_ms, and the
+// whole batch is rejected when a timestamp goes backwards.
+func (h *Handler) handleFocusEvents(w http.ResponseWriter, r *http.Request) {
+	var req models.FocusEventBatchRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
 	}
-	profiles, err := h.memory.ListProfiles()
-	if err != nil {
-		h.logger.Error("list profiles failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "profiles error")
+	if len(req.Events) == 0 {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "events required")
 		return
 	}
-	events, err := h.memory.ListEvents(limit)
-	if err != nil {
-		h.logger.Error("list memory events failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "memory events error")
+	if len(req.Events) > maxFocusBatch {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("at most %d events per batch", maxFocusBatch))
 		return
 	}
-	explanations := buildLearningExplanations(profiles)
-	respondJSON(w, http.StatusOK, map[string]any{
-		"summary":      h.memory.GetProfileSummary(),
-		"explanations": explanations,
-		"profiles":     profiles,
-		"events":       events,
-	})
-}
-
-func (h *Handler) handleStateHistory(w http.ResponseWriter, r *http.Request) {
-	limit := 200
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := parseInt(l); err == nil {
-			limit = parsed
+	snapshots := make([]focus.FocusSnapshot, 0, len(req.Events))
+	for i, event := range req.Events {
+		if err := validateFocusEvent(event); err != nil {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("events[%d]: %v", i, err))
+			return
 		}
-	}
-	var sinceMs int64
-	if s := r.URL.Query().Get("since_ms"); s != "" {
-		if parsed, err := parseInt64(s); err == nil {
-			sinceMs = parsed
+		if event.TsMs == 0 {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("events[%d]: ts_ms required", i))
+			return
 		}
+		snapshots = append(snapshots, focusSnapshot(event))
 	}
-	var untilMs int64
-	if s := r.URL.Query().Get("until_ms"); s != "" {
-		if parsed, err := parseInt64(s); err == nil {
-			untilMs = parsed
-		}
+	if h.focus == nil {
+		respondError(w, http.StatusServiceUnavailable, codeFocusUnavailable, "focus monitor unavailable")
+		return
+	}
+	err := h.focus.IngestBatch(snapshots)
+	if errors.Is(err, focus.ErrIngestDisabled) {
+		respondError(w, http.StatusForbidden, codeIngestDisabled, "focus ingestion disabled")
+		return
+	}
+	var outOfOrder focus.OutOfOrderError
+	if errors.As(err, &outOfOrder) {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("events[%d]: ts_ms %d is before %d", outOfOrder.Index, outOfOrder.TsMs, outOfOrder.AfterMs))
+		return
 	}
-	snapshots, err := h.store.ListFocusStateSnapshots(limit, sinceMs, untilMs)
 	if err != nil {
-		h.logger.Error("list state history failed", slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "state history error")
+		h.logger.Error("focus batch ingest failed", slog.Int("events", len(snapshots)), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeFocusError, "focus error")
 		return
 	}
-	respondJSON(w, http.StatusOK, snapshots)
+	respondJSON(w, http.StatusOK, map[string]any{"status": "ok", "ingested": len(snapshots)})
 }
 
-func (h *Handler) handleOllamaModels(w http.ResponseWriter, r *http.Request) {
-	tagsURL := ollamaTagsURL()
-	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, tagsURL, nil)
+func validateFocusEvent(req models.FocusEventRequest) error {
+	if strings.TrimSpace(req.AppName) == "" {
+		return errors.New("app_name required")
+	}
+	if req.TsMs < 0 {
+		return errors.New("invalid ts_ms")
+	}
+	if req.LastInputMs < 0 {
+		return errors.New("invalid last_input_ms")
+	}
+	return nil
+}
+
+func focusSnapshot(req models.FocusEventRequest) focus.FocusSnapshot {
+	return focus.FocusSnapshot{
+		TsMs:        req.TsMs,
+		AppName:     strings.TrimSpace(req.AppName),
+		BundleID:    strings.TrimSpace(req.BundleID),
+		PID:         req.PID,
+		WindowTitle: req.WindowTitle,
+		LastInputMs: req.LastInputMs,
+	}
+}
+
+// handleFocusPurge wipes focus history recorded before ?before_ms=, or all of
+// it with ?all=true. One of the two is required so a bare DELETE cannot erase
+// everything by accident.
+func (h *Handler) handleFocusPurge(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var beforeMs int64
+	if raw := query.Get("before_ms"); raw != "" {
+		parsed, err := parseInt64(raw)
+		if err != nil || parsed <= 0 {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, "invalid before_ms")
+			return
+		}
+		beforeMs = parsed
+	} else if all, err := strconv.ParseBool(query.Get("all")); err != nil || !all {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "before_ms or all=true required")
+		return
+	}
+	if h.focus == nil {
+		respondError(w, http.StatusServiceUnavailable, codeFocusUnavailable, "focus monitor unavailable")
+		return
+	}
+	result, err := h.focus.Purge(beforeMs)
+	if err != nil {
+		h.logger.Error("focus purge failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	h.logger.Info("focus history purged",
+		slog.Int64("before_ms", beforeMs),
+		slog.Int64("events", result.EventsDeleted),
+		slog.Int64("snapshots", result.SnapshotsDeleted))
+	respondJSON(w, http.StatusOK, result)
+}
+
+const defaultFocusAnalyticsWindow = 24 * time.Hour
+
+// handleFocusAnalytics reports focus KPIs for [since_ms, until_ms), defaulting
+// to the last 24 hours.
+func (h *Handler) handleFocusAnalytics(w http.ResponseWriter, r *http.Request) {
+	sinceMs, untilMs, err := parseTimeRange(r, defaultFocusAnalyticsWindow)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	analytics, err := h.store.FocusAnalytics(sinceMs, untilMs, userLocation(h.store))
+	if err != nil {
+		h.logger.Error("focus analytics failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	if h.focus != nil && h.focus.RedactTitles() {
+		for i := range analytics.Sessions {
+			analytics.Sessions[i].WindowTitle = ""
+		}
+	}
+	round := func(minutes float64) float64 { return focus.RoundMinutes(minutes, h.minutesPrec) }
+	analytics.FocusedMinutes = round(analytics.FocusedMinutes)
+	analytics.DistractedMinutes = round(analytics.DistractedMinutes)
+	analytics.NoProgressMinutes = round(analytics.NoProgressMinutes)
+	analytics.AvgSessionMinutes = round(analytics.AvgSessionMinutes)
+	analytics.LongestSessionMinutes = round(analytics.LongestSessionMinutes)
+	for i := range analytics.TopApps {
+		analytics.TopApps[i].FocusMinutes = round(analytics.TopApps[i].FocusMinutes)
+	}
+	respondJSON(w, http.StatusOK, analytics)
+}
+
+func (h *Handler) handleFocusState(w http.ResponseWriter, r *http.Request) {
+	reading, ok := readFocusState(h.store, h.focus)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, codeFocusError, "focus error")
+		return
+	}
+	if reading.State == "" {
+		reading.State = focus.StateUnknown
+	}
+	reading.FocusMinutes = focus.RoundMinutes(reading.FocusMinutes, h.minutesPrec)
+	if reading.Current != nil {
+		reading.Current.FocusMinutes = focus.RoundMinutes(reading.Current.FocusMinutes, h.minutesPrec)
+	}
+	respondJSON(w, http.StatusOK, reading)
+}
+
+func (h *Handler) handleFocusRecent(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parsePagination(w, r, 200)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	limit := params.Limit
+	query := r.URL.Query()
+	paged := wantsPage(r)
+	filter := models.FocusEventFilter{
+		AppName:  strings.TrimSpace(query.Get("app")),
+		BundleID: strings.TrimSpace(query.Get("bundle_id")),
+		Limit:    limit,
+		SinceMs:  params.SinceMs,
+		UntilMs:  params.UntilMs,
+	}
+	if paged {
+		filter.Limit = limit + 1
+	}
+	events, err := h.store.ListFocusEventsFiltered(filter)
+	if err != nil {
+		h.logger.Error("focus recent failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	hasMore := paged && len(events) > limit
+	if hasMore {
+		events = events[:limit]
+	}
+	if h.focus != nil && h.focus.RedactTitles() {
+		for i := range events {
+			events[i].WindowTitle = ""
+		}
+	}
+	filtered := filter.AppName != "" || filter.BundleID != "" || filter.SinceMs > 0 || filter.UntilMs > 0
+	if paged {
+		page := newPage(events, len(events), limit, hasMore)
+		if filtered {
+			page["total_duration_ms"] = focusEventsDuration(events, time.Now().UnixMilli())
+		}
+		respondJSON(w, http.StatusOK, page)
+		return
+	}
+	if filtered {
+		respondJSON(w, http.StatusOK, map[string]any{
+			"events":            events,
+			"total_duration_ms": focusEventsDuration(events, time.Now().UnixMilli()),
+		})
+		return
+	}
+	respondJSON(w, http.StatusOK, events)
+}
+
+// focusEventsDuration sums event durations, counting a still-open event up to nowMs.
+func focusEventsDuration(events []models.FocusEvent, nowMs int64) int64 {
+	var total int64
+	for _, event := range events {
+		if event.DurationMs > 0 {
+			total += event.DurationMs
+			continue
+		}
+		if delta := nowMs - event.TsMs; delta > 0 {
+			total += delta
+		}
+	}
+	return total
+}
+
+func (h *Handler) handleExport(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parsePagination(w, r, 1000)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	fields, err := parseExportFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	records, err := h.store.ExportRecords(params.Limit, params.SinceMs, params.UntilMs)
+	if err != nil {
+		h.logger.Error("export logs failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+
+	// Large exports can outlive the server-wide WriteTimeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Warn("extend export deadline failed", slog.Any("error", err))
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		var line any = record
+		if fields != nil {
+			line = projectExport(record, fields)
+		}
+		if err := encoder.Encode(line); err != nil {
+			h.logger.Error("export encode failed", slog.Any("error", err))
+			break
+		}
+	}
+	_ = writer.Flush()
+}
+
+// handleSettingsGet lists stored settings, optionally narrowed to keys
+// starting with ?prefix= and/or to an explicit ?keys=a,b,c subset.
+func (h *Handler) handleSettingsGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var keys map[string]bool
+	if raw := query.Get("keys"); raw != "" {
+		keys = map[string]bool{}
+		for _, key := range strings.Split(raw, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			if !allowedSettings[key] {
+				respondError(w, http.StatusBadRequest, codeUnsupportedSetting, "unsupported setting key: "+key)
+				return
+			}
+			keys[key] = true
+		}
+	}
+	values, err := h.listSettings(query.Get("prefix"))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "ollama request error")
+		h.logger.Error("list settings failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	if keys != nil {
+		filtered := []models.SettingItem{}
+		for _, item := range values {
+			if keys[item.Key] {
+				filtered = append(filtered, item)
+			}
+		}
+		values = filtered
+	}
+	respondJSON(w, http.StatusOK, values)
+}
+
+func (h *Handler) handleSettingsPost(w http.ResponseWriter, r *http.Request) {
+	var req models.SettingRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	if strings.TrimSpace(req.Key) == "" {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "key required")
 		return
 	}
-	resp, err := http.DefaultClient.Do(req)
+	if strings.TrimSpace(req.Value) == "" {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "value required")
+		return
+	}
+	if !allowedSettings[req.Key] {
+		respondError(w, http.StatusBadRequest, codeUnsupportedSetting, "unsupported setting key")
+		return
+	}
+	normalizedValue, err := normalizeSettingValue(req.Key, req.Value)
 	if err != nil {
-		respondError(w, http.StatusBadGateway, "ollama unavailable")
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
 		return
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		respondError(w, http.StatusBadGateway, "ollama unavailable")
+	req.Value = normalizedValue
+
+	if err := h.settingsStore(req.Key).UpsertSetting(req.Key, req.Value); err != nil {
+		h.logger.Error("update setting failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
 		return
 	}
+	if req.Key == settings.FocusMonitorEnabled && h.focus != nil {
+		enabled := req.Value == "true"
+		if err := h.focus.SetEnabled(enabled); err != nil && !errors.Is(err, focus.ErrUnsupported) {
+			h.logger.Error("focus toggle failed", slog.Any("error", err))
+		}
+	}
+	if req.Key == settings.FocusIngestEnabled && h.focus != nil {
+		h.focus.SetIngestEnabled(req.Value == "true")
+	}
+	if req.Key == settings.FocusPollMs && h.focus != nil {
+		interval, _ := focus.ParsePollInterval(req.Value)
+		h.focus.SetPollInterval(interval)
+	}
+	if (req.Key == settings.FocusAppBlocklist || req.Key == settings.FocusAppAllowlist) && h.focus != nil {
+		h.focus.ReloadAppFilter()
+	}
+	if req.Key == settings.NoProgressInputReset && h.focus != nil {
+		h.focus.ReloadInputReset()
+	}
+	if req.Key == settings.RedactWindowTitles && h.focus != nil {
+		h.focus.SetRedactTitles(req.Value == "true")
+	}
+	if (req.Key == settings.BatteryBackoff || req.Key == settings.BatteryPollFactor) && h.focus != nil {
+		h.focus.RefreshPower()
+	}
+	if budgetSettings[req.Key] {
+		if warnings := h.gateway.ConfigWarnings(); len(warnings) > 0 {
+			respondJSON(w, http.StatusOK, map[string]any{"status": "ok", "warnings": warnings})
+			return
+		}
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handlePing is a liveness probe for load balancers: 200 with an empty body,
+// cheaper than /v1/health and left out of the request log by default.
+func handlePing(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
 
-	var payload struct {
-		Models []struct {
-			Name string `json:"name"`
-		} `json:"models"`
+func (h *Handler) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	now := time.Now()
+	respondJSON(w, http.StatusOK, map[string]any{
+		"status":     "ok",
+		"started_at": h.started.Format(time.RFC3339Nano),
+		"uptime_ms":  now.Sub(h.started).Milliseconds(),
+	})
+}
+
+func (h *Handler) handleMemoryReset(w http.ResponseWriter, _ *http.Request) {
+	if err := h.memory.Reset(); err != nil {
+		h.logger.Error("memory reset failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeMemoryError, "memory reset failed")
+		return
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		respondError(w, http.StatusBadGateway, "ollama invalid response")
+	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *Handler) handleMemoryExport(w http.ResponseWriter, _ *http.Request) {
+	snapshot, err := h.memory.Export()
+	if err != nil {
+		h.logger.Error("memory export failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeMemoryError, "memory export failed")
 		return
 	}
+	respondJSON(w, http.StatusOK, snapshot)
+}
+
+func (h *Handler) handleMemoryImport(w http.ResponseWriter, r *http.Request) {
+	var snapshot memory.Snapshot
+	if err := decodeJSONLimit(w, r, &snapshot, memoryImportMaxBytes); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	if err := memory.ValidateSnapshot(snapshot); err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	result, err := h.memory.Import(snapshot)
+	if err != nil {
+		h.logger.Error("memory import failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeMemoryError, "memory import failed")
+		return
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) handleMemoryForget(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgetRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	key := strings.TrimSpace(req.Key)
+	if key == "" {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "key required")
+		return
+	}
+	suppressFor := defaultForgetSuppression
+	if req.SuppressMinutes != nil {
+		if *req.SuppressMinutes < 0 {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, "invalid suppress_minutes")
+			return
+		}
+		suppressFor = time.Duration(*req.SuppressMinutes) * time.Minute
+	}
+	profile, ok, err := h.memory.Forget(key, suppressFor)
+	if err != nil {
+		h.logger.Error("memory forget failed", slog.String("key", key), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeMemoryError, "memory forget failed")
+		return
+	}
+	if !ok {
+		respondError(w, http.StatusNotFound, codeNotFound, "profile not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"forgotten":           profile,
+		"suppressed_until_ms": time.Now().Add(suppressFor).UnixMilli(),
+	})
+}
+
+// handleMemoryPreviewFeedback runs the memory learning for a feedback as a dry
+// run and returns the profile changes it would make, without writing them.
+func (h *Handler) handleMemoryPreviewFeedback(w http.ResponseWriter, r *http.Request) {
+	var req models.FeedbackRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	if err := validateFeedback(req); err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if !h.checkFeedbackTarget(w, req) {
+		return
+	}
+	preview, err := h.memory.PreviewFeedback(req.RequestID, formatFeedback(req), req.ReasonCode, req.Rating)
+	if err != nil {
+		h.logger.Error("preview feedback failed", slog.String("request_id", req.RequestID), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeMemoryError, "memory preview failed")
+		return
+	}
+	respondJSON(w, http.StatusOK, preview)
+}
+
+func (h *Handler) handleProfile(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.memory.ListProfiles()
+	if err != nil {
+		h.logger.Error("list profiles failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeMemoryError, "profiles error")
+		return
+	}
+	if wantsPage(r) {
+		params, err := h.parsePagination(w, r, len(profiles))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+		limit := params.Limit
+		hasMore := len(profiles) > limit
+		if hasMore {
+			profiles = profiles[:limit]
+		}
+		page := newPage(profiles, len(profiles), limit, hasMore)
+		page["summary"] = h.memory.GetProfileSummary()
+		respondJSON(w, http.StatusOK, page)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"summary":  h.memory.GetProfileSummary(),
+		"profiles": profiles,
+	})
+}
+
+func (h *Handler) handleLearningExplanations(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parsePagination(w, r, 20)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	order := memory.NormalizeEventOrder(r.URL.Query().Get("by"))
+	if order == "" {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "by must be recent or importance")
+		return
+	}
+	profiles, err := h.memory.ListProfiles()
+	if err != nil {
+		h.logger.Error("list profiles failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeMemoryError, "profiles error")
+		return
+	}
+	events, err := h.memory.ListEvents(params.Limit, order)
+	if err != nil {
+		h.logger.Error("list memory events failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeMemoryError, "memory events error")
+		return
+	}
+	explanations := buildLearningExplanations(profiles)
+	respondJSON(w, http.StatusOK, map[string]any{
+		"summary":      h.memory.GetProfileSummary(),
+		"explanations": explanations,
+		"profiles":     profiles,
+		"events":       events,
+	})
+}
+
+func (h *Handler) handleStateHistory(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parsePagination(w, r, 200)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	limit, sinceMs, untilMs := params.Limit, params.SinceMs, params.UntilMs
+	snapshots, err := h.store.ListFocusStateSnapshots(limit, sinceMs, untilMs)
+	if err != nil {
+		h.logger.Error("list state history failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "state history error")
+		return
+	}
+	respondJSON(w, http.StatusOK, snapshots)
+}
+
+func (h *Handler) handleBudgetHistory(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parsePagination(w, r, 200)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	snapshots, err := h.store.ListBudgetSnapshots(params.Limit, params.SinceMs, params.UntilMs)
+	if err != nil {
+		h.logger.Error("list budget history failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	respondJSON(w, http.StatusOK, snapshots)
+}
+
+func (h *Handler) handleDailySummary(w http.ResponseWriter, r *http.Request) {
+	loc := userLocation(h.store)
+	day := time.Now().In(loc).AddDate(0, 0, -1)
+	if raw := r.URL.Query().Get("date"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, loc)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, "invalid date")
+			return
+		}
+		day = parsed
+	}
+	summary, err := h.store.DailySummary(day)
+	if err != nil {
+		h.logger.Error("daily summary failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	resp := map[string]any{"summary": summary}
+	event, ok, err := h.memory.GetDailySummary(summary.Date)
+	if err != nil {
+		h.logger.Error("daily summary lookup failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeMemoryError, "memory events error")
+		return
+	}
+	if ok {
+		resp["memory_event"] = event
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleWeeklyStats(w http.ResponseWriter, _ *http.Request) {
+	trend, err := h.store.WeeklyTrend(time.Now().In(userLocation(h.store)))
+	if err != nil {
+		h.logger.Error("weekly stats failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	respondJSON(w, http.StatusOK, trend)
+}
+
+// feedbackStatsWindows is the default lookback for each bucket interval.
+var feedbackStatsWindows = map[string]time.Duration{
+	models.IntervalHour: 24 * time.Hour,
+	models.IntervalDay:  7 * 24 * time.Hour,
+	models.IntervalWeek: 12 * 7 * 24 * time.Hour,
+}
+
+func (h *Handler) handleFeedbackStats(w http.ResponseWriter, r *http.Request) {
+	interval := strings.ToLower(r.URL.Query().Get("interval"))
+	if interval == "" {
+		interval = models.IntervalDay
+	}
+	window, ok := feedbackStatsWindows[interval]
+	if !ok {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "invalid interval")
+		return
+	}
+	sinceMs, untilMs, err := parseTimeRange(r, window)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	stats, err := h.store.FeedbackStats(sinceMs, untilMs, interval, userLocation(h.store))
+	if err != nil {
+		if errors.Is(err, db.ErrTooManyBuckets) {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, "range too large for interval")
+			return
+		}
+		h.logger.Error("feedback stats failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	respondJSON(w, http.StatusOK, stats)
+}
+
+func (h *Handler) handleGatewayRules(w http.ResponseWriter, _ *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]any{"rules": h.gateway.Rules()})
+}
+
+func (h *Handler) handleGatewaySimulate(w http.ResponseWriter, r *http.Request) {
+	mode := models.ModeLight
+	if raw := r.URL.Query().Get("mode"); raw != "" {
+		mode = models.Mode(strings.ToUpper(raw))
+	}
+	switch mode {
+	case models.ModeSilent, models.ModeLight, models.ModeActive:
+	default:
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "invalid mode")
+		return
+	}
+	respondJSON(w, http.StatusOK, h.gateway.Simulate(mode))
+}
+
+func (h *Handler) handleGatewayReplay(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parsePagination(w, r, 1000)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	exported, err := h.store.ExportRecords(params.Limit, params.SinceMs, params.UntilMs)
+	if err != nil {
+		h.logger.Error("replay load failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	records := make([]gateway.ReplayRecord, 0, len(exported))
+	for _, record := range exported {
+		records = append(records, gateway.ReplayRecord{
+			Context:   record.Context,
+			RawAction: record.RawAction,
+			Original:  record.GatewayDecision,
+			AtMs:      record.CreatedAtMs,
+		})
+	}
+	result := h.gateway.Replay(records)
+	respondJSON(w, http.StatusOK, map[string]any{
+		"since_ms": params.SinceMs,
+		"until_ms": params.UntilMs,
+		"result":   result,
+	})
+}
+
+func (h *Handler) handleOllamaModels(w http.ResponseWriter, r *http.Request) {
+	if models, ok := h.ollamaModels.fresh(time.Now()); ok {
+		respondJSON(w, http.StatusOK, map[string]any{"models": models, "stale": false})
+		return
+	}
+
+	models, err := fetchOllamaModels(r.Context())
+	if err != nil {
+		if cached, ok := h.ollamaModels.last(); ok {
+			h.logger.Warn("ollama models refresh failed, serving cached list", slog.Any("error", err))
+			respondJSON(w, http.StatusOK, map[string]any{"models": cached, "stale": true})
+			return
+		}
+		var reqErr ollamaRequestError
+		if errors.As(err, &reqErr) {
+			respondError(w, http.StatusInternalServerError, codeInternalError, "ollama request error")
+			return
+		}
+		var decodeErr ollamaDecodeError
+		if errors.As(err, &decodeErr) {
+			respondError(w, http.StatusBadGateway, codeAIUnavailable, "ollama invalid response")
+			return
+		}
+		respondError(w, http.StatusBadGateway, codeAIUnavailable, "ollama unavailable")
+		return
+	}
+	h.ollamaModels.store(models, time.Now())
+	respondJSON(w, http.StatusOK, map[string]any{"models": models, "stale": false})
+}
+
+const pageMediaType = "application/vnd.always.page+json"
+
+// wantsPage reports whether the client opted into the paginated envelope,
+// either with ?v=2 or by accepting pageMediaType. Other clients keep the bare
+// arrays they were built against.
+func wantsPage(r *http.Request) bool {
+	if r.URL.Query().Get("v") == "2" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), pageMediaType)
+}
+
+func newPage(items any, count int, limit int, hasMore bool) map[string]any {
+	return map[string]any{
+		"items":    items,
+		"count":    count,
+		"has_more": hasMore,
+		"limit":    limit,
+	}
+}
+
+func respondJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		return
+	}
+}
+
+// replayDecision answers a request whose request_id is already stored:
+// with the stored response when it is a retry of the same request, and 409
+// otherwise. It reports whether it responded.
+func (h *Handler) replayDecision(w http.ResponseWriter, requestID string, clientContext models.Context) bool {
+	previous, ok, err := h.store.GetDecisionResponse(requestID)
+	if err != nil {
+		h.logger.Error("decision lookup failed", slog.String("request_id", requestID), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return true
+	}
+	if ok {
+		if !isDecisionRetry(clientContext, previous.Context) {
+			respondError(w, http.StatusConflict, codeConflict, "request_id already used")
+			return true
+		}
+		h.logger.Info("duplicate decision request, replaying stored response", slog.String("request_id", requestID))
+		respondJSON(w, http.StatusOK, previous)
+		return true
+	}
+	// Another user's decision under the same request_id is not a retry,
+	// and must not be replayed to this one.
+	inUse, err := h.store.RequestIDInUse(requestID)
+	if err != nil {
+		h.logger.Error("decision lookup failed", slog.String("request_id", requestID), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return true
+	}
+	if inUse {
+		respondError(w, http.StatusConflict, codeConflict, "request_id already used")
+		return true
+	}
+	return false
+}
 
-	models := make([]string, 0, len(payload.Models))
-	for _, m := range payload.Models {
-		name := strings.TrimSpace(m.Name)
-		if name == "" {
-			continue
-		}
-		models = append(models, name)
+// respondDuplicateDecision handles an insert that lost the race for
+// requestID to a decision stored by another process.
+func (h *Handler) respondDuplicateDecision(w http.ResponseWriter, requestID string, clientContext models.Context) {
+	h.logger.Warn("decision request_id stored concurrently", slog.String("request_id", requestID))
+	if !h.replayDecision(w, requestID, clientContext) {
+		respondError(w, http.StatusConflict, codeConflict, "request_id already used")
 	}
-	respondJSON(w, http.StatusOK, map[string]any{"models": models})
 }
 
-func respondJSON(w http.ResponseWriter, status int, payload any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		return
+// isDecisionRetry reports whether incoming carries the same client-supplied
+// context as the stored request. The stored context has been enriched, so only
+// the fields the client controls are compared.
+func isDecisionRetry(incoming, stored models.Context) bool {
+	if incoming.UserText != stored.UserText {
+		return false
+	}
+	if string(incoming.Mode) != requestedMode(stored) {
+		return false
 	}
+	return incoming.Timestamp == 0 || incoming.Timestamp == stored.Timestamp
+}
+
+// logAIProgress emits a debug line every aiProgressInterval until the returned
+// stop func is called or the request goes away, so a slow model is
+// distinguishable from a hung one.
+func (h *Handler) logAIProgress(ctx context.Context, requestID string, start time.Time) func() {
+	if !h.logger.Enabled(ctx, slog.LevelDebug) {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(aiProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.logger.Debug(
+					"ai decide in progress",
+					slog.String("request_id", requestID),
+					slog.Int64("elapsed_ms", time.Since(start).Milliseconds()),
+				)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
 }
 
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
+// evalOptions treats requests carrying typed user text as user-initiated, so
+// conversations bypass the gateway's cooldown and budget without touching them.
+func evalOptions(ctx models.Context) gateway.EvalOptions {
+	return gateway.EvalOptions{UserInitiated: ctx.UserText != ""}
 }
 
-func (h *Handler) respondWithAction(w http.ResponseWriter, requestID string, ctx models.Context, rawAction models.Action, policyVersion string, modelVersion string, latency int64) {
-	finalAction, gatewayDecision := h.gateway.Evaluate(ctx, rawAction)
+// respondWithAction runs a rule-made action through the gateway, stores it
+// and responds with it. clientContext is the context as the client sent it,
+// for telling a retry from a reused request_id.
+func (h *Handler) respondWithAction(w http.ResponseWriter, requestID string, clientContext, ctx models.Context, rawAction models.Action, policyVersion string, modelVersion string, breakdown models.LatencyBreakdown, auto *autoSuggestionCheck) {
+	latency := breakdown.AIMs
+	policyVersion = models.WithPolicyVariant(policyVersion, ctx.Signals[policyVariantSignal])
+	gatewayStart := time.Now()
+	finalAction, gatewayDecision := h.evaluate(ctx, rawAction)
+	breakdown.GatewayMs = time.Since(gatewayStart).Milliseconds()
 	createdAt := time.Now()
 	resp := models.DecisionResponse{
 		RequestID:       requestID,
@@ -704,6 +1780,12 @@ func (h *Handler) respondWithAction(w http.ResponseWriter, requestID string, ctx
 		CreatedAtMs:     createdAt.UnixMilli(),
 		GatewayDecision: gatewayDecision,
 	}
+	if auto != nil {
+		resp.AutoSuggestionsRemaining = auto.Remaining
+		if !auto.Allowed {
+			resp.AutoDiagnostic = auto.diagnostic()
+		}
+	}
 	logEntry := models.DecisionLogEntry{
 		RequestID:       requestID,
 		Context:         ctx,
@@ -716,11 +1798,19 @@ func (h *Handler) respondWithAction(w http.ResponseWriter, requestID string, ctx
 		CreatedAt:       createdAt,
 		CreatedAtMs:     createdAt.UnixMilli(),
 	}
+	persistStart := time.Now()
 	if err := h.store.InsertDecision(logEntry); err != nil {
+		if errors.Is(err, db.ErrDuplicateRequestID) {
+			h.respondDuplicateDecision(w, requestID, clientContext)
+			return
+		}
 		h.logger.Error("insert decision failed", slog.String("request_id", requestID), slog.Any("error", err))
-		respondError(w, http.StatusInternalServerError, "db error")
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
 		return
 	}
+	breakdown.PersistMs = time.Since(persistStart).Milliseconds()
+	breakdown.TotalMs = breakdown.EnrichMs + breakdown.AIMs + breakdown.GatewayMs + breakdown.PersistMs
+	resp.LatencyBreakdown = breakdown
 	respondJSON(w, http.StatusOK, resp)
 }
 
@@ -737,6 +1827,8 @@ type logAggregateBucket struct {
 	Total      int            `json:"total"`
 	ByAction   map[string]int `json:"by_action"`
 	ByDecision map[string]int `json:"by_decision"`
+	// ByVariant counts decisions per A/B policy variant; "" is no variant.
+	ByVariant map[string]int `json:"by_variant"`
 }
 
 func aggregateLogs(logs []models.EventLog) []logAggregateBucket {
@@ -753,6 +1845,7 @@ func aggregateLogs(logs []models.EventLog) []logAggregateBucket {
 				Bucket:     bucketKey,
 				ByAction:   map[string]int{},
 				ByDecision: map[string]int{},
+				ByVariant:  map[string]int{},
 			}
 			buckets[bucketKey] = bucket
 		}
@@ -763,6 +1856,7 @@ func aggregateLogs(logs []models.EventLog) []logAggregateBucket {
 		if entry.GatewayDecision.Decision != "" {
 			bucket.ByDecision[string(entry.GatewayDecision.Decision)]++
 		}
+		bucket.ByVariant[models.PolicyVariantOf(entry.PolicyVersion)]++
 	}
 
 	keys := make([]string, 0, len(buckets))
@@ -778,33 +1872,12 @@ func aggregateLogs(logs []models.EventLog) []logAggregateBucket {
 	return result
 }
 
-func ollamaTagsURL() string {
-	raw := os.Getenv("OLLAMA_URL")
-	if raw == "" {
-		return "http://localhost:11434/api/tags"
-	}
-	parsed, err := url.Parse(raw)
-	if err != nil {
-		return "http://localhost:11434/api/tags"
-	}
-	path := strings.TrimSuffix(parsed.Path, "/")
-	if strings.HasSuffix(path, "/api/tags") {
-		return parsed.String()
-	}
-	if strings.HasSuffix(path, "/api/generate") {
-		path = strings.TrimSuffix(path, "/generate")
-	} else if !strings.HasSuffix(path, "/api") {
-		path = path + "/api"
-	}
-	parsed.Path = path + "/tags"
-	return parsed.String()
-}
-
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Request-ID")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Request-ID, X-User-ID, traceparent, tracestate")
+		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,DELETE,OPTIONS")
+		w.Header().Set("Access-Control-Expose-Headers", limitHeader)
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -813,29 +1886,39 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func (h *Handler) loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		h.logger.Info("📥 收到HTTP请求",
-			slog.String("method", r.Method),
-			slog.String("path", r.URL.Path),
-			slog.String("remote", r.RemoteAddr),
-		)
-		next.ServeHTTP(w, r)
-		h.logger.Info("✅ 请求处理完成",
-			slog.String("method", r.Method),
-			slog.String("path", r.URL.Path),
-			slog.Duration("duration", time.Since(start)),
-		)
-	})
+// decodeJSON decodes a request body capped at the handler's body limit.
+func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	return decodeJSONLimit(w, r, v, h.maxBodyBytes)
 }
 
-func decodeJSON(r *http.Request, v any) error {
-	decoder := json.NewDecoder(r.Body)
+func decodeJSONLimit(w http.ResponseWriter, r *http.Request, v any, limit int64) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
 	decoder.DisallowUnknownFields()
 	return decoder.Decode(v)
 }
 
+// respondDecodeError maps a decodeJSON failure to 413 for oversized bodies
+// and 400 for everything else, naming the field when the body is valid JSON
+// that does not fit the request type.
+func respondDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondError(w, http.StatusRequestEntityTooLarge, codeBodyTooLarge, "request body too large")
+		return
+	}
+	// encoding/json has no typed error for DisallowUnknownFields.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		respondError(w, http.StatusBadRequest, codeInvalidJSON, "unknown field "+field)
+		return
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		respondError(w, http.StatusBadRequest, codeInvalidJSON, fmt.Sprintf("invalid type for field %q", typeErr.Field))
+		return
+	}
+	respondError(w, http.StatusBadRequest, codeInvalidJSON, "invalid json")
+}
+
 func validateContext(ctx models.Context) error {
 	validModes := map[models.Mode]bool{
 		models.ModeSilent: true,
@@ -849,17 +1932,42 @@ func validateContext(ctx models.Context) error {
 	if ctx.Timestamp < 1_000_000_000_000 || ctx.Timestamp > 10_000_000_000_000 {
 		return fmt.Errorf("timestamp must be milliseconds")
 	}
-	for key, value := range ctx.Signals {
-		if strings.TrimSpace(key) == "" {
-			return fmt.Errorf("signals key required")
-		}
-		if value == "" {
-			continue
-		}
+	if ctx.SwitchCount < 0 || ctx.SwitchCount > maxSignalSwitchCount {
+		return fmt.Errorf("invalid switch_count")
 	}
 	return nil
 }
 
+// checkFeedbackTarget verifies the decision a feedback refers to exists and,
+// for WANTED_MORE, that it was a silent one, responding with the error and
+// returning false otherwise.
+func (h *Handler) checkFeedbackTarget(w http.ResponseWriter, req models.FeedbackRequest) bool {
+	decision, exists, err := h.store.GetDecisionResponse(req.RequestID)
+	if err != nil {
+		h.logger.Error("check request_id failed", slog.String("request_id", req.RequestID), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return false
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, codeNotFound, "request_id not found")
+		return false
+	}
+	if req.Feedback == models.FeedbackWantedMore && decision.Action.ActionType != models.ActionDoNotDisturb {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "WANTED_MORE only applies to silent decisions")
+		return false
+	}
+	return true
+}
+
+// formatFeedback is the feedback string stored in event_logs and passed to
+// memory: the type, followed by the free text when there is any.
+func formatFeedback(req models.FeedbackRequest) string {
+	if req.FeedbackText != "" {
+		return string(req.Feedback) + ": " + req.FeedbackText
+	}
+	return string(req.Feedback)
+}
+
 func validateFeedback(req models.FeedbackRequest) error {
 	if req.RequestID == "" {
 		return fmt.Errorf("request_id required")
@@ -868,102 +1976,188 @@ func validateFeedback(req models.FeedbackRequest) error {
 		return fmt.Errorf("invalid request_id")
 	}
 	valid := map[models.FeedbackType]bool{
-		models.FeedbackLike:    true,
-		models.FeedbackDislike: true,
-		models.FeedbackAdopted: true,
-		models.FeedbackIgnored: true,
-		models.FeedbackClosed:  true,
-		models.FeedbackOpen:    true,
+		models.FeedbackLike:       true,
+		models.FeedbackDislike:    true,
+		models.FeedbackAdopted:    true,
+		models.FeedbackIgnored:    true,
+		models.FeedbackClosed:     true,
+		models.FeedbackOpen:       true,
+		models.FeedbackWantedMore: true,
 	}
 	if !valid[req.Feedback] {
 		return fmt.Errorf("invalid feedback")
 	}
+	switch req.ReasonCode {
+	case "", models.ReasonTooFrequent, models.ReasonWrongTime, models.ReasonNotRelevant, models.ReasonHelpful:
+	default:
+		return fmt.Errorf("invalid reason_code")
+	}
+	if req.Rating != 0 && (req.Rating < 1 || req.Rating > 5) {
+		return fmt.Errorf("rating must be between 1 and 5")
+	}
 	return nil
 }
 
-func enrichSignals(store *db.Store, focusMonitor *focus.Monitor, payload *models.Context) error {
-	payload.Signals["hour_of_day"] = strconv.Itoa(time.Now().Hour())
+func enrichSignals(store *db.Store, focusMonitor *focus.Monitor, payload *models.Context, loc *time.Location, precision int) error {
+	payload.Signals["hour_of_day"] = strconv.Itoa(time.Now().In(loc).Hour())
 	if _, ok := payload.Signals["session_minutes"]; !ok {
 		payload.Signals["session_minutes"] = "0"
 	}
 
-	quietHours, ok, err := store.GetSetting(settingQuietHours)
+	reader := settings.New(store)
+	quietHours, err := reader.GetString(settings.QuietHours, "")
 	if err != nil {
 		return err
 	}
-	if ok && quietHours != "" {
+	if quietHours != "" {
 		payload.Signals["quiet_hours"] = quietHours
 	}
 
-	budgetSetting, ok, err := store.GetSetting(settingInterventionBudget)
+	budgetSetting, err := reader.GetString(settings.InterventionBudget, "")
 	if err != nil {
 		return err
 	}
-	if ok {
-		budgetValue := normalizeBudget(budgetSetting)
-		if budgetValue != "" {
-			payload.Signals["intervention_budget"] = budgetValue
-		}
+	if budgetValue := normalizeBudget(budgetSetting); budgetValue != "" {
+		payload.Signals["intervention_budget"] = budgetValue
 	}
 
-	modelSetting, ok, err := store.GetSetting(settingOllamaModel)
+	modelSetting, err := reader.GetString(settings.OllamaModel, "")
 	if err != nil {
 		return err
 	}
-	if ok && modelSetting != "" {
+	if modelSetting != "" {
 		payload.Signals["ollama_model"] = modelSetting
 	}
 
+	disabledSetting, err := reader.GetString(settings.DisabledActions, "")
+	if err != nil {
+		return err
+	}
+	if disabledSetting != "" {
+		payload.Signals["disabled_actions"] = disabledSetting
+	}
+
+	reading, ok := readFocusState(store, focusMonitor)
+	if !ok {
+		return nil
+	}
+	payload.SwitchCount = reading.SwitchCount
+	payload.Signals["switch_count"] = strconv.Itoa(reading.SwitchCount)
+	if reading.Source == focusSourceHistory {
+		payload.Signals["focus_minutes_window"] = focus.FormatMinutes(reading.FocusMinutes, precision)
+	} else {
+		payload.Signals["title_switch_count"] = strconv.Itoa(reading.TitleSwitchCount)
+	}
+	if reading.NoProgress {
+		noProgressDuration := time.Duration(reading.NoProgressMs) * time.Millisecond
+		payload.Signals["no_progress_minutes"] = fmt.Sprintf("%.1f", noProgressDuration.Minutes())
+	}
+	if current := reading.Current; current != nil {
+		if _, exists := payload.Signals["focus_app"]; !exists {
+			payload.Signals["focus_app"] = current.AppName
+		}
+		if _, exists := payload.Signals["focus_bundle_id"]; !exists {
+			payload.Signals["focus_bundle_id"] = current.BundleID
+		}
+		if _, exists := payload.Signals["focus_window_title"]; !exists {
+			payload.Signals["focus_window_title"] = current.WindowTitle
+		}
+		if _, exists := payload.Signals["focus_minutes"]; !exists {
+			payload.Signals["focus_minutes"] = focus.FormatMinutes(current.FocusMinutes, precision)
+		}
+	}
+	if payload.Signals[gateway.InMeetingSignal] == "true" {
+		reading.State = focus.StateMeeting
+	}
+	if reading.State == "" {
+		return nil
+	}
+	payload.Signals["focus_score"] = strconv.Itoa(reading.FocusScore)
+	payload.FocusState = reading.State
+	payload.Signals["focus_state"] = reading.State
+	if reading.Current != nil {
+		_ = store.InsertFocusStateSnapshot(models.FocusStateSnapshot{
+			TsMs:         time.Now().UnixMilli(),
+			FocusState:   reading.State,
+			SwitchCount:  reading.SwitchCount,
+			NoProgressMs: reading.NoProgressMs,
+			FocusMinutes: reading.FocusMinutes,
+			AppName:      reading.Current.AppName,
+			WindowTitle:  reading.Current.WindowTitle,
+		})
+	}
+	return nil
+}
+
+const (
+	focusSourceMonitor = "monitor"
+	focusSourceHistory = "history"
+)
+
+// readFocusState gathers the focus metrics and derived state, preferring the
+// live monitor and falling back to the last ten minutes of stored events. The
+// state is left empty when the monitor has no current app yet. The title
+// threshold and score weights are machine settings, read from the default
+// user whoever is asking.
+func readFocusState(store *db.Store, focusMonitor *focus.Monitor) (models.FocusStateReading, bool) {
+	machine := machineStore(store)
+	titleThreshold, err := focus.LoadTitleSwitchThreshold(machine)
+	if err != nil {
+		return models.FocusStateReading{}, false
+	}
+	weights, err := focus.LoadScoreWeights(machine)
+	if err != nil {
+		return models.FocusStateReading{}, false
+	}
+	meetingApps, err := focus.LoadMeetingApps(store)
+	if err != nil {
+		return models.FocusStateReading{}, false
+	}
 	if focusMonitor != nil && focusMonitor.Enabled() {
-		switchCount := focusMonitor.SwitchCount()
-		payload.SwitchCount = switchCount
-		payload.Signals["switch_count"] = strconv.Itoa(switchCount)
-		noProgress, noProgressDuration := focusMonitor.NoProgress()
-		if noProgress {
-			payload.Signals["no_progress_minutes"] = fmt.Sprintf("%.1f", noProgressDuration.Minutes())
+		reading := models.FocusStateReading{
+			Source:           focusSourceMonitor,
+			SwitchCount:      focusMonitor.SwitchCount(),
+			TitleSwitchCount: focusMonitor.TitleSwitchCount(),
 		}
+		noProgress, noProgressDuration := focusMonitor.NoProgress()
+		reading.NoProgress = noProgress
+		reading.NoProgressMs = noProgressDuration.Milliseconds()
 
 		current, ok, err := focusMonitor.Current()
-		if err != nil {
-			return nil
+		if err != nil || !ok {
+			return reading, true
 		}
-		if ok {
-			if _, exists := payload.Signals["focus_app"]; !exists {
-				payload.Signals["focus_app"] = current.AppName
-			}
-			if _, exists := payload.Signals["focus_bundle_id"]; !exists {
-				payload.Signals["focus_bundle_id"] = current.BundleID
-			}
-			if _, exists := payload.Signals["focus_window_title"]; !exists {
-				payload.Signals["focus_window_title"] = current.WindowTitle
-			}
-			if _, exists := payload.Signals["focus_minutes"]; !exists {
-				payload.Signals["focus_minutes"] = fmt.Sprintf("%.1f", current.FocusMinutes)
-			}
-			focusState := deriveFocusState(current.FocusMinutes, switchCount, noProgress, noProgressDuration)
-			payload.FocusState = focusState
-			payload.Signals["focus_state"] = focusState
-			_ = store.InsertFocusStateSnapshot(models.FocusStateSnapshot{
-				TsMs:         time.Now().UnixMilli(),
-				FocusState:   focusState,
-				SwitchCount:  switchCount,
-				NoProgressMs: noProgressDuration.Milliseconds(),
-				FocusMinutes: current.FocusMinutes,
-				AppName:      current.AppName,
-				WindowTitle:  current.WindowTitle,
-			})
-		}
-	} else {
-		if metrics, err := store.FocusMetrics(int64((10 * time.Minute).Milliseconds())); err == nil {
-			payload.SwitchCount = metrics.SwitchCount
-			payload.Signals["switch_count"] = strconv.Itoa(metrics.SwitchCount)
-			payload.Signals["focus_minutes_window"] = fmt.Sprintf("%.1f", metrics.FocusMinutes)
-			focusState := deriveFocusState(metrics.FocusMinutes, metrics.SwitchCount, false, 0)
-			payload.FocusState = focusState
-			payload.Signals["focus_state"] = focusState
+		metrics := focus.Metrics{
+			FocusMinutes:       current.FocusMinutes,
+			SwitchCount:        reading.SwitchCount,
+			TitleSwitchCount:   reading.TitleSwitchCount,
+			NoProgress:         noProgress,
+			NoProgressDuration: noProgressDuration,
+			InMeeting:          focus.IsMeetingApp(meetingApps, current.AppName, current.BundleID),
 		}
+		reading.FocusMinutes = current.FocusMinutes
+		reading.State = focus.DeriveState(metrics, titleThreshold)
+		reading.FocusScore = focus.Score(metrics, weights, titleThreshold)
+		current.FocusScore = reading.FocusScore
+		reading.Current = &current
+		return reading, true
 	}
-	return nil
+
+	windowMetrics, err := store.FocusMetrics(int64((10 * time.Minute).Milliseconds()))
+	if err != nil {
+		return models.FocusStateReading{}, false
+	}
+	metrics := focus.Metrics{
+		FocusMinutes: windowMetrics.FocusMinutes,
+		SwitchCount:  windowMetrics.SwitchCount,
+	}
+	return models.FocusStateReading{
+		State:        focus.DeriveState(metrics, titleThreshold),
+		Source:       focusSourceHistory,
+		FocusScore:   focus.Score(metrics, weights, titleThreshold),
+		SwitchCount:  windowMetrics.SwitchCount,
+		FocusMinutes: windowMetrics.FocusMinutes,
+	}, true
 }
 
 func normalizeBudget(value string) string {
@@ -982,118 +2176,497 @@ func normalizeBudget(value string) string {
 func normalizeSettingValue(key, value string) (string, error) {
 	trimmed := strings.TrimSpace(value)
 	switch key {
-	case settingInterventionBudget:
+	case settings.InterventionBudget:
 		normalized := strings.ToLower(trimmed)
 		if normalized == "low" || normalized == "medium" || normalized == "high" {
 			return normalized, nil
 		}
 		return "", fmt.Errorf("invalid intervention_budget")
-	case settingQuietHours:
+	case settings.QuietHours:
 		if isValidQuietHours(trimmed) {
 			return trimmed, nil
 		}
 		return "", fmt.Errorf("invalid quiet_hours")
-	case settingAgentEnabled, settingRuleOnlyMode:
+	case settings.ActiveHours:
+		if strings.EqualFold(trimmed, "none") {
+			return "none", nil
+		}
+		if isValidActiveHours(trimmed) {
+			return trimmed, nil
+		}
+		return "", fmt.Errorf("invalid active_hours")
+	case settings.AgentEnabled, settings.RuleOnlyMode, settings.AllowHighRisk, settings.StrictSignals, settings.AutoMode, settings.FocusIngestEnabled,
+		settings.BatteryBackoff, settings.AdaptiveCooldown, settings.RedactWindowTitles,
+		settings.NoProgressInputReset, settings.DebugPrompts, settings.BreakReminder, settings.UrgentBypass, settings.FocusContext:
 		switch strings.ToLower(trimmed) {
 		case "true", "false":
 			return strings.ToLower(trimmed), nil
 		default:
 			return "", fmt.Errorf("invalid %s", key)
 		}
-	case settingFocusMonitor:
+	case settings.FocusMonitorEnabled:
 		switch strings.ToLower(trimmed) {
 		case "true", "false":
 			return strings.ToLower(trimmed), nil
 		default:
 			return "", fmt.Errorf("invalid focus_monitor_enabled")
 		}
-	case settingOllamaModel:
+	case settings.Timezone:
+		if strings.EqualFold(trimmed, "none") {
+			return "none", nil
+		}
+		if _, err := time.LoadLocation(trimmed); err != nil || trimmed == "" {
+			return "", fmt.Errorf("invalid timezone")
+		}
+		return trimmed, nil
+	case settings.FocusProvider:
+		name := strings.ToLower(trimmed)
+		if !focus.HasProvider(name) {
+			return "", fmt.Errorf("invalid focus_provider")
+		}
+		return name, nil
+	case settings.OllamaModel:
 		if trimmed == "" {
 			return "", fmt.Errorf("invalid ollama_model")
 		}
 		return trimmed, nil
-	case settingBudgetSilent, settingBudgetLight, settingBudgetActive, settingDailyBudgetCap, settingHourlyBudgetCap:
+	case settings.BudgetSilent, settings.BudgetLight, settings.BudgetActive, settings.DailyBudgetCap, settings.HourlyBudgetCap,
+		settings.CostRest, settings.CostEncourage, settings.CostTask, settings.CostReframe:
 		parsed, err := strconv.ParseFloat(trimmed, 64)
 		if err != nil || parsed < 0 {
 			return "", fmt.Errorf("invalid %s", key)
 		}
 		return trimmed, nil
-	case settingCooldownSeconds:
+	case settings.FocusScoreWeights:
+		if _, err := focus.ParseScoreWeights(trimmed); err != nil {
+			return "", fmt.Errorf("invalid focus_score_weights: %w", err)
+		}
+		return trimmed, nil
+	case settings.FocusPollMs:
+		if strings.EqualFold(trimmed, "none") {
+			return "none", nil
+		}
+		interval, err := focus.ParsePollInterval(trimmed)
+		if err != nil {
+			return "", fmt.Errorf("invalid focus_poll_ms: %w", err)
+		}
+		return strconv.FormatInt(interval.Milliseconds(), 10), nil
+	case settings.FocusAppBlocklist, settings.FocusAppAllowlist, settings.MeetingApps:
+		patterns, err := focus.ParseAppPatterns(trimmed)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if len(patterns) == 0 {
+			return "none", nil
+		}
+		return strings.Join(patterns, ","), nil
+	case settings.BatteryPollFactor:
+		if _, err := focus.ParseBatteryFactor(trimmed); err != nil {
+			return "", fmt.Errorf("invalid battery_poll_multiplier: %w", err)
+		}
+		return trimmed, nil
+	case settings.TitleSwitchThreshold, settings.BreakAfterMinutes:
+		parsed, err := strconv.Atoi(trimmed)
+		if err != nil || parsed <= 0 {
+			return "", fmt.Errorf("invalid %s", key)
+		}
+		return trimmed, nil
+	case settings.HalfLifeDays:
+		parsed, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil || parsed <= 0 {
+			return "", fmt.Errorf("invalid %s", key)
+		}
+		return trimmed, nil
+	case settings.AIBackend:
+		backend := ai.NormalizeBackend(trimmed)
+		if backend == "" {
+			return "", fmt.Errorf("invalid ai_backend")
+		}
+		return backend, nil
+	case settings.AutoJitterPercent:
+		parsed, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil || parsed < 0 || parsed > maxAutoJitterPercent {
+			return "", fmt.Errorf("invalid %s", key)
+		}
+		return trimmed, nil
+	case settings.DisabledActions:
+		actions, err := gateway.ParseDisabledActions(trimmed)
+		if err != nil {
+			return "", fmt.Errorf("invalid disabled_actions: %w", err)
+		}
+		if len(actions) == 0 {
+			return "none", nil
+		}
+		names := make([]string, 0, len(actions))
+		for _, actionType := range actions {
+			names = append(names, string(actionType))
+		}
+		return strings.Join(names, ","), nil
+	case settings.MessageRestTemplate, settings.MessageQuietHoursTemplate, settings.MessageAutoGuardTemplate, settings.MessageOverrideTemplate:
+		if strings.EqualFold(trimmed, "none") {
+			return "none", nil
+		}
+		if _, err := parseMessageTemplate(trimmed, nil); err != nil {
+			return "", fmt.Errorf("invalid %s: %w", key, err)
+		}
+		return trimmed, nil
+	case settings.DefaultMode:
+		if strings.EqualFold(trimmed, "none") {
+			return "none", nil
+		}
+		mode := models.Mode(strings.ToUpper(trimmed))
+		switch mode {
+		case models.ModeSilent, models.ModeLight, models.ModeActive:
+			return string(mode), nil
+		default:
+			return "", fmt.Errorf("invalid %s", key)
+		}
+	case settings.EventOrder:
+		order := memory.NormalizeEventOrder(trimmed)
+		if order == "" {
+			return "", fmt.Errorf("invalid %s", key)
+		}
+		return order, nil
+	case settings.MaxRiskSilent, settings.MaxRiskLight, settings.MaxRiskActive:
+		level := models.RiskLevel(strings.ToUpper(trimmed))
+		switch level {
+		case models.RiskLow, models.RiskMedium, models.RiskHigh:
+			return string(level), nil
+		default:
+			return "", fmt.Errorf("invalid %s", key)
+		}
+	case settings.MaxAutoPerDay:
+		if strings.EqualFold(trimmed, "none") {
+			return "none", nil
+		}
 		parsed, err := strconv.Atoi(trimmed)
 		if err != nil || parsed < 0 {
-			return "", fmt.Errorf("invalid cooldown_seconds")
+			return "", fmt.Errorf("invalid %s", key)
+		}
+		return strconv.Itoa(parsed), nil
+	case settings.CooldownSeconds, settings.RepeatRest, settings.RepeatEncourage, settings.RepeatTask, settings.RepeatReframe, settings.WarmupSeconds:
+		parsed, err := strconv.Atoi(trimmed)
+		if err != nil || parsed < 0 {
+			return "", fmt.Errorf("invalid %s", key)
 		}
 		return strconv.Itoa(parsed), nil
+	case settings.PolicyVariants:
+		variants, err := parsePolicyVariants(trimmed)
+		if err != nil {
+			return "", err
+		}
+		if len(variants) == 0 {
+			return "none", nil
+		}
+		return strings.Join(variants, ","), nil
+	case settings.NegativeWeight, settings.PositiveWeight:
+		parsed, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return "", fmt.Errorf("invalid %s", key)
+		}
+		return trimmed, nil
+	case settings.AdaptiveCooldownMax:
+		parsed, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil || parsed < 1 || parsed > gateway.MaxAdaptiveCooldownFactor {
+			return "", fmt.Errorf("invalid %s", key)
+		}
+		return trimmed, nil
 	default:
 		return trimmed, nil
 	}
 }
 
-func isValidQuietHours(value string) bool {
-	parts := strings.Split(value, "-")
-	if len(parts) != 2 {
-		return false
+// userLocation returns the zone quiet and active hours are evaluated in: the
+// timezone setting when it names a valid IANA zone, else the server's own.
+func userLocation(store *db.Store) *time.Location {
+	value, err := settings.New(store).GetString(settings.Timezone, "")
+	if err != nil || value == "" {
+		return time.Local
 	}
-	for _, part := range parts {
-		if _, err := time.Parse("15:04", strings.TrimSpace(part)); err != nil {
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// isValidQuietHours accepts a single HH:MM-HH:MM window. The end may be
+// 24:00, so "00:00-24:00" means the whole day.
+func isValidQuietHours(value string) bool {
+	_, _, ok := parseTimeWindow(value)
+	return ok
+}
+
+func withinQuietHours(now time.Time, quietHours string) bool {
+	return withinTimeWindow(now, quietHours)
+}
+
+// isValidActiveHours accepts one or more comma-separated HH:MM-HH:MM windows.
+func isValidActiveHours(value string) bool {
+	for _, window := range strings.Split(value, ",") {
+		if !isValidQuietHours(strings.TrimSpace(window)) {
 			return false
 		}
 	}
 	return true
 }
 
-func withinQuietHours(now time.Time, quietHours string) bool {
-	parts := strings.Split(quietHours, "-")
-	if len(parts) != 2 {
-		return false
+func withinActiveHours(now time.Time, activeHours string) bool {
+	for _, window := range strings.Split(activeHours, ",") {
+		if withinTimeWindow(now, strings.TrimSpace(window)) {
+			return true
+		}
 	}
-	start, err := time.Parse("15:04", strings.TrimSpace(parts[0]))
-	if err != nil {
+	return false
+}
+
+// withinTimeWindow reports whether now falls in window, evaluated in now's
+// location. A window whose end precedes its start wraps past midnight; an
+// equal start and end is empty, and "00:00-24:00" covers the whole day.
+//
+// The bounds are resolved to real instants for today and for yesterday (whose
+// window may wrap into today) rather than compared as minutes of the day, so
+// a window spanning a DST change keeps its wall-clock edges. See wallTime for
+// bounds that land in a DST gap.
+func withinTimeWindow(now time.Time, window string) bool {
+	startMinutes, endMinutes, ok := parseTimeWindow(window)
+	if !ok || startMinutes == endMinutes {
 		return false
 	}
-	end, err := time.Parse("15:04", strings.TrimSpace(parts[1]))
+	year, month, day := now.Date()
+	for _, offset := range []int{0, -1} {
+		startDay := day + offset
+		endDay := startDay
+		if endMinutes < startMinutes {
+			endDay++
+		}
+		start := wallTime(year, month, startDay, startMinutes, now.Location())
+		end := wallTime(year, month, endDay, endMinutes, now.Location())
+		if !now.Before(start) && now.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// wallTime resolves minutes past midnight on the given day in loc. A wall
+// time skipped by a spring-forward change is moved forward by the length of
+// the gap (02:30 becomes 03:30); a repeated one during fall-back resolves to
+// whichever occurrence time.Date picks.
+func wallTime(year int, month time.Month, day int, minutes int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, 0, minutes, 0, 0, loc)
+	want := time.Date(year, month, day, 0, minutes, 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if got.Before(want) {
+		t = t.Add(want.Sub(got))
+	}
+	return t
+}
+
+const minutesPerDay = 24 * 60
+
+// parseTimeWindow splits an HH:MM-HH:MM window into minutes since midnight.
+// Only the end may be 24:00.
+func parseTimeWindow(window string) (int, int, bool) {
+	parts := strings.Split(window, "-")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(parts[0]))
 	if err != nil {
-		return false
+		return 0, 0, false
 	}
-	nowMinutes := now.Hour()*60 + now.Minute()
 	startMinutes := start.Hour()*60 + start.Minute()
-	endMinutes := end.Hour()*60 + end.Minute()
-	if startMinutes == endMinutes {
-		return false
+	rawEnd := strings.TrimSpace(parts[1])
+	if rawEnd == "24:00" {
+		return startMinutes, minutesPerDay, true
 	}
-	if startMinutes < endMinutes {
-		return nowMinutes >= startMinutes && nowMinutes < endMinutes
+	end, err := time.Parse("15:04", rawEnd)
+	if err != nil {
+		return 0, 0, false
 	}
-	return nowMinutes >= startMinutes || nowMinutes < endMinutes
+	return startMinutes, end.Hour()*60 + end.Minute(), true
 }
 
-func (h *Handler) shouldAllowAutoSuggestion(ctx models.Context) (bool, string, error) {
-	now := time.Now()
-	lastRaw, ok, err := h.store.GetSetting(settingLastAutoSuggestMs)
+// autoSuggestionCheck is the outcome of shouldAllowAutoSuggestion. Remaining
+// is nil unless max_auto_suggestions_per_day is set.
+type autoSuggestionCheck struct {
+	Allowed   bool
+	Reason    string
+	Remaining *int
+	Gates     []models.GateCheck
+}
+
+// addGate records a gate result; the first failing gate becomes the reason.
+// Reason is only reported on gates that failed.
+func (c *autoSuggestionCheck) addGate(gate models.GateCheck) {
+	if gate.Passed {
+		gate.Reason = ""
+	} else if c.Reason == "" {
+		c.Reason = gate.Reason
+	}
+	c.Gates = append(c.Gates, gate)
+}
+
+func (c autoSuggestionCheck) diagnostic() *models.AutoDiagnostic {
+	return &models.AutoDiagnostic{Allowed: c.Allowed, Reason: c.Reason, Gates: c.Gates}
+}
+
+// shouldAllowAutoSuggestion runs every gate an auto request must pass, even
+// after one fails, so a held-back response can explain itself in full. The
+// auto window and daily count only advance when all gates pass.
+func (h *Handler) shouldAllowAutoSuggestion(ctx models.Context, now time.Time, quietHours string) (autoSuggestionCheck, error) {
+	var check autoSuggestionCheck
+	// The gateway starts with a full budget, so hold auto suggestions back
+	// for a while after boot instead of firing one straight away.
+	warmup, err := settings.New(h.store).GetDuration(settings.WarmupSeconds, time.Second, 0)
+	if err != nil {
+		return autoSuggestionCheck{}, err
+	}
+	warmupLeft := max(h.started.Add(warmup).Sub(now), 0)
+	check.addGate(models.GateCheck{
+		Gate:   "warmup",
+		Passed: warmupLeft == 0,
+		Reason: "warmup",
+		Details: map[string]any{
+			"warmup_seconds": warmup.Seconds(),
+			"remaining_ms":   warmupLeft.Milliseconds(),
+		},
+	})
+
+	check.addGate(models.GateCheck{
+		Gate:    "quiet_hours",
+		Passed:  quietHours == "" || !withinQuietHours(now, quietHours),
+		Reason:  "quiet_hours",
+		Details: map[string]any{"window": quietHours},
+	})
+
+	check.addGate(models.GateCheck{
+		Gate:    "in_meeting",
+		Passed:  ctx.FocusState != focus.StateMeeting && ctx.Signals[gateway.InMeetingSignal] != "true",
+		Reason:  gateway.ReasonInMeeting,
+		Details: map[string]any{"focus_state": ctx.FocusState},
+	})
+
+	activeHours, err := settings.New(h.store).GetString(settings.ActiveHours, "")
 	if err != nil {
-		return false, "", err
+		return autoSuggestionCheck{}, err
 	}
-	if ok && lastRaw != "" {
-		if lastMs, err := strconv.ParseInt(lastRaw, 10, 64); err == nil {
-			if now.UnixMilli()-lastMs < autoSuggestionWindow.Milliseconds() {
-				return false, "auto_window", nil
-			}
+	check.addGate(models.GateCheck{
+		Gate:    "active_hours",
+		Passed:  activeHours == "" || withinActiveHours(now, activeHours),
+		Reason:  "outside_active_hours",
+		Details: map[string]any{"window": activeHours},
+	})
+
+	maxPerDay, err := settings.New(h.store).GetInt(settings.MaxAutoPerDay, 0)
+	if err != nil {
+		return autoSuggestionCheck{}, err
+	}
+	day := now.Format("2006-01-02")
+	capGate := models.GateCheck{Gate: "daily_auto_cap", Passed: true, Reason: "daily_auto_cap", Details: map[string]any{"limit": maxPerDay}}
+	if maxPerDay > 0 {
+		used, err := h.store.AutoSuggestCount(day)
+		if err != nil {
+			return autoSuggestionCheck{}, err
+		}
+		remaining := max(maxPerDay-used, 0)
+		check.Remaining = &remaining
+		capGate.Passed = remaining > 0
+		capGate.Details["used"] = used
+		capGate.Details["remaining"] = remaining
+	}
+	check.addGate(capGate)
+
+	nextMs, err := h.nextAutoSuggestionMs()
+	if err != nil {
+		return autoSuggestionCheck{}, err
+	}
+	check.addGate(models.GateCheck{
+		Gate:   "auto_window",
+		Passed: nextMs <= 0 || now.UnixMilli() >= nextMs,
+		Reason: "auto_window",
+		Details: map[string]any{
+			"next_allowed_ms": nextMs,
+			"wait_ms":         max(nextMs-now.UnixMilli(), 0),
+		},
+	})
+
+	for _, gate := range h.gateway.InterventionGates(ctx, h.gateway.MaxActionCost()) {
+		check.addGate(gate)
+	}
+	if check.Reason != "" {
+		return check, nil
+	}
+
+	jitterPct, err := h.autoJitterPercent()
+	if err != nil {
+		return autoSuggestionCheck{}, err
+	}
+	next := now.Add(jitteredWindow(autoSuggestionWindow, jitterPct))
+	if err := h.store.UpsertSetting(settings.LastAutoSuggestMs, strconv.FormatInt(now.UnixMilli(), 10)); err != nil {
+		return autoSuggestionCheck{}, err
+	}
+	if err := h.store.UpsertSetting(settings.NextAutoSuggestMs, strconv.FormatInt(next.UnixMilli(), 10)); err != nil {
+		return autoSuggestionCheck{}, err
+	}
+	if maxPerDay > 0 {
+		used, err := h.store.IncrementAutoSuggestCount(day)
+		if err != nil {
+			return autoSuggestionCheck{}, err
 		}
+		remaining := max(maxPerDay-used, 0)
+		check.Remaining = &remaining
+	}
+	check.Allowed = true
+	check.Reason = "allow"
+	return check, nil
+}
+
+// nextAutoSuggestionMs returns when the next auto-suggestion becomes eligible,
+// falling back to last+window for data written before the next timestamp existed.
+func (h *Handler) nextAutoSuggestionMs() (int64, error) {
+	reader := settings.New(h.store)
+	nextMs, err := reader.GetInt64(settings.NextAutoSuggestMs, 0)
+	if err != nil || nextMs > 0 {
+		return nextMs, err
 	}
-	allowed, reason := h.gateway.CanIntervene(ctx, gateway.MaxActionCost())
-	if !allowed {
-		return false, reason, nil
+	lastMs, err := reader.GetInt64(settings.LastAutoSuggestMs, 0)
+	if err != nil || lastMs == 0 {
+		return 0, err
+	}
+	return lastMs + autoSuggestionWindow.Milliseconds(), nil
+}
+
+func (h *Handler) autoJitterPercent() (float64, error) {
+	pct, err := settings.New(h.store).GetFloat(settings.AutoJitterPercent, 0)
+	if err != nil {
+		return 0, err
 	}
-	if err := h.store.UpsertSetting(settingLastAutoSuggestMs, strconv.FormatInt(now.UnixMilli(), 10)); err != nil {
-		return false, "", err
+	return math.Min(pct, maxAutoJitterPercent), nil
+}
+
+// jitteredWindow scales window by a random factor within ±pct percent.
+func jitteredWindow(window time.Duration, pct float64) time.Duration {
+	if pct <= 0 {
+		return window
 	}
-	return true, "allow", nil
+	factor := 1 + (rand.Float64()*2-1)*pct/100
+	return time.Duration(float64(window) * factor)
 }
 
 func autoSuggestionMessage(reason string) string {
 	switch reason {
+	case "warmup":
+		return "服务刚启动，暂不生成自动提示。"
 	case "auto_window":
 		return "自动提示冷却中。"
+	case "outside_active_hours":
+		return "当前不在活跃时段，已暂停自动提示。"
+	case gateway.ReasonInMeeting:
+		return "会议中，已暂停自动提示。"
+	case "daily_auto_cap":
+		return "今日自动提示次数已达上限。"
 	case gateway.ReasonCooldownActive:
 		return "处于冷却期，已暂停自动提示。"
 	case gateway.ReasonBudgetExhausted:
@@ -1112,17 +2685,34 @@ func isImplicitFeedback(feedback models.FeedbackType) bool {
 	}
 }
 
-func deriveFocusState(focusMinutes float64, switchCount int, noProgress bool, noProgressDuration time.Duration) string {
-	if noProgress && noProgressDuration >= 20*time.Minute {
-		return "NO_PROGRESS"
+// applyAutoMode lets the derived focus state override the client's mode when
+// auto_mode is on: deep focus goes silent, stalled progress gets more proactive.
+// The original mode is kept in the requested_mode signal.
+func applyAutoMode(store *db.Store, payload *models.Context) error {
+	enabled, err := settings.New(store).GetBool(settings.AutoMode, false)
+	if err != nil || !enabled {
+		return err
 	}
-	if switchCount >= 8 {
-		return "DISTRACTED"
+	payload.Signals["requested_mode"] = string(payload.Mode)
+	switch payload.FocusState {
+	case focus.StateFocused:
+		payload.Mode = models.ModeSilent
+	case focus.StateNoProgress:
+		switch payload.Mode {
+		case models.ModeSilent:
+			payload.Mode = models.ModeLight
+		case models.ModeLight:
+			payload.Mode = models.ModeActive
+		}
 	}
-	if focusMinutes >= 25 {
-		return "FOCUSED"
+	return nil
+}
+
+func requestedMode(ctx models.Context) string {
+	if mode := ctx.Signals["requested_mode"]; mode != "" {
+		return mode
 	}
-	return "LIGHT"
+	return string(ctx.Mode)
 }
 
 func buildLearningExplanations(profiles []memory.Profile) []string {
@@ -1138,6 +2728,9 @@ func buildLearningExplanations(profiles []memory.Profile) []string {
 			explanations = append(explanations, fmt.Sprintf("提示频率偏好: %s", value))
 		case key == "tolerance_night_intervention":
 			explanations = append(explanations, fmt.Sprintf("夜间提示容忍度: %s", value))
+		case strings.HasPrefix(key, "tolerance_hour_"):
+			hour := strings.TrimPrefix(key, "tolerance_hour_")
+			explanations = append(explanations, fmt.Sprintf("%s点提示容忍度: %s", hour, value))
 		case strings.HasPrefix(key, "accepts_action_"):
 			action := strings.TrimPrefix(key, "accepts_action_")
 			actionLabel := describeActionType(action)
@@ -1172,21 +2765,16 @@ type decisionSettings struct {
 }
 
 func loadDecisionSettings(store *db.Store) (decisionSettings, error) {
-	settings := decisionSettings{
-		AgentEnabled: true,
-		RuleOnly:     false,
-	}
-	if value, ok, err := store.GetSetting(settingAgentEnabled); err != nil {
-		return settings, err
-	} else if ok {
-		settings.AgentEnabled = value == "true"
+	reader := settings.New(store)
+	agentEnabled, err := reader.GetBool(settings.AgentEnabled, true)
+	if err != nil {
+		return decisionSettings{}, err
 	}
-	if value, ok, err := store.GetSetting(settingRuleOnlyMode); err != nil {
-		return settings, err
-	} else if ok {
-		settings.RuleOnly = value == "true"
+	ruleOnly, err := reader.GetBool(settings.RuleOnlyMode, false)
+	if err != nil {
+		return decisionSettings{}, err
 	}
-	return settings, nil
+	return decisionSettings{AgentEnabled: agentEnabled, RuleOnly: ruleOnly}, nil
 }
 
 func (s decisionSettings) policyVersion() string {
@@ -0,0 +1,108 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"always/core/internal/db"
+	"always/core/internal/memory"
+	"always/core/internal/models"
+)
+
+// fakeAI answers every decision with action and counts the calls.
+type fakeAI struct {
+	action models.Action
+	err    error
+	calls  int
+}
+
+func (f *fakeAI) Decide(models.Context, string) (models.Action, string, string, error) {
+	f.calls++
+	return f.action, "policy_v0", "fake", f.err
+}
+
+func (f *fakeAI) Feedback(string, string) error {
+	return nil
+}
+
+func newTestStore(t *testing.T) *db.Store {
+	t.Helper()
+	store, err := db.Open(filepath.Join(t.TempDir(), "always.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.DB().Close() })
+	return store
+}
+
+func newTestHandler(t *testing.T) (*Handler, *db.Store, *fakeAI) {
+	t.Helper()
+	store := newTestStore(t)
+	logger := slog.New(slog.DiscardHandler)
+	backend := &fakeAI{action: models.Action{
+		ActionType: models.ActionEncourage,
+		Message:    "keep going",
+		Confidence: 0.9,
+		RiskLevel:  models.RiskLow,
+	}}
+	h := NewHandler(store, backend, nil, memory.NewService(store.DB(), logger), time.Now(), logger, nil)
+	return h, store, backend
+}
+
+// serve sends body, JSON-encoded unless it is already a string, to the
+// handler's router and returns the recorded response.
+func serve(t *testing.T, h *Handler, method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	h.Router().ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeBody unmarshals a JSON response body into v.
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+}
+
+// errorOf decodes an error response.
+func errorOf(t *testing.T, rec *httptest.ResponseRecorder) errorResponse {
+	t.Helper()
+	var body errorResponse
+	decodeBody(t, rec, &body)
+	return body
+}
+
+func decisionBody(requestID string, mode models.Mode) map[string]any {
+	return map[string]any{
+		"request_id": requestID,
+		"context": map[string]any{
+			"user_text": "help me plan",
+			"timestamp": int64(1_760_000_000_000),
+			"mode":      mode,
+		},
+	}
+}
@@ -0,0 +1,83 @@
+package httpapi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithinQuietHours(t *testing.T) {
+	day := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		window string
+		now    time.Time
+		want   bool
+	}{
+		{"00:00-24:00", day(0, 0), true},
+		{"00:00-24:00", day(12, 0), true},
+		{"00:00-24:00", day(23, 59), true},
+		{"00:00-00:00", day(12, 0), false},
+		{"13:00-13:00", day(13, 0), false},
+		{"22:00-07:00", day(23, 0), true},
+		{"22:00-07:00", day(6, 59), true},
+		{"22:00-07:00", day(7, 0), false},
+		{"22:00-07:00", day(21, 59), false},
+		{"09:00-17:00", day(9, 0), true},
+		{"09:00-17:00", day(17, 0), false},
+		{"bogus", day(12, 0), false},
+	}
+	for _, tt := range tests {
+		if got := withinQuietHours(tt.now, tt.window); got != tt.want {
+			t.Errorf("withinQuietHours(%s, %q) = %v, want %v", tt.now.Format("15:04"), tt.window, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidQuietHours(t *testing.T) {
+	for value, want := range map[string]bool{
+		"00:00-24:00": true,
+		"22:00-07:00": true,
+		"00:00-00:00": true,
+		"24:00-01:00": false,
+		"00:00-24:01": false,
+		"9-17":        false,
+		"22:00":       false,
+	} {
+		if got := isValidQuietHours(value); got != want {
+			t.Errorf("isValidQuietHours(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestWithinQuietHoursAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	// 2026-03-08 02:00 EST jumps to 03:00 EDT; 2026-11-01 02:00 EDT falls
+	// back to 01:00 EST, so 01:00-02:00 happens twice.
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC).In(ny)
+	}
+	tests := []struct {
+		name   string
+		window string
+		now    time.Time
+		want   bool
+	}{
+		{"start in the spring gap moves past it", "02:30-04:00", utc(time.March, 8, 7, 15), false},                       // 03:15 EDT
+		{"after the moved start", "02:30-04:00", utc(time.March, 8, 7, 45), true},                                        // 03:45 EDT
+		{"overnight window ends on the wall clock after spring forward", "22:00-06:00", utc(time.March, 8, 9, 59), true}, // 05:59 EDT
+		{"overnight window end after spring forward", "22:00-06:00", utc(time.March, 8, 10, 0), false},                   // 06:00 EDT
+		{"first 01:15 during fall back", "00:00-01:30", utc(time.November, 1, 5, 15), true},                              // 01:15 EDT
+		{"repeated 01:15 is past the end", "00:00-01:30", utc(time.November, 1, 6, 15), false},                           // 01:15 EST
+		{"overnight window ends on the wall clock after fall back", "22:00-06:00", utc(time.November, 1, 10, 59), true},  // 05:59 EST
+		{"overnight window end after fall back", "22:00-06:00", utc(time.November, 1, 11, 0), false},                     // 06:00 EST
+	}
+	for _, tt := range tests {
+		if got := withinQuietHours(tt.now, tt.window); got != tt.want {
+			t.Errorf("%s: withinQuietHours(%s, %q) = %v, want %v", tt.name, tt.now.Format("15:04 MST"), tt.window, got, tt.want)
+		}
+	}
+}
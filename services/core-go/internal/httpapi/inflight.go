@@ -0,0 +1,49 @@
+package httpapi
+
+import (
+	"context"
+	"sync"
+)
+
+// requestReservations tracks client-supplied decision request_ids that are
+// being answered, so a retry sent while the original is still waiting on the
+// model queues behind it instead of calling the model and charging the
+// gateway a second time.
+type requestReservations struct {
+	mu       sync.Mutex
+	inflight map[string]chan struct{}
+}
+
+func newRequestReservations() *requestReservations {
+	return &requestReservations{inflight: map[string]chan struct{}{}}
+}
+
+// reserve claims requestID, waiting for any request already holding it to
+// finish. The returned release must be called once the decision is stored or
+// abandoned. It fails only when ctx ends while waiting.
+func (r *requestReservations) reserve(ctx context.Context, requestID string) (func(), error) {
+	for {
+		r.mu.Lock()
+		done, busy := r.inflight[requestID]
+		if !busy {
+			done = make(chan struct{})
+			r.inflight[requestID] = done
+			r.mu.Unlock()
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					r.mu.Lock()
+					delete(r.inflight, requestID)
+					r.mu.Unlock()
+					close(done)
+				})
+			}, nil
+		}
+		r.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package httpapi
+
+import (
+	"net/http"
+	"testing"
+
+	"always/core/internal/memory"
+)
+
+func TestLearningExplanationsOrderEvents(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	for _, event := range []struct {
+		summary    string
+		importance float64
+	}{
+		{"disliked", 0.8},
+		{"ignored", 0.3},
+	} {
+		if err := h.memory.AddEvent("feedback", event.summary, event.importance); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	rec := serve(t, h, http.MethodGet, "/v1/learning/explanations?by=importance", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("by=importance = %d %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Events []memory.MemoryEvent `json:"events"`
+	}
+	decodeBody(t, rec, &body)
+	if len(body.Events) != 2 || body.Events[0].Summary != "disliked" {
+		t.Errorf("events by importance = %+v, want the disliked one first", body.Events)
+	}
+
+	if rec := serve(t, h, http.MethodGet, "/v1/learning/explanations?by=rating", nil, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("by=rating = %d %s, want 400", rec.Code, rec.Body)
+	}
+}
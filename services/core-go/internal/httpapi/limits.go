@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultMaxListLimit = 5000
+
+// defaultMaxBodyBytes bounds JSON request bodies; user_text and signals are
+// small, so this leaves plenty of headroom.
+const defaultMaxBodyBytes = 256 << 10
+
+// memoryImportMaxBytes is the separate, larger cap for memory snapshots,
+// which carry every profile and memory event.
+const memoryImportMaxBytes = 16 << 20
+
+// limitHeader reports the limit a list endpoint actually applied, which may
+// be lower than the one requested.
+const limitHeader = "X-Effective-Limit"
+
+// maxListLimit reads MAX_LIST_LIMIT, the ceiling applied to every ?limit=
+// query so a single request cannot make the service materialise an unbounded
+// slice.
+func maxListLimit() int {
+	raw := strings.TrimSpace(os.Getenv("MAX_LIST_LIMIT"))
+	if raw == "" {
+		return defaultMaxListLimit
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		return defaultMaxListLimit
+	}
+	return limit
+}
+
+// maxBodyBytes reads MAX_BODY_BYTES, the cap decodeJSON applies to request
+// bodies.
+func maxBodyBytes() int64 {
+	raw := strings.TrimSpace(os.Getenv("MAX_BODY_BYTES"))
+	if raw == "" {
+		return defaultMaxBodyBytes
+	}
+	limit, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || limit <= 0 {
+		return defaultMaxBodyBytes
+	}
+	return limit
+}
+
+// pagination holds the list query parameters shared by the log, focus and
+// export endpoints. Zero SinceMs/UntilMs mean unbounded.
+type pagination struct {
+	Limit   int
+	SinceMs int64
+	UntilMs int64
+}
+
+// parsePagination validates ?limit=, ?since_ms= and ?until_ms=. Missing
+// values fall back to defLimit and an open range; malformed ones are reported
+// so the caller can answer 400 rather than silently using the default. The
+// limit is clamped to the handler's maximum and echoed in limitHeader.
+func (h *Handler) parsePagination(w http.ResponseWriter, r *http.Request, defLimit int) (pagination, error) {
+	query := r.URL.Query()
+	page := pagination{Limit: defLimit}
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := parseInt(raw)
+		if err != nil || parsed <= 0 {
+			return pagination{}, errors.New("invalid limit")
+		}
+		page.Limit = parsed
+	}
+	if raw := query.Get("since_ms"); raw != "" {
+		parsed, err := parseInt64(raw)
+		if err != nil || parsed < 0 {
+			return pagination{}, errors.New("invalid since_ms")
+		}
+		page.SinceMs = parsed
+	}
+	if raw := query.Get("until_ms"); raw != "" {
+		parsed, err := parseInt64(raw)
+		if err != nil || parsed < 0 {
+			return pagination{}, errors.New("invalid until_ms")
+		}
+		page.UntilMs = parsed
+	}
+	if page.SinceMs > 0 && page.UntilMs > 0 && page.UntilMs < page.SinceMs {
+		return pagination{}, errors.New("until_ms before since_ms")
+	}
+	page.Limit = h.clampLimit(page.Limit)
+	w.Header().Set(limitHeader, strconv.Itoa(page.Limit))
+	return page, nil
+}
+
+// parseTimeRange validates ?since_ms= and ?until_ms= for endpoints that need
+// a closed window. until defaults to now and since to window before it.
+func parseTimeRange(r *http.Request, window time.Duration) (int64, int64, error) {
+	query := r.URL.Query()
+	untilMs := time.Now().UnixMilli()
+	if raw := query.Get("until_ms"); raw != "" {
+		parsed, err := parseInt64(raw)
+		if err != nil || parsed <= 0 {
+			return 0, 0, errors.New("invalid until_ms")
+		}
+		untilMs = parsed
+	}
+	sinceMs := untilMs - window.Milliseconds()
+	if raw := query.Get("since_ms"); raw != "" {
+		parsed, err := parseInt64(raw)
+		if err != nil || parsed < 0 {
+			return 0, 0, errors.New("invalid since_ms")
+		}
+		sinceMs = parsed
+	}
+	if sinceMs >= untilMs {
+		return 0, 0, errors.New("until_ms before since_ms")
+	}
+	return sinceMs, untilMs, nil
+}
+
+func (h *Handler) clampLimit(limit int) int {
+	if h.maxLimit > 0 && limit > h.maxLimit {
+		return h.maxLimit
+	}
+	return limit
+}
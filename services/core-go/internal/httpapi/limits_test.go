@@ -0,0 +1,137 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"always/core/internal/models"
+)
+
+func TestMaxListLimit(t *testing.T) {
+	for _, tt := range []struct {
+		env  string
+		want int
+	}{
+		{"", defaultMaxListLimit},
+		{"100", 100},
+		{"0", defaultMaxListLimit},
+		{"-5", defaultMaxListLimit},
+		{"lots", defaultMaxListLimit},
+	} {
+		t.Setenv("MAX_LIST_LIMIT", tt.env)
+		if got := maxListLimit(); got != tt.want {
+			t.Errorf("MAX_LIST_LIMIT=%q: maxListLimit = %d, want %d", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestListLimitIsCapped(t *testing.T) {
+	t.Setenv("MAX_LIST_LIMIT", "3")
+	h, store, _ := newTestHandler(t)
+	action := models.Action{ActionType: models.ActionEncourage, Message: "m", Confidence: 0.9, RiskLevel: models.RiskLow}
+	for i := 0; i < 5; i++ {
+		err := store.InsertDecision(models.DecisionLogEntry{
+			RequestID:   fmt.Sprintf("req-%d", i),
+			Context:     models.Context{Mode: models.ModeActive},
+			RawAction:   action,
+			FinalAction: action,
+		})
+		if err != nil {
+			t.Fatalf("insert decision: %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		query     string
+		wantLimit string
+		wantLen   int
+	}{
+		{"?limit=10000000", "3", 3},
+		{"?limit=2", "2", 2},
+		{"", "3", 3},
+	} {
+		rec := serve(t, h, http.MethodGet, "/v1/logs"+tt.query, nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d %s", tt.query, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get(limitHeader); got != tt.wantLimit {
+			t.Errorf("%q: %s = %q, want %q", tt.query, limitHeader, got, tt.wantLimit)
+		}
+		var logs []models.EventLog
+		decodeBody(t, rec, &logs)
+		if len(logs) != tt.wantLen {
+			t.Errorf("%q: got %d logs, want %d", tt.query, len(logs), tt.wantLen)
+		}
+	}
+
+	rec := serve(t, h, http.MethodGet, "/v1/focus/recent?limit=10000000", nil, nil)
+	if got := rec.Header().Get(limitHeader); rec.Code != http.StatusOK || got != "3" {
+		t.Errorf("focus recent: status %d, %s = %q, want 200 and 3", rec.Code, limitHeader, got)
+	}
+}
+
+func TestListEndpointsRejectMalformedParams(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	endpoints := []string{"/v1/logs", "/v1/export", "/v1/focus/recent", "/v1/state/history", "/v1/learning/explanations"}
+	queries := map[string]string{
+		"limit=abc":                   "invalid limit",
+		"limit=0":                     "invalid limit",
+		"limit=-1":                    "invalid limit",
+		"since_ms=yesterday":          "invalid since_ms",
+		"since_ms=-5":                 "invalid since_ms",
+		"until_ms=1.5":                "invalid until_ms",
+		"since_ms=2000&until_ms=1000": "until_ms before since_ms",
+	}
+	for _, endpoint := range endpoints {
+		for query, want := range queries {
+			rec := serve(t, h, http.MethodGet, endpoint+"?"+query, nil, nil)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s?%s: status = %d, want 400", endpoint, query, rec.Code)
+				continue
+			}
+			if got := errorOf(t, rec); got.Code != codeInvalidRequest || got.Error != want {
+				t.Errorf("%s?%s: error = %+v, want %s %q", endpoint, query, got, codeInvalidRequest, want)
+			}
+		}
+		if rec := serve(t, h, http.MethodGet, endpoint+"?limit=5&since_ms=1000&until_ms=2000", nil, nil); rec.Code != http.StatusOK {
+			t.Errorf("%s with valid params: status = %d %s", endpoint, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestOversizedBodyIsRejected(t *testing.T) {
+	h, _, backend := newTestHandler(t)
+	body := decisionBody(uuid.NewString(), models.ModeActive)
+	body["context"].(map[string]any)["user_text"] = strings.Repeat("a", defaultMaxBodyBytes)
+	for _, path := range []string{"/v1/decision", "/v1/feedback"} {
+		rec := serve(t, h, http.MethodPost, path, body, nil)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: status = %d, want 413", path, rec.Code)
+			continue
+		}
+		if got := errorOf(t, rec).Code; got != codeBodyTooLarge {
+			t.Errorf("%s: code = %q, want %q", path, got, codeBodyTooLarge)
+		}
+	}
+	if backend.calls != 0 {
+		t.Errorf("model called %d times for an oversized body", backend.calls)
+	}
+}
+
+func TestBodyLimitIsConfigurable(t *testing.T) {
+	t.Setenv("MAX_BODY_BYTES", "512")
+	h, _, _ := newTestHandler(t)
+	small := decisionBody(uuid.NewString(), models.ModeActive)
+	if rec := serve(t, h, http.MethodPost, "/v1/decision", small, nil); rec.Code != http.StatusOK {
+		t.Errorf("small body: status = %d %s, want 200", rec.Code, rec.Body)
+	}
+	large := decisionBody(uuid.NewString(), models.ModeActive)
+	large["context"].(map[string]any)["user_text"] = strings.Repeat("a", 600)
+	if rec := serve(t, h, http.MethodPost, "/v1/decision", large, nil); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("600 byte user_text under a 512 byte cap: status = %d, want 413", rec.Code)
+	}
+}
@@ -0,0 +1,157 @@
+package httpapi
+
+import (
+	"bufio"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLogExcludePaths are polled by the desktop UI and load balancers
+// often enough that logging every hit drowns out everything else.
+var defaultLogExcludePaths = []string{"/v1/ping", "/v1/health", "/v1/focus/current", "/v1/focus/status"}
+
+// alwaysLoggedPaths stay in the request log whatever LOG_EXCLUDE_PATHS says.
+var alwaysLoggedPaths = map[string]bool{
+	"/v1/decision": true,
+	"/v1/feedback": true,
+}
+
+// logExcludePaths reads LOG_EXCLUDE_PATHS, a comma-separated list of request
+// paths left out of the request log. "none" logs everything.
+func logExcludePaths() map[string]bool {
+	paths := defaultLogExcludePaths
+	if raw := strings.TrimSpace(os.Getenv("LOG_EXCLUDE_PATHS")); raw != "" {
+		paths = nil
+		if !strings.EqualFold(raw, "none") {
+			paths = strings.Split(raw, ",")
+		}
+	}
+	excluded := map[string]bool{}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path != "" && !alwaysLoggedPaths[path] {
+			excluded[path] = true
+		}
+	}
+	return excluded
+}
+
+// logExcludedSampleRate reads LOG_EXCLUDED_SAMPLE_RATE, the fraction of
+// requests to excluded paths that are still logged (default 0).
+func logExcludedSampleRate() float64 {
+	raw := strings.TrimSpace(os.Getenv("LOG_EXCLUDED_SAMPLE_RATE"))
+	if raw == "" {
+		return 0
+	}
+	rate, err := strconv.ParseFloat(raw, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0
+	}
+	return rate
+}
+
+func sortedPaths(paths map[string]bool) string {
+	list := make([]string, 0, len(paths))
+	for path := range paths {
+		list = append(list, path)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// statusRecorder remembers the status code a handler wrote so it can be
+// logged once the request completes. Flush and Hijack pass through, so
+// streaming and upgraded connections keep working behind the middleware.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client when the underlying writer supports it.
+func (s *statusRecorder) Flush() {
+	flusher, ok := s.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	flusher.Flush()
+}
+
+// Hijack hands the connection to the handler, recorded as 101 Switching
+// Protocols since no status line is written through the recorder.
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && s.status == 0 {
+		s.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// /v1/export relies on to extend its write deadline.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Status is the code sent to the client; handlers that never write get 200.
+func (s *statusRecorder) Status() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
+
+func (h *Handler) loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.logExclude[r.URL.Path] && (h.logSample <= 0 || rand.Float64() >= h.logSample) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		h.logger.Info("📥 收到HTTP请求",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("remote", r.RemoteAddr),
+		)
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		// Server errors are logged at warn so error rates can be tracked by level.
+		level := slog.LevelInfo
+		if recorder.Status() >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+		h.logger.Log(r.Context(), level, "✅ 请求处理完成",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.Status()),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
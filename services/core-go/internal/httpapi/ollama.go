@@ -0,0 +1,134 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultOllamaModelsTTL = 5 * time.Minute
+
+type ollamaRequestError struct{ err error }
+
+func (e ollamaRequestError) Error() string { return "build ollama request: " + e.err.Error() }
+func (e ollamaRequestError) Unwrap() error { return e.err }
+
+type ollamaDecodeError struct{ err error }
+
+func (e ollamaDecodeError) Error() string { return "decode ollama models: " + e.err.Error() }
+func (e ollamaDecodeError) Unwrap() error { return e.err }
+
+// ollamaModelCache keeps the last successful /api/tags result so the settings
+// UI keeps working while Ollama restarts.
+type ollamaModelCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	models    []string
+	fetchedAt time.Time
+}
+
+func newOllamaModelCache(ttl time.Duration) *ollamaModelCache {
+	return &ollamaModelCache{ttl: ttl}
+}
+
+func (c *ollamaModelCache) fresh(now time.Time) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fetchedAt.IsZero() || now.Sub(c.fetchedAt) >= c.ttl {
+		return nil, false
+	}
+	return append([]string(nil), c.models...), true
+}
+
+func (c *ollamaModelCache) last() ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fetchedAt.IsZero() {
+		return nil, false
+	}
+	return append([]string(nil), c.models...), true
+}
+
+func (c *ollamaModelCache) store(models []string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.models = append([]string(nil), models...)
+	c.fetchedAt = now
+}
+
+func fetchOllamaModels(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ollamaTagsURL(), nil)
+	if err != nil {
+		return nil, ollamaRequestError{err: err}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call ollama: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama status %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, ollamaDecodeError{err: err}
+	}
+
+	models := make([]string, 0, len(payload.Models))
+	for _, m := range payload.Models {
+		name := strings.TrimSpace(m.Name)
+		if name == "" {
+			continue
+		}
+		models = append(models, name)
+	}
+	return models, nil
+}
+
+func ollamaTagsURL() string {
+	raw := os.Getenv("OLLAMA_URL")
+	if raw == "" {
+		return "http://localhost:11434/api/tags"
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "http://localhost:11434/api/tags"
+	}
+	path := strings.TrimSuffix(parsed.Path, "/")
+	if strings.HasSuffix(path, "/api/tags") {
+		return parsed.String()
+	}
+	if strings.HasSuffix(path, "/api/generate") {
+		path = strings.TrimSuffix(path, "/generate")
+	} else if !strings.HasSuffix(path, "/api") {
+		path = path + "/api"
+	}
+	parsed.Path = path + "/tags"
+	return parsed.String()
+}
+
+// ollamaModelsTTL reads OLLAMA_MODELS_TTL_SECONDS; zero disables caching of
+// fresh results but a stale list is still served while Ollama is down.
+func ollamaModelsTTL() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("OLLAMA_MODELS_TTL_SECONDS"))
+	if raw == "" {
+		return defaultOllamaModelsTTL
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 0 {
+		return defaultOllamaModelsTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
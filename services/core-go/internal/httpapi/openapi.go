@@ -0,0 +1,295 @@
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"always/core/internal/memory"
+	"always/core/internal/models"
+)
+
+// apiOperation describes a route for the OpenAPI spec. Request and Response
+// are sample values whose types are reflected into schemas; a nil Response
+// documents an untyped JSON object.
+type apiOperation struct {
+	Summary  string
+	Query    []string
+	Request  any
+	Response any
+	// List marks responses that are a JSON array of Response.
+	List bool
+}
+
+var rangeQuery = []string{"limit", "since_ms", "until_ms"}
+
+// apiOperations documents the routes by "METHOD pattern". Routes missing
+// here still appear in the spec, since paths come from the live router.
+var apiOperations = map[string]apiOperation{
+	"GET /v1/health":                      {Summary: "Service health and uptime"},
+	"GET /v1/ping":                        {Summary: "Liveness probe with an empty body"},
+	"HEAD /v1/ping":                       {Summary: "Liveness probe with an empty body"},
+	"GET /v1/openapi.json":                {Summary: "This OpenAPI document"},
+	"POST /v1/decision":                   {Summary: "Decide whether and how to intervene", Request: models.DecisionRequest{}, Response: models.DecisionResponse{}},
+	"POST /v1/selftest":                   {Summary: "Run the decision pipeline once on a canned context without side effects", Response: models.SelfTestReport{}},
+	"POST /v1/decision/debug":             {Summary: "Show the enriched context and prompt a decision would use", Request: models.DecisionRequest{}, Response: models.DecisionDebugResponse{}},
+	"POST /v1/decision/{request_id}/tags": {Summary: "Tag a logged decision", Request: models.TagsRequest{}},
+	"POST /v1/decision/{request_id}/ack":  {Summary: "Record when a suggestion was dismissed", Request: models.AckRequest{}, Response: models.DecisionAck{}},
+	"POST /v1/feedback":                   {Summary: "Submit feedback on a decision", Request: models.FeedbackRequest{}},
+	"POST /v1/memory/reset":               {Summary: "Clear learned profiles and memory events"},
+	"GET /v1/memory/export":               {Summary: "Export profiles and memory events", Response: memory.Snapshot{}},
+	"POST /v1/memory/import":              {Summary: "Import an exported memory snapshot", Request: memory.Snapshot{}},
+	"POST /v1/memory/forget":              {Summary: "Forget a learned profile key", Request: models.ForgetRequest{}},
+	"POST /v1/memory/preview-feedback":    {Summary: "Preview how feedback would change profiles", Request: models.FeedbackRequest{}, Response: memory.FeedbackPreview{}},
+	"GET /v1/logs":                        {Summary: "List decision logs", Query: append([]string{"tag", "aggregate", "v"}, rangeQuery...), Response: models.EventLog{}, List: true},
+	"GET /v1/logs/unrated":                {Summary: "List decisions without feedback", Query: append([]string{"v"}, rangeQuery...), Response: models.EventLog{}, List: true},
+	"GET /v1/logs/enriched":               {Summary: "List decision logs with their feedback", Query: append([]string{"tag", "v"}, rangeQuery...), Response: models.EnrichedLog{}, List: true},
+	"GET /v1/focus/current":               {Summary: "Current foreground app", Response: models.FocusCurrent{}},
+	"GET /v1/focus/recent":                {Summary: "Recent focus events", Query: append([]string{"v"}, rangeQuery...), Response: models.FocusEvent{}, List: true},
+	"GET /v1/focus/state":                 {Summary: "Derived focus state", Response: models.FocusStateReading{}},
+	"GET /v1/focus/status":                {Summary: "Focus monitor status", Response: models.FocusStatus{}},
+	"POST /v1/focus/event":                {Summary: "Report the foreground app from a client", Request: models.FocusEventRequest{}},
+	"POST /v1/focus/events":               {Summary: "Report a batch of buffered foreground-app snapshots", Request: models.FocusEventBatchRequest{}},
+	"DELETE /v1/focus/events":             {Summary: "Purge stored focus data", Response: models.FocusPurgeResult{}},
+	"GET /v1/focus/analytics":             {Summary: "Focus time analytics", Query: rangeQuery, Response: models.FocusAnalytics{}},
+	"GET /v1/export":                      {Summary: "Export decision records as NDJSON", Query: append([]string{"fields"}, rangeQuery...), Response: models.ExportRecord{}},
+	"GET /v1/ollama/models":               {Summary: "Models available from Ollama"},
+	"GET /v1/settings":                    {Summary: "List stored settings", Query: []string{"prefix", "keys"}, Response: models.SettingItem{}, List: true},
+	"POST /v1/settings":                   {Summary: "Update a setting", Request: models.SettingRequest{}},
+	"GET /v1/config":                      {Summary: "Effective runtime configuration"},
+	"GET /v1/profile":                     {Summary: "Learned user profile"},
+	"GET /v1/learning/explanations":       {Summary: "Recent learning explanations", Query: []string{"limit", "by"}},
+	"GET /v1/state/history":               {Summary: "Focus state snapshots", Query: rangeQuery, Response: models.FocusStateSnapshot{}, List: true},
+	"GET /v1/gateway/rules":               {Summary: "Gateway rules with live thresholds"},
+	"GET /v1/gateway/simulate":            {Summary: "Simulate the gateway budget over a day", Query: []string{"mode"}},
+	"POST /v1/gateway/replay":             {Summary: "Replay past decisions through the current gateway config"},
+	"GET /v1/gateway/budget-history":      {Summary: "Sampled gateway budget snapshots", Query: rangeQuery, Response: models.BudgetSnapshot{}, List: true},
+	"GET /v1/summary/daily":               {Summary: "Daily summary", Query: []string{"date"}},
+	"GET /v1/stats/weekly":                {Summary: "This week against last week", Response: models.WeeklyTrend{}},
+	"GET /v1/stats/feedback":              {Summary: "Feedback acceptance over time", Query: []string{"interval", "since_ms", "until_ms"}, Response: models.FeedbackStats{}},
+	"GET /v1/stats/variants":              {Summary: "Acceptance per A/B policy variant", Query: []string{"since_ms", "until_ms"}},
+	"GET /v1/stats/dismissals":            {Summary: "Time-to-dismiss per action type", Query: []string{"since_ms", "until_ms"}},
+}
+
+// schemaEnums lists the values of the string types the API constrains.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(models.Mode("")):                {string(models.ModeSilent), string(models.ModeLight), string(models.ModeActive)},
+	reflect.TypeOf(models.RiskLevel("")):           {string(models.RiskLow), string(models.RiskMedium), string(models.RiskHigh)},
+	reflect.TypeOf(models.GatewayDecisionType("")): {string(models.GatewayAllow), string(models.GatewayDeny), string(models.GatewayOverride)},
+	reflect.TypeOf(models.ActionType("")): {
+		string(models.ActionDoNotDisturb), string(models.ActionEncourage), string(models.ActionTaskBreakdown),
+		string(models.ActionRestReminder), string(models.ActionReframe),
+	},
+	reflect.TypeOf(models.FeedbackType("")): {
+		string(models.FeedbackLike), string(models.FeedbackDislike), string(models.FeedbackAdopted), string(models.FeedbackIgnored),
+		string(models.FeedbackClosed), string(models.FeedbackOpen), string(models.FeedbackWantedMore),
+	},
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+func (h *Handler) handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	spec, err := buildOpenAPI(h.Router())
+	if err != nil {
+		h.logger.Error("build openapi failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeInternalError, "openapi error")
+		return
+	}
+	respondJSON(w, http.StatusOK, spec)
+}
+
+// buildOpenAPI describes every route registered on router.
+func buildOpenAPI(router chi.Routes) (map[string]any, error) {
+	schemas := map[string]any{}
+	paths := map[string]map[string]any{}
+	errorRef := schemaRef(reflect.TypeOf(errorResponse{}), schemas)
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// Only the API is described, not the admin page.
+		if !strings.HasPrefix(route, "/v1/") {
+			return nil
+		}
+		op := apiOperations[method+" "+route]
+		operation := map[string]any{
+			"operationId": operationID(method, route),
+			"responses": map[string]any{
+				"200":     map[string]any{"description": "OK", "content": jsonContent(op.responseSchema(schemas))},
+				"default": map[string]any{"description": "Error", "content": jsonContent(errorRef)},
+			},
+		}
+		if op.Summary != "" {
+			operation["summary"] = op.Summary
+		}
+		params := []any{}
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route, -1) {
+			params = append(params, map[string]any{"name": match[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+		}
+		for _, name := range op.Query {
+			params = append(params, map[string]any{"name": name, "in": "query", "schema": map[string]any{"type": "string"}})
+		}
+		if route != "/v1/health" && route != "/v1/ping" {
+			params = append(params, map[string]any{"name": "X-User-ID", "in": "header", "schema": map[string]any{"type": "string"}})
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(schemaRef(reflect.TypeOf(op.Request), schemas)),
+			}
+		}
+		if paths[route] == nil {
+			paths[route] = map[string]any{}
+		}
+		paths[route][strings.ToLower(method)] = operation
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Always core API",
+			"version": "v1",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}, nil
+}
+
+func (op apiOperation) responseSchema(schemas map[string]any) map[string]any {
+	if op.Response == nil {
+		return map[string]any{"type": "object"}
+	}
+	schema := schemaRef(reflect.TypeOf(op.Response), schemas)
+	if op.List {
+		return map[string]any{"type": "array", "items": schema}
+	}
+	return schema
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// operationID turns "POST /v1/decision/{request_id}/tags" into
+// "postDecisionRequestIdTags".
+func operationID(method, route string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range strings.FieldsFunc(strings.TrimPrefix(route, "/v1"), func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '_' || r == '-' || r == '.'
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// schemaRef returns the schema for t, registering named structs under
+// components/schemas and referring to them by $ref.
+func schemaRef(t reflect.Type, schemas map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if values, ok := schemaEnums[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		name := schemaName(t)
+		if _, ok := schemas[name]; !ok {
+			// Reserve the name first so recursive types terminate.
+			schemas[name] = map[string]any{}
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// schemaName qualifies types outside models with their package, e.g.
+// memory.Snapshot becomes MemorySnapshot.
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	if pkg == "models" || pkg == "httpapi" {
+		return t.Name()
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name()
+}
+
+// structSchema follows encoding/json: embedded structs are flattened, "-"
+// fields skipped, and fields without omitempty are required.
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				collect(field.Type)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			property := schemaRef(field.Type, schemas)
+			switch {
+			case field.Type.Kind() != reflect.Pointer:
+				if !strings.Contains(opts, "omitempty") {
+					required = append(required, name)
+				}
+			case strings.Contains(opts, "omitempty"):
+			case property["$ref"] != nil:
+				// A nil pointer without omitempty encodes as null.
+				property = map[string]any{"allOf": []any{property}, "nullable": true}
+			default:
+				property["nullable"] = true
+			}
+			properties[name] = property
+		}
+	}
+	collect(t)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,33 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	routed := map[string]bool{}
+	err := chi.Walk(h.Router(), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, "/v1/") {
+			return nil
+		}
+		key := method + " " + route
+		routed[key] = true
+		if apiOperations[key].Summary == "" {
+			t.Errorf("%s is not documented in apiOperations", key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk router: %v", err)
+	}
+	for key := range apiOperations {
+		if !routed[key] {
+			t.Errorf("apiOperations documents %s, which is not routed", key)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"always/core/internal/ai"
+	"always/core/internal/memory"
+	"always/core/internal/models"
+)
+
+// Self-test components, in the order they run.
+const (
+	selfTestDB      = "db"
+	selfTestMemory  = "memory"
+	selfTestAI      = "ai"
+	selfTestGateway = "gateway"
+)
+
+// selfTestAction stands in for the model's action when the AI check fails,
+// so the gateway is still exercised.
+var selfTestAction = models.Action{
+	ActionType: models.ActionEncourage,
+	Message:    "self-test",
+	Confidence: 1,
+	Cost:       1,
+	RiskLevel:  models.RiskLow,
+}
+
+// handleSelfTest runs the decision pipeline once against a canned context and
+// reports each component. It is a dry run: the decision is not logged, the
+// gateway is evaluated on a copy of its state and nothing is written to
+// memory. It answers 503 when any component fails so deploy scripts can rely
+// on the status alone.
+func (h *Handler) handleSelfTest(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	report := models.SelfTestReport{Passed: true}
+	run := func(component string, check func() (map[string]any, error)) {
+		checkStart := time.Now()
+		details, err := check()
+		result := models.SelfTestCheck{
+			Component:  component,
+			Passed:     err == nil,
+			DurationMs: time.Since(checkStart).Milliseconds(),
+			Details:    details,
+		}
+		if err != nil {
+			result.Error = err.Error()
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	ctx := models.Context{
+		Mode:       models.ModeLight,
+		FocusState: "LIGHT",
+		Signals: map[string]string{
+			"hour_of_day": strconv.Itoa(time.Now().In(userLocation(h.store)).Hour()),
+		},
+	}
+
+	run(selfTestDB, func() (map[string]any, error) {
+		pingCtx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		if err := h.store.DB().PingContext(pingCtx); err != nil {
+			return nil, err
+		}
+		if _, err := h.store.GetBudgetUsage(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+
+	run(selfTestMemory, func() (map[string]any, error) {
+		profiles, err := h.memory.ListProfiles()
+		if err != nil {
+			return nil, err
+		}
+		events, err := h.memory.ListEvents(1, memory.OrderRecent)
+		if err != nil {
+			return nil, err
+		}
+		ctx.ProfileSummary = h.memory.GetProfileSummary()
+		ctx.MemorySummary = h.memory.GetRecentEvents(5)
+		return map[string]any{"profiles": len(profiles), "has_events": len(events) > 0}, nil
+	})
+
+	action := selfTestAction
+	actionSource := "canned"
+	run(selfTestAI, func() (map[string]any, error) {
+		raw, policyVersion, modelVersion, err := h.ai.Decide(ctx, "selftest-"+uuid.NewString())
+		var invalid ai.InvalidResponseError
+		if errors.As(err, &invalid) {
+			return map[string]any{"raw": truncateUTF8(invalid.Raw, maxLoggedAIResponse)}, err
+		}
+		if err != nil {
+			return nil, err
+		}
+		action = raw
+		actionSource = "ai"
+		return map[string]any{
+			"action_type":    raw.ActionType,
+			"policy_version": policyVersion,
+			"model_version":  modelVersion,
+		}, nil
+	})
+
+	run(selfTestGateway, func() (map[string]any, error) {
+		_, decision := h.gateway.DryRun(ctx, action, evalOptions(ctx))
+		if decision.Decision == "" {
+			return nil, errors.New("gateway returned no decision")
+		}
+		return map[string]any{
+			"action_source": actionSource,
+			"decision":      decision.Decision,
+			"reason":        decision.Reason,
+		}, nil
+	})
+
+	report.DurationMs = time.Since(start).Milliseconds()
+	status := http.StatusOK
+	if !report.Passed {
+		h.logger.Warn("self-test failed", slog.Any("checks", report.Checks))
+		status = http.StatusServiceUnavailable
+	}
+	respondJSON(w, status, report)
+}
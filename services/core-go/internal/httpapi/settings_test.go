@@ -0,0 +1,110 @@
+package httpapi
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+// settingKeys parses the key constants out of the settings package source,
+// mapping each key to the constant that declares it.
+func settingKeys(t *testing.T) map[string]string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "../settings/keys.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse keys.go: %v", err)
+	}
+	keys := map[string]string{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for i, name := range value.Names {
+				lit, ok := value.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				key, _ := strconv.Unquote(lit.Value)
+				if other, dup := keys[key]; dup {
+					t.Errorf("settings.%s and settings.%s share the key %q", other, name.Name, key)
+				}
+				keys[key] = name.Name
+			}
+		}
+	}
+	return keys
+}
+
+func TestEverySettingKeyIsAllowed(t *testing.T) {
+	internal := map[string]bool{settings.LastAutoSuggestMs: true, settings.NextAutoSuggestMs: true}
+	keys := settingKeys(t)
+	for key, name := range keys {
+		if allowedSettings[key] == internal[key] {
+			t.Errorf("settings.%s (%q): allowed = %v, internal = %v", name, key, allowedSettings[key], internal[key])
+		}
+	}
+	for key := range allowedSettings {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("allowed setting %q is not declared in the settings package", key)
+		}
+	}
+}
+
+func TestLearnWeightSettingsAreValidated(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	for _, key := range []string{settings.NegativeWeight, settings.PositiveWeight} {
+		for _, tt := range []struct {
+			value      string
+			wantStatus int
+		}{
+			{"0", http.StatusOK},
+			{"1", http.StatusOK},
+			{"0.45", http.StatusOK},
+			{"1.5", http.StatusBadRequest},
+			{"-0.1", http.StatusBadRequest},
+			{"high", http.StatusBadRequest},
+		} {
+			rec := serve(t, h, http.MethodPost, "/v1/settings", models.SettingRequest{Key: key, Value: tt.value}, nil)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("%s=%s: status = %d %s, want %d", key, tt.value, rec.Code, rec.Body, tt.wantStatus)
+			}
+		}
+		if got, _, _ := store.GetSetting(key); got != "0.45" {
+			t.Errorf("%s stored %q, want the last valid value 0.45", key, got)
+		}
+	}
+}
+
+func TestLoadDecisionSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   decisionSettings
+	}{
+		{"defaults", nil, decisionSettings{AgentEnabled: true}},
+		{"agent off", map[string]string{settings.AgentEnabled: "false"}, decisionSettings{}},
+		{"rule only", map[string]string{settings.RuleOnlyMode: "true"}, decisionSettings{AgentEnabled: true, RuleOnly: true}},
+		{"unparsable keeps defaults", map[string]string{settings.AgentEnabled: "maybe"}, decisionSettings{AgentEnabled: true}},
+	}
+	for _, tt := range tests {
+		store := newTestStore(t)
+		for key, value := range tt.values {
+			if err := store.UpsertSetting(key, value); err != nil {
+				t.Fatalf("set %s: %v", key, err)
+			}
+		}
+		got, err := loadDecisionSettings(store)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: loadDecisionSettings = %+v, %v; want %+v", tt.name, got, err, tt.want)
+		}
+	}
+}
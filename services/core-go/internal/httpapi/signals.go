@@ -0,0 +1,169 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"always/core/internal/focus"
+)
+
+type signalKind int
+
+const (
+	signalString signalKind = iota
+	signalInt
+	signalFloat
+	signalBool
+)
+
+const maxSignalValueLen = 256
+
+// signalSpec describes a known signal. Numeric signals must fall within
+// [0, max]; anything outside is a client bug rather than a real measurement.
+type signalSpec struct {
+	kind signalKind
+	max  float64
+}
+
+const (
+	maxSignalMinutes     = 24 * 60
+	maxSignalSwitchCount = 10_000
+)
+
+// knownSignals lists every signal the core or the AI service reads. Anything
+// else is prompt bloat at best.
+var knownSignals = map[string]signalSpec{
+	"hour_of_day":          {kind: signalInt, max: 23},
+	"session_minutes":      {kind: signalFloat, max: maxSignalMinutes},
+	"switch_count":         {kind: signalInt, max: maxSignalSwitchCount},
+	"title_switch_count":   {kind: signalInt, max: maxSignalSwitchCount},
+	"no_progress_minutes":  {kind: signalFloat, max: maxSignalMinutes},
+	"focus_minutes":        {kind: signalFloat, max: maxSignalMinutes},
+	"focus_minutes_window": {kind: signalFloat, max: maxSignalMinutes},
+	"focus_state":          {kind: signalString},
+	"focus_score":          {kind: signalInt, max: 100},
+	"focus_app":            {kind: signalString},
+	"focus_bundle_id":      {kind: signalString},
+	"focus_window_title":   {kind: signalString},
+	"quiet_hours":          {kind: signalString},
+	"intervention_budget":  {kind: signalString},
+	"ollama_model":         {kind: signalString},
+	"disabled_actions":     {kind: signalString},
+	"hour_tolerance":       {kind: signalString},
+	"agent_enabled":        {kind: signalBool},
+	"rule_only_mode":       {kind: signalBool},
+	"rule_only":            {kind: signalBool},
+	"cooldown_active":      {kind: signalBool},
+	"cooldown_until_ms":    {kind: signalInt, max: math.MaxInt64},
+	"budget_exhausted":     {kind: signalBool},
+	"budget_remaining":     {kind: signalFloat, max: math.MaxFloat64},
+	"policy_variant":       {kind: signalString},
+	"session_id":           {kind: signalString},
+	"in_meeting":           {kind: signalBool},
+}
+
+// sanitizeSignals coerces known signals in place and removes unknown keys,
+// returning the dropped keys. In strict mode an unknown key is an error instead.
+func sanitizeSignals(signals map[string]string, strict bool) ([]string, error) {
+	var dropped []string
+	for key, value := range signals {
+		if strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("signals key required")
+		}
+		spec, ok := knownSignals[key]
+		if !ok {
+			if strict {
+				return nil, fmt.Errorf("unknown signal %s", key)
+			}
+			dropped = append(dropped, key)
+			delete(signals, key)
+			continue
+		}
+		coerced, err := coerceSignal(spec, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signal %s: %w", key, err)
+		}
+		signals[key] = coerced
+	}
+	sort.Strings(dropped)
+	return dropped, nil
+}
+
+func coerceSignal(spec signalSpec, value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return "", nil
+	}
+	switch spec.kind {
+	case signalInt:
+		parsed, err := strconv.ParseInt(trimmed, 10, 64)
+		if err != nil {
+			return "", errors.New("not an integer")
+		}
+		if err := checkSignalRange(float64(parsed), spec.max); err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(parsed, 10), nil
+	case signalFloat:
+		parsed, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+			return "", errors.New("not a number")
+		}
+		if err := checkSignalRange(parsed, spec.max); err != nil {
+			return "", err
+		}
+		return trimmed, nil
+	case signalBool:
+		parsed, err := strconv.ParseBool(strings.ToLower(trimmed))
+		if err != nil {
+			return "", errors.New("not a boolean")
+		}
+		return strconv.FormatBool(parsed), nil
+	default:
+		if len(trimmed) > maxSignalValueLen {
+			trimmed = truncateUTF8(trimmed, maxSignalValueLen)
+		}
+		return trimmed, nil
+	}
+}
+
+func checkSignalRange(value, limit float64) error {
+	if value < 0 {
+		return errors.New("must not be negative")
+	}
+	if value > limit {
+		return fmt.Errorf("must be at most %g", limit)
+	}
+	return nil
+}
+
+func truncateUTF8(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut]
+}
+
+// focusMinutesPrecision reads FOCUS_MINUTES_PRECISION, the number of decimals
+// focus minutes are presented with in signals and focus responses.
+func focusMinutesPrecision() int {
+	raw := strings.TrimSpace(os.Getenv("FOCUS_MINUTES_PRECISION"))
+	if raw == "" {
+		return focus.DefaultMinutesPrecision
+	}
+	precision, err := strconv.Atoi(raw)
+	if err != nil || precision < 0 || precision > focus.MaxMinutesPrecision {
+		return focus.DefaultMinutesPrecision
+	}
+	return precision
+}
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"always/core/internal/models"
+)
+
+func TestSanitizeSignalsRanges(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       string // coerced value, or the error substring when wantErr
+		wantErr    bool
+	}{
+		{"session_minutes", "45.5", "45.5", false},
+		{"session_minutes", "1440", "1440", false},
+		{"session_minutes", "1440.5", "must be at most", true},
+		{"session_minutes", "99999999", "must be at most", true},
+		{"session_minutes", "-1", "must not be negative", true},
+		{"session_minutes", "1e400", "not a number", true},
+		{"focus_minutes", "NaN", "not a number", true},
+		{"switch_count", "0", "0", false},
+		{"switch_count", " 10000 ", "10000", false},
+		{"switch_count", "10001", "must be at most", true},
+		{"switch_count", "-3", "must not be negative", true},
+		{"switch_count", "9223372036854775808", "not an integer", true},
+		{"hour_of_day", "24", "must be at most", true},
+		{"focus_score", "101", "must be at most", true},
+		{"no_progress_minutes", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			signals := map[string]string{tt.key: tt.value}
+			_, err := sanitizeSignals(signals, false)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if signals[tt.key] != tt.want {
+				t.Errorf("coerced = %q, want %q", signals[tt.key], tt.want)
+			}
+		})
+	}
+}
+
+func TestDecisionRejectsOutOfRangeSignals(t *testing.T) {
+	h, _, backend := newTestHandler(t)
+	for _, signals := range []map[string]any{
+		{"session_minutes": "99999999"},
+		{"switch_count": "-1"},
+	} {
+		body := decisionBody(uuid.NewString(), models.ModeActive)
+		body["context"].(map[string]any)["signals"] = signals
+		rec := serve(t, h, http.MethodPost, "/v1/decision", body, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%v: status = %d %s, want 400", signals, rec.Code, rec.Body)
+			continue
+		}
+		if got := errorOf(t, rec); got.Code != codeInvalidRequest || !strings.Contains(got.Error, "invalid signal") {
+			t.Errorf("%v: error = %+v, want an invalid signal %s", signals, got, codeInvalidRequest)
+		}
+	}
+
+	body := decisionBody(uuid.NewString(), models.ModeActive)
+	body["context"].(map[string]any)["switch_count"] = maxSignalSwitchCount + 1
+	rec := serve(t, h, http.MethodPost, "/v1/decision", body, nil)
+	if rec.Code != http.StatusBadRequest || errorOf(t, rec).Error != "invalid switch_count" {
+		t.Errorf("switch_count overflow: status = %d %s, want 400 invalid switch_count", rec.Code, rec.Body)
+	}
+	if backend.calls != 0 {
+		t.Errorf("model called %d times for rejected contexts", backend.calls)
+	}
+}
@@ -0,0 +1,43 @@
+package httpapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+func TestDailySummaryUsesUserTimezone(t *testing.T) {
+	h, store, _ := newTestHandler(t)
+	// UTC+14: noon UTC on March 2 is already March 3 for the user.
+	if err := store.UpsertSetting(settings.Timezone, "Pacific/Kiritimati"); err != nil {
+		t.Fatalf("set timezone: %v", err)
+	}
+	action := models.Action{ActionType: models.ActionEncourage, Message: "m", Confidence: 0.9, RiskLevel: models.RiskLow}
+	err := store.InsertDecision(models.DecisionLogEntry{
+		RequestID:   "req-1",
+		Context:     models.Context{Mode: models.ModeActive},
+		RawAction:   action,
+		FinalAction: action,
+		CreatedAt:   time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("insert decision: %v", err)
+	}
+
+	for date, want := range map[string]int{"2026-03-02": 0, "2026-03-03": 1} {
+		rec := serve(t, h, http.MethodGet, "/v1/summary/daily?date="+date, nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body %s", date, rec.Code, rec.Body)
+		}
+		var body struct {
+			Summary models.DailySummary `json:"summary"`
+		}
+		decodeBody(t, rec, &body)
+		if body.Summary.Interventions != want {
+			t.Errorf("%s: interventions = %d, want %d", date, body.Summary.Interventions, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+
+	"always/core/internal/models"
+)
+
+const maxTagsPerRequest = 16
+
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,64}$`)
+
+func isValidTag(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
+
+// handleDecisionTags attaches research tags to a logged decision. Tags are
+// bookkeeping only and never feed back into the decision pipeline.
+func (h *Handler) handleDecisionTags(w http.ResponseWriter, r *http.Request) {
+	requestID := chi.URLParam(r, "request_id")
+	var req models.TagsRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	if len(req.Tags) == 0 {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "tags required")
+		return
+	}
+	if len(req.Tags) > maxTagsPerRequest {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, "too many tags")
+		return
+	}
+	for _, tag := range req.Tags {
+		if !isValidTag(tag) {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, "invalid tag")
+			return
+		}
+	}
+
+	exists, err := h.store.DecisionExists(requestID)
+	if err != nil {
+		h.logger.Error("check request_id failed", slog.String("request_id", requestID), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, codeNotFound, "request_id not found")
+		return
+	}
+	tags, err := h.store.AddTags(requestID, req.Tags)
+	if err != nil {
+		h.logger.Error("add tags failed", slog.String("request_id", requestID), slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"request_id": requestID, "tags": tags})
+}
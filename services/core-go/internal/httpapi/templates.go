@@ -0,0 +1,119 @@
+package httpapi
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"always/core/internal/gateway"
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+const (
+	maxMessageTemplateLen = 500
+	maxMessageLen         = 500
+)
+
+// messageVars are the context values a message template can use, written as
+// {{focus_minutes}}. Anything else fails to parse.
+var messageVars = []string{"focus_minutes", "app_name", "switch_count", "mode", "focus_state", "reason"}
+
+// parseMessageTemplate parses text with every messageVars name bound to its
+// entry in values.
+func parseMessageTemplate(text string, values map[string]string) (*template.Template, error) {
+	if len(text) > maxMessageTemplateLen {
+		return nil, fmt.Errorf("template longer than %d bytes", maxMessageTemplateLen)
+	}
+	funcs := template.FuncMap{}
+	for _, name := range messageVars {
+		value := values[name]
+		funcs[name] = func() string { return value }
+	}
+	return template.New("message").Funcs(funcs).Parse(text)
+}
+
+// renderMessage returns the operator's template stored under key rendered
+// against ctx, or fallback when none is set or it fails to render.
+func (h *Handler) renderMessage(key string, ctx models.Context, reason, fallback string) string {
+	text, err := settings.New(h.store).GetString(key, "")
+	if err != nil || text == "" || text == "none" {
+		return fallback
+	}
+	tmpl, err := parseMessageTemplate(text, messageValues(ctx, reason))
+	if err != nil {
+		h.logger.Warn("message template invalid", slog.String("key", key), slog.Any("error", err))
+		return fallback
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, nil); err != nil {
+		h.logger.Warn("message template failed", slog.String("key", key), slog.Any("error", err))
+		return fallback
+	}
+	message := sanitizeMessage(out.String())
+	if message == "" {
+		return fallback
+	}
+	return message
+}
+
+func messageValues(ctx models.Context, reason string) map[string]string {
+	switchCount := ctx.Signals["switch_count"]
+	if switchCount == "" {
+		switchCount = strconv.Itoa(ctx.SwitchCount)
+	}
+	focusMinutes := ctx.Signals["focus_minutes"]
+	if parsed, err := strconv.ParseFloat(focusMinutes, 64); err == nil {
+		focusMinutes = strconv.Itoa(int(parsed))
+	}
+	return map[string]string{
+		"focus_minutes": focusMinutes,
+		"app_name":      ctx.Signals["focus_app"],
+		"switch_count":  switchCount,
+		"mode":          string(ctx.Mode),
+		"focus_state":   ctx.FocusState,
+		"reason":        reason,
+	}
+}
+
+// sanitizeMessage drops control characters, which could come in through an
+// app name, and caps the length of a rendered message.
+func sanitizeMessage(message string) string {
+	message = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' {
+			return -1
+		}
+		return r
+	}, message))
+	return truncateUTF8(message, maxMessageLen)
+}
+
+// evaluate runs action through the gateway, rendering message_override_template
+// over the built-in message when the gateway replaces it.
+func (h *Handler) evaluate(ctx models.Context, action models.Action) (models.Action, models.GatewayDecision) {
+	return h.evaluateWith(ctx, action, evalOptions(ctx))
+}
+
+// evaluateModel is evaluate for an action the model proposed: it also hands
+// the gateway the session's recent model messages, so a model stuck
+// repeating itself is caught.
+func (h *Handler) evaluateModel(ctx models.Context, action models.Action) (models.Action, models.GatewayDecision) {
+	opts := evalOptions(ctx)
+	recent, err := h.store.RecentModelMessages(ctx.Signals[sessionIDSignal], gateway.RepetitionWindow)
+	if err != nil {
+		h.logger.Warn("read recent model messages failed", slog.Any("error", err))
+	}
+	opts.RecentModelMessages = recent
+	return h.evaluateWith(ctx, action, opts)
+}
+
+func (h *Handler) evaluateWith(ctx models.Context, action models.Action, opts gateway.EvalOptions) (models.Action, models.GatewayDecision) {
+	finalAction, decision := h.gateway.Evaluate(ctx, action, opts)
+	if decision.Decision != models.GatewayAllow {
+		finalAction.Message = h.renderMessage(settings.MessageOverrideTemplate, ctx, decision.Reason, finalAction.Message)
+	}
+	return finalAction, decision
+}
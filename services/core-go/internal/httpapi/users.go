@@ -0,0 +1,142 @@
+package httpapi
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"always/core/internal/db"
+	"always/core/internal/gateway"
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+const userIDHeader = "X-User-ID"
+
+var userIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// machineSettings configure the shared focus monitor and AI backend rather than
+// a person, so they are always stored under the default user.
+var machineSettings = map[string]bool{
+	settings.FocusMonitorEnabled:  true,
+	settings.FocusIngestEnabled:   true,
+	settings.FocusProvider:        true,
+	settings.TitleSwitchThreshold: true,
+	settings.FocusScoreWeights:    true,
+	settings.FocusPollMs:          true,
+	settings.BatteryBackoff:       true,
+	settings.BatteryPollFactor:    true,
+	settings.RedactWindowTitles:   true,
+	settings.FocusAppBlocklist:    true,
+	settings.FocusAppAllowlist:    true,
+	settings.NoProgressInputReset: true,
+	settings.AIBackend:            true,
+}
+
+// userScopes holds the default user's store and one gateway per user, so
+// budgets and cooldowns are tracked separately for everyone on the machine.
+type userScopes struct {
+	mu       sync.Mutex
+	logger   *slog.Logger
+	store    *db.Store
+	gateways map[string]*gateway.Gateway
+}
+
+func newUserScopes(logger *slog.Logger, store *db.Store, defaultGateway *gateway.Gateway) *userScopes {
+	return &userScopes{
+		logger:   logger,
+		store:    store,
+		gateways: map[string]*gateway.Gateway{models.DefaultUserID: defaultGateway},
+	}
+}
+
+func (u *userScopes) gateway(store *db.Store) *gateway.Gateway {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	gw, ok := u.gateways[store.UserID()]
+	if !ok {
+		gw = gateway.New(u.logger, store)
+		u.gateways[store.UserID()] = gw
+	}
+	return gw
+}
+
+// userIDFromRequest returns the X-User-ID header, or the default user when absent.
+func userIDFromRequest(r *http.Request) (string, error) {
+	userID := strings.TrimSpace(r.Header.Get(userIDHeader))
+	if userID == "" {
+		return models.DefaultUserID, nil
+	}
+	if !userIDPattern.MatchString(userID) {
+		return "", errors.New("invalid " + userIDHeader)
+	}
+	return userID, nil
+}
+
+// scoped adapts a handler method so it runs against the store, memory and
+// gateway of the user named by the request's X-User-ID header.
+func (h *Handler) scoped(fn func(*Handler, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := userIDFromRequest(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+		fn(h.forUser(userID), w, r)
+	}
+}
+
+func (h *Handler) forUser(userID string) *Handler {
+	if userID == h.store.UserID() {
+		return h
+	}
+	scoped := *h
+	scoped.store = h.users.store.ForUser(userID)
+	scoped.memory = h.memory.ForUser(userID)
+	scoped.gateway = h.users.gateway(scoped.store)
+	return &scoped
+}
+
+// settingsStore returns the store key is persisted in for the current user.
+func (h *Handler) settingsStore(key string) *db.Store {
+	if machineSettings[key] {
+		return h.users.store
+	}
+	return h.store
+}
+
+// machineStore returns the store machineSettings live in: the default
+// user's, on the same database as store.
+func machineStore(store *db.Store) *db.Store {
+	return store.ForUser(models.DefaultUserID)
+}
+
+// listSettings returns the current user's settings whose key starts with
+// prefix together with the matching machine-wide ones, ordered by key.
+func (h *Handler) listSettings(prefix string) ([]models.SettingItem, error) {
+	values, err := h.store.ListSettingsByPrefix(prefix)
+	if err != nil || h.store.UserID() == models.DefaultUserID {
+		return values, err
+	}
+	shared, err := h.users.store.ListSettingsByPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	merged := values[:0]
+	for _, item := range values {
+		if !machineSettings[item.Key] {
+			merged = append(merged, item)
+		}
+	}
+	for _, item := range shared {
+		if machineSettings[item.Key] {
+			merged = append(merged, item)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Key < merged[j].Key })
+	return merged, nil
+}
@@ -0,0 +1,29 @@
+package httpapi
+
+import (
+	"testing"
+
+	"always/core/internal/settings"
+)
+
+func TestReadFocusStateUsesMachineSettingsForEveryUser(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.UpsertSetting(settings.FocusScoreWeights, "focus=1,switch=0,title=0,no_progress=0"); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	want, ok := readFocusState(store, nil)
+	if !ok {
+		t.Fatal("readFocusState failed for the default user")
+	}
+	got, ok := readFocusState(store.ForUser("alice"), nil)
+	if !ok {
+		t.Fatal("readFocusState failed for alice")
+	}
+	if got.FocusScore != want.FocusScore {
+		t.Errorf("alice focus score = %d, want %d from the machine weights", got.FocusScore, want.FocusScore)
+	}
+	if want.FocusScore != 0 {
+		t.Errorf("focus score with no focus time = %d, want 0 under focus-only weights", want.FocusScore)
+	}
+}
@@ -0,0 +1,119 @@
+package httpapi
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"always/core/internal/ai"
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+const (
+	// policyVariantSignal carries the A/B variant a decision runs under to
+	// the AI backend. It is also recorded in the stored policy version.
+	policyVariantSignal = ai.PolicyVariantSignal
+	// sessionIDSignal keys the hash-based variant assignment, so a session
+	// stays on one variant.
+	sessionIDSignal   = "session_id"
+	maxPolicyVariants = 8
+	maxVariantNameLen = 32
+)
+
+const defaultVariantStatsWindow = 7 * 24 * time.Hour
+
+func isValidVariantName(name string) bool {
+	if name == "" || len(name) > maxVariantNameLen {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePolicyVariants reads the policy_variants setting: a comma-separated
+// list of variant names, or "none" for no A/B test.
+func parsePolicyVariants(raw string) ([]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, "none") {
+		return nil, nil
+	}
+	var variants []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" || seen[name] {
+			continue
+		}
+		if !isValidVariantName(name) {
+			return nil, fmt.Errorf("invalid policy variant %q", name)
+		}
+		seen[name] = true
+		variants = append(variants, name)
+	}
+	if len(variants) > maxPolicyVariants {
+		return nil, fmt.Errorf("at most %d policy variants", maxPolicyVariants)
+	}
+	return variants, nil
+}
+
+// assignPolicyVariant sets the policy_variant signal. A variant the client
+// sent is kept; otherwise, while policy_variants is configured, one is picked
+// by hashing the session_id signal, or requestID when there is none.
+func (h *Handler) assignPolicyVariant(ctx *models.Context, requestID string) error {
+	if variant := strings.ToLower(ctx.Signals[policyVariantSignal]); variant != "" {
+		if !isValidVariantName(variant) {
+			return fmt.Errorf("invalid signal %s", policyVariantSignal)
+		}
+		ctx.Signals[policyVariantSignal] = variant
+		return nil
+	}
+	raw, err := settings.New(h.store).GetString(settings.PolicyVariants, "")
+	if err != nil {
+		return err
+	}
+	variants, err := parsePolicyVariants(raw)
+	if err != nil || len(variants) == 0 {
+		return nil
+	}
+	key := ctx.Signals[sessionIDSignal]
+	if key == "" {
+		key = requestID
+	}
+	ctx.Signals[policyVariantSignal] = pickVariant(variants, key)
+	return nil
+}
+
+func pickVariant(variants []string, key string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return variants[hash.Sum32()%uint32(len(variants))]
+}
+
+// handleVariantStats compares acceptance across A/B policy variants, by
+// default over the last 7 days.
+func (h *Handler) handleVariantStats(w http.ResponseWriter, r *http.Request) {
+	sinceMs, untilMs, err := parseTimeRange(r, defaultVariantStatsWindow)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	variants, err := h.store.VariantStats(sinceMs, untilMs)
+	if err != nil {
+		h.logger.Error("variant stats failed", slog.Any("error", err))
+		respondError(w, http.StatusInternalServerError, codeDBError, "db error")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"since_ms": sinceMs,
+		"until_ms": untilMs,
+		"variants": variants,
+	})
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// focusTrendMinSnapshots is how many snapshots the current part of the day
+// needs before its share of a state is worth reporting.
+const focusTrendMinSnapshots = 3
+
+// GetRecentEventsWithFocus is GetRecentEvents preceded by lines synthesized
+// from today's focus-state snapshots, such as "Distracted 3 times today", so
+// the model sees the focus trend alongside feedback-derived memories.
+func (s *Service) GetRecentEventsWithFocus(limit int, now time.Time) string {
+	lines := s.focusTrend(now)
+	if events := s.GetRecentEvents(limit); events != "" {
+		lines = append(lines, events)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// focusTrend counts how often the user slipped into DISTRACTED or
+// NO_PROGRESS since local midnight and characterises the current part of the
+// day when most of its snapshots share a state.
+func (s *Service) focusTrend(now time.Time) []string {
+	now = now.In(s.Location())
+	year, month, day := now.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	rows, err := s.db.Query(
+		`SELECT ts_ms, focus_state FROM focus_state_snapshots
+		 WHERE ts_ms >= ? AND ts_ms <= ? ORDER BY ts_ms ASC, id ASC`,
+		midnight.UnixMilli(), now.UnixMilli(),
+	)
+	if err != nil {
+		s.logger.Error("failed to query focus snapshots", slog.Any("error", err))
+		return nil
+	}
+	defer rows.Close()
+
+	period, periodStart := partOfDay(now)
+	entered := map[string]int{}
+	periodStates := map[string]int{}
+	periodTotal := 0
+	previous := ""
+	for rows.Next() {
+		var tsMs int64
+		var state string
+		if err := rows.Scan(&tsMs, &state); err != nil {
+			continue
+		}
+		if state != previous {
+			entered[state]++
+		}
+		previous = state
+		if tsMs >= periodStart.UnixMilli() {
+			periodStates[state]++
+			periodTotal++
+		}
+	}
+
+	var lines []string
+	if n := entered["DISTRACTED"]; n > 0 {
+		lines = append(lines, fmt.Sprintf("- Distracted %s today", times(n)))
+	}
+	if n := entered["NO_PROGRESS"]; n > 0 {
+		lines = append(lines, fmt.Sprintf("- Stuck without progress %s today", times(n)))
+	}
+	if periodTotal >= focusTrendMinSnapshots {
+		switch {
+		case periodStates["DISTRACTED"]*2 >= periodTotal:
+			lines = append(lines, "- High distraction this "+period)
+		case periodStates["FOCUSED"]*10 >= periodTotal*7:
+			lines = append(lines, "- Mostly focused this "+period)
+		}
+	}
+	return lines
+}
+
+// partOfDay names the part of the day now falls in and when it began.
+func partOfDay(now time.Time) (string, time.Time) {
+	year, month, day := now.Date()
+	at := func(hour int) time.Time { return time.Date(year, month, day, hour, 0, 0, 0, now.Location()) }
+	switch hour := now.Hour(); {
+	case hour < 5:
+		return "night", at(0)
+	case hour < 12:
+		return "morning", at(5)
+	case hour < 18:
+		return "afternoon", at(12)
+	default:
+		return "evening", at(18)
+	}
+}
+
+func times(n int) string {
+	if n == 1 {
+		return "once"
+	}
+	return fmt.Sprintf("%d times", n)
+}
@@ -3,6 +3,7 @@ package memory
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
@@ -10,26 +11,91 @@ import (
 	"time"
 
 	"always/core/internal/models"
+	"always/core/internal/settings"
 )
 
+const (
+	defaultHalfLifeDays = 21.0
+	// profileDropFloor is the decayed confidence below which Compact forgets a profile.
+	profileDropFloor = 0.1
+	// DefaultNegativeWeight and DefaultPositiveWeight are the confidences a
+	// disliked or liked action's acceptance profile is written with.
+	DefaultNegativeWeight = 0.7
+	DefaultPositiveWeight = 0.6
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Service manages the learned memory of a single user; see ForUser.
 type Service struct {
-	db     *sql.DB
-	logger *slog.Logger
+	db           *sql.DB
+	userID       string
+	logger       *slog.Logger
+	timeBuckets  []TimeBucket
+	halfLifeDays float64
 }
 
 func NewService(db *sql.DB, logger *slog.Logger) *Service {
 	return &Service{
-		db:     db,
-		logger: logger,
+		db:           db,
+		userID:       models.DefaultUserID,
+		logger:       logger,
+		timeBuckets:  DefaultTimeBuckets(),
+		halfLifeDays: defaultHalfLifeDays,
+	}
+}
+
+// ForUser returns a Service over the same database scoped to userID's
+// profiles, memory events and settings.
+func (s *Service) ForUser(userID string) *Service {
+	if userID == "" {
+		userID = models.DefaultUserID
+	}
+	scoped := *s
+	scoped.userID = userID
+	return &scoped
+}
+
+// TimeBucket is an hour-of-day range [StartHour, EndHour) whose intervention
+// tolerance is learned under ProfileKey. Ranges may wrap past midnight.
+type TimeBucket struct {
+	ProfileKey string
+	StartHour  int
+	EndHour    int
+}
+
+func (b TimeBucket) Contains(hour int) bool {
+	if b.StartHour <= b.EndHour {
+		return hour >= b.StartHour && hour < b.EndHour
 	}
+	return hour >= b.StartHour || hour < b.EndHour
+}
+
+// DefaultTimeBuckets keeps the coarse night bucket and adds one bucket per hour.
+func DefaultTimeBuckets() []TimeBucket {
+	buckets := []TimeBucket{{ProfileKey: "tolerance_night_intervention", StartHour: 22, EndHour: 7}}
+	for hour := 0; hour < 24; hour++ {
+		buckets = append(buckets, TimeBucket{ProfileKey: hourToleranceKey(hour), StartHour: hour, EndHour: hour + 1})
+	}
+	return buckets
+}
+
+func hourToleranceKey(hour int) string {
+	return fmt.Sprintf("tolerance_hour_%d", hour)
 }
 
 // Profile represents a user preference or trait
 type Profile struct {
-	Key        string  `json:"key"`
-	Value      string  `json:"value"`
-	Confidence float64 `json:"confidence"`
-	UpdatedAt  int64   `json:"updated_at_ms"`
+	Key                 string  `json:"key"`
+	Value               string  `json:"value"`
+	Confidence          float64 `json:"confidence"`
+	EffectiveConfidence float64 `json:"effective_confidence"`
+	UpdatedAt           int64   `json:"updated_at_ms"`
 }
 
 type MemoryEvent struct {
@@ -41,7 +107,8 @@ type MemoryEvent struct {
 
 // GetProfileSummary returns a natural language summary of user profiles
 func (s *Service) GetProfileSummary() string {
-	rows, err := s.db.Query("SELECT key, value, confidence, updated_at_ms FROM profiles")
+	halfLifeDays := s.HalfLifeDays()
+	rows, err := s.db.Query("SELECT key, value, confidence, COALESCE(decayed_at_ms, updated_at_ms) FROM profiles WHERE user_id = ?", s.userID)
 	if err != nil {
 		s.logger.Error("failed to query profiles", slog.Any("error", err))
 		return ""
@@ -52,14 +119,18 @@ func (s *Service) GetProfileSummary() string {
 	for rows.Next() {
 		var key, value string
 		var confidence float64
-		var updatedAtMs int64
-		if err := rows.Scan(&key, &value, &confidence, &updatedAtMs); err != nil {
+		var decayedAtMs int64
+		if err := rows.Scan(&key, &value, &confidence, &decayedAtMs); err != nil {
 			continue
 		}
-		effectiveConfidence := decayConfidence(confidence, updatedAtMs)
+		effectiveConfidence := decayConfidence(confidence, decayedAtMs, halfLifeDays)
 		if effectiveConfidence < 0.5 {
 			continue
 		}
+		// Hourly tolerance is injected as a signal for the current hour only
+		if strings.HasPrefix(key, "tolerance_hour_") {
+			continue
+		}
 		// Simple formatting, can be enhanced later
 		summaries = append(summaries, fmt.Sprintf("- %s: %s", key, value))
 	}
@@ -70,23 +141,241 @@ func (s *Service) GetProfileSummary() string {
 	return strings.Join(summaries, "\n")
 }
 
-func decayConfidence(confidence float64, updatedAtMs int64) float64 {
-	if updatedAtMs <= 0 {
+// decayConfidence halves confidence every halfLifeDays since sinceMs, the
+// moment the stored confidence was last reinforced or compacted.
+func decayConfidence(confidence float64, sinceMs int64, halfLifeDays float64) float64 {
+	if sinceMs <= 0 || halfLifeDays <= 0 {
 		return confidence
 	}
-	ageMs := time.Now().UnixMilli() - updatedAtMs
+	ageMs := time.Now().UnixMilli() - sinceMs
 	if ageMs <= 0 {
 		return confidence
 	}
-	const halfLifeDays = 21.0
 	ageDays := float64(ageMs) / (24 * 60 * 60 * 1000)
 	decay := math.Pow(0.5, ageDays/halfLifeDays)
 	return confidence * decay
 }
 
-// GetRecentEvents returns recent memory events as strings
+// Location returns the user's configured timezone, falling back to the
+// server's local zone, so hour buckets match the user's wall clock.
+func (s *Service) Location() *time.Location {
+	return s.location(s.db)
+}
+
+func (s *Service) location(q querier) *time.Location {
+	raw, err := s.settingsReader(q).GetString(settings.Timezone, "")
+	if err != nil {
+		s.logger.Warn("failed to read timezone setting", slog.Any("error", err))
+	}
+	if raw == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(raw)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// HalfLifeDays returns the configured confidence half-life, falling back to the default.
+func (s *Service) HalfLifeDays() float64 {
+	days, err := s.settingsReader(s.db).GetFloat(settings.HalfLifeDays, s.halfLifeDays)
+	if err != nil {
+		s.logger.Warn("failed to read half-life setting", slog.Any("error", err))
+	}
+	if days <= 0 {
+		return s.halfLifeDays
+	}
+	return days
+}
+
+// eventOrder returns the memory_event_order setting, falling back to OrderRecent.
+func (s *Service) eventOrder() string {
+	raw, err := s.settingsReader(s.db).GetString(settings.EventOrder, OrderRecent)
+	if err != nil {
+		s.logger.Warn("failed to read event order setting", slog.Any("error", err))
+	}
+	if order := NormalizeEventOrder(raw); order != "" {
+		return order
+	}
+	return OrderRecent
+}
+
+// LearnWeights returns the confidences feedback is learned with for each
+// polarity, falling back to the defaults when unset or out of [0, 1].
+func (s *Service) LearnWeights() (negative, positive float64) {
+	return s.learnWeights(s.db)
+}
+
+func (s *Service) learnWeights(q querier) (negative, positive float64) {
+	return s.weightSetting(q, settings.NegativeWeight, DefaultNegativeWeight),
+		s.weightSetting(q, settings.PositiveWeight, DefaultPositiveWeight)
+}
+
+func (s *Service) weightSetting(q querier, key string, fallback float64) float64 {
+	weight, err := s.settingsReader(q).GetFloat(key, fallback)
+	if err != nil {
+		s.logger.Warn("failed to read learning weight setting", slog.String("key", key), slog.Any("error", err))
+	}
+	if weight > 1 {
+		return fallback
+	}
+	return weight
+}
+
+// settingsReader reads the user's settings through q, which may be the
+// transaction a write is running in.
+func (s *Service) settingsReader(q querier) settings.Reader {
+	return settings.New(settingsStore{q: q, userID: s.userID})
+}
+
+// settingsStore is a settings.Store over a querier rather than a db.Store.
+type settingsStore struct {
+	q      querier
+	userID string
+}
+
+func (st settingsStore) GetSetting(key string) (string, bool, error) {
+	var value string
+	err := st.q.QueryRow("SELECT value FROM user_settings WHERE user_id = ? AND key = ?", st.userID, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("get setting: %w", err)
+	}
+	return value, true, nil
+}
+
+// scaleWeight rescales a built-in confidence that was tuned against the
+// default weight for its polarity to the configured weight.
+func scaleWeight(base, weight, defaultWeight float64) float64 {
+	return math.Round(base*weight/defaultWeight*1000) / 1000
+}
+
+type CompactResult struct {
+	Updated int `json:"updated"`
+	Dropped int `json:"dropped"`
+}
+
+// Compact writes decayed confidences back into profiles so stored values reflect
+// their true strength, and forgets profiles that have decayed below the floor.
+func (s *Service) Compact() (CompactResult, error) {
+	var result CompactResult
+	halfLifeDays := s.HalfLifeDays()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("begin compact: %w", err)
+	}
+	rows, err := tx.Query("SELECT key, confidence, COALESCE(decayed_at_ms, updated_at_ms) FROM profiles WHERE user_id = ?", s.userID)
+	if err != nil {
+		_ = tx.Rollback()
+		return result, fmt.Errorf("query profiles: %w", err)
+	}
+	type decayed struct {
+		key        string
+		confidence float64
+	}
+	var pending []decayed
+	for rows.Next() {
+		var key string
+		var confidence float64
+		var decayedAtMs int64
+		if err := rows.Scan(&key, &confidence, &decayedAtMs); err != nil {
+			rows.Close()
+			_ = tx.Rollback()
+			return result, fmt.Errorf("scan profile: %w", err)
+		}
+		pending = append(pending, decayed{key: key, confidence: decayConfidence(confidence, decayedAtMs, halfLifeDays)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		_ = tx.Rollback()
+		return result, fmt.Errorf("profile rows: %w", err)
+	}
+
+	nowMs := time.Now().UnixMilli()
+	for _, profile := range pending {
+		if profile.confidence < profileDropFloor {
+			if _, err := tx.Exec("DELETE FROM profiles WHERE user_id = ? AND key = ?", s.userID, profile.key); err != nil {
+				_ = tx.Rollback()
+				return result, fmt.Errorf("drop profile %s: %w", profile.key, err)
+			}
+			result.Dropped++
+			continue
+		}
+		if _, err := tx.Exec(
+			"UPDATE profiles SET confidence = ?, decayed_at_ms = ? WHERE user_id = ? AND key = ?",
+			profile.confidence, nowMs, s.userID, profile.key,
+		); err != nil {
+			_ = tx.Rollback()
+			return result, fmt.Errorf("update profile %s: %w", profile.key, err)
+		}
+		result.Updated++
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit compact: %w", err)
+	}
+	return result, nil
+}
+
+// HourTolerance returns the learned intervention tolerance for hour, if confident.
+func (s *Service) HourTolerance(hour int) (string, bool) {
+	var value string
+	var confidence float64
+	var decayedAtMs int64
+	err := s.db.QueryRow(
+		"SELECT value, confidence, COALESCE(decayed_at_ms, updated_at_ms) FROM profiles WHERE user_id = ? AND key = ?",
+		s.userID, hourToleranceKey(hour),
+	).Scan(&value, &confidence, &decayedAtMs)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("failed to query hour tolerance", slog.Any("error", err))
+		}
+		return "", false
+	}
+	if decayConfidence(confidence, decayedAtMs, s.HalfLifeDays()) < 0.5 {
+		return "", false
+	}
+	return value, true
+}
+
+// Memory event orderings for GetRecentEvents and ListEvents.
+const (
+	OrderRecent     = "recent"
+	OrderImportance = "importance"
+)
+
+// importanceCandidates is how many of the latest events GetRecentEvents
+// picks the most important from, so old events do not crowd out new ones.
+const importanceCandidates = 50
+
+// NormalizeEventOrder maps an ordering onto its canonical name, returning ""
+// when the value is not recognised. Empty means OrderRecent.
+func NormalizeEventOrder(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", OrderRecent:
+		return OrderRecent
+	case OrderImportance:
+		return OrderImportance
+	default:
+		return ""
+	}
+}
+
+// GetRecentEvents returns recent memory events as strings. With the
+// memory_event_order setting at "importance" they are the most important of
+// the latest importanceCandidates events instead of the latest ones.
 func (s *Service) GetRecentEvents(limit int) string {
-	rows, err := s.db.Query("SELECT summary FROM memory_events ORDER BY created_at_ms DESC LIMIT ?", limit)
+	query := "SELECT summary FROM memory_events WHERE user_id = ? ORDER BY created_at_ms DESC LIMIT ?"
+	args := []any{s.userID, limit}
+	if s.eventOrder() == OrderImportance {
+		query = `SELECT summary FROM (
+			SELECT summary, importance, created_at_ms FROM memory_events WHERE user_id = ? ORDER BY created_at_ms DESC LIMIT ?
+		) ORDER BY importance DESC, created_at_ms DESC LIMIT ?`
+		args = []any{s.userID, importanceCandidates, limit}
+	}
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		s.logger.Error("failed to query events", slog.Any("error", err))
 		return ""
@@ -110,26 +399,143 @@ func (s *Service) GetRecentEvents(limit int) string {
 
 // AddEvent adds a new memory event
 func (s *Service) AddEvent(eventType, summary string, importance float64) error {
-	_, err := s.db.Exec(
-		"INSERT INTO memory_events (event_type, summary, created_at_ms, importance) VALUES (?, ?, ?, ?)",
-		eventType, summary, time.Now().UnixMilli(), importance,
+	return s.addEvent(s.db, eventType, summary, importance)
+}
+
+func (s *Service) addEvent(q querier, eventType, summary string, importance float64) error {
+	_, err := q.Exec(
+		"INSERT INTO memory_events (user_id, event_type, summary, created_at_ms, importance) VALUES (?, ?, ?, ?, ?)",
+		s.userID, eventType, summary, time.Now().UnixMilli(), importance,
 	)
 	return err
 }
 
-// SetProfile updates or inserts a profile
+const EventDailySummary = "daily_summary"
+
+// RecordDailySummary stores a one-line rollup of the given day as a memory event.
+func (s *Service) RecordDailySummary(summary models.DailySummary) error {
+	return s.AddEvent(EventDailySummary, formatDailySummary(summary), 0.6)
+}
+
+// GetDailySummary returns the stored rollup for date (YYYY-MM-DD), if any.
+func (s *Service) GetDailySummary(date string) (MemoryEvent, bool, error) {
+	var event MemoryEvent
+	err := s.db.QueryRow(
+		"SELECT event_type, summary, created_at_ms, importance FROM memory_events WHERE user_id = ? AND event_type = ? AND summary LIKE ? ORDER BY created_at_ms DESC LIMIT 1",
+		s.userID, EventDailySummary, dailySummaryPrefix(date)+"%",
+	).Scan(&event.EventType, &event.Summary, &event.CreatedAtMs, &event.Importance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return MemoryEvent{}, false, nil
+		}
+		return MemoryEvent{}, false, fmt.Errorf("get daily summary: %w", err)
+	}
+	return event, true, nil
+}
+
+func dailySummaryPrefix(date string) string {
+	return "Daily summary " + date + ":"
+}
+
+func formatDailySummary(summary models.DailySummary) string {
+	parts := []string{fmt.Sprintf("focus %.0f min", summary.FocusMinutes)}
+	if len(summary.TopApps) > 0 {
+		names := make([]string, 0, len(summary.TopApps))
+		for _, app := range summary.TopApps {
+			names = append(names, app.AppName)
+		}
+		parts = append(parts, "top apps "+strings.Join(names, ", "))
+	}
+	parts = append(parts, fmt.Sprintf("%d interventions", summary.Interventions))
+	if summary.RatedCount > 0 {
+		parts = append(parts, fmt.Sprintf("acceptance %.0f%%", summary.AcceptanceRate*100))
+	}
+	return dailySummaryPrefix(summary.Date) + " " + strings.Join(parts, "; ")
+}
+
+// SetProfile updates or inserts a profile. Keys recently forgotten by the user
+// are left alone until their suppression window ends.
 func (s *Service) SetProfile(key, value string, confidence float64) error {
-	_, err := s.db.Exec(
-		`INSERT INTO profiles (key, value, confidence, updated_at_ms) 
-		 VALUES (?, ?, ?, ?) 
-		 ON CONFLICT(key) DO UPDATE SET value=excluded.value, confidence=excluded.confidence, updated_at_ms=excluded.updated_at_ms`,
-		key, value, confidence, time.Now().UnixMilli(),
+	return s.setProfile(s.db, key, value, confidence)
+}
+
+func (s *Service) setProfile(q querier, key, value string, confidence float64) error {
+	suppressed, err := s.isSuppressed(q, key)
+	if err != nil {
+		return err
+	}
+	if suppressed {
+		return nil
+	}
+	_, err = q.Exec(
+		`INSERT INTO profiles (user_id, key, value, confidence, updated_at_ms) 
+		 VALUES (?, ?, ?, ?, ?) 
+		 ON CONFLICT(user_id, key) DO UPDATE SET value=excluded.value, confidence=excluded.confidence, updated_at_ms=excluded.updated_at_ms, decayed_at_ms=NULL`,
+		s.userID, key, value, confidence, time.Now().UnixMilli(),
 	)
 	return err
 }
 
+func (s *Service) isSuppressed(q querier, key string) (bool, error) {
+	var untilMs int64
+	err := q.QueryRow("SELECT until_ms FROM profile_suppressions WHERE user_id = ? AND key = ?", s.userID, key).Scan(&untilMs)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("check profile suppression: %w", err)
+	}
+	return time.Now().UnixMilli() < untilMs, nil
+}
+
+// Forget deletes a learned profile and suppresses re-learning it for suppressFor,
+// so stale feedback arriving shortly after cannot immediately restore it.
+func (s *Service) Forget(key string, suppressFor time.Duration) (Profile, bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Profile{}, false, fmt.Errorf("begin forget: %w", err)
+	}
+	var profile Profile
+	err = tx.QueryRow("SELECT key, value, confidence, updated_at_ms FROM profiles WHERE user_id = ? AND key = ?", s.userID, key).
+		Scan(&profile.Key, &profile.Value, &profile.Confidence, &profile.UpdatedAt)
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return Profile{}, false, nil
+		}
+		return Profile{}, false, fmt.Errorf("find profile: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM profiles WHERE user_id = ? AND key = ?", s.userID, key); err != nil {
+		_ = tx.Rollback()
+		return Profile{}, false, fmt.Errorf("delete profile: %w", err)
+	}
+	now := time.Now()
+	if suppressFor > 0 {
+		if _, err := tx.Exec(
+			`INSERT INTO profile_suppressions (user_id, key, until_ms) VALUES (?, ?, ?)
+			 ON CONFLICT(user_id, key) DO UPDATE SET until_ms=excluded.until_ms`,
+			s.userID, key, now.Add(suppressFor).UnixMilli(),
+		); err != nil {
+			_ = tx.Rollback()
+			return Profile{}, false, fmt.Errorf("suppress profile: %w", err)
+		}
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO memory_events (user_id, event_type, summary, created_at_ms, importance) VALUES (?, ?, ?, ?, ?)",
+		s.userID, "forget", fmt.Sprintf("User asked to forget '%s' (was '%s')", key, profile.Value), now.UnixMilli(), 0.7,
+	); err != nil {
+		_ = tx.Rollback()
+		return Profile{}, false, fmt.Errorf("insert forget event: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Profile{}, false, fmt.Errorf("commit forget: %w", err)
+	}
+	return profile, true, nil
+}
+
 func (s *Service) ListProfiles() ([]Profile, error) {
-	rows, err := s.db.Query("SELECT key, value, confidence, updated_at_ms FROM profiles ORDER BY updated_at_ms DESC")
+	halfLifeDays := s.HalfLifeDays()
+	rows, err := s.db.Query("SELECT key, value, confidence, updated_at_ms, COALESCE(decayed_at_ms, updated_at_ms) FROM profiles WHERE user_id = ? ORDER BY updated_at_ms DESC", s.userID)
 	if err != nil {
 		return nil, fmt.Errorf("list profiles: %w", err)
 	}
@@ -138,9 +544,11 @@ func (s *Service) ListProfiles() ([]Profile, error) {
 	var profiles []Profile
 	for rows.Next() {
 		var profile Profile
-		if err := rows.Scan(&profile.Key, &profile.Value, &profile.Confidence, &profile.UpdatedAt); err != nil {
+		var decayedAtMs int64
+		if err := rows.Scan(&profile.Key, &profile.Value, &profile.Confidence, &profile.UpdatedAt, &decayedAtMs); err != nil {
 			return nil, fmt.Errorf("scan profile: %w", err)
 		}
+		profile.EffectiveConfidence = decayConfidence(profile.Confidence, decayedAtMs, halfLifeDays)
 		profiles = append(profiles, profile)
 	}
 	if err := rows.Err(); err != nil {
@@ -149,13 +557,19 @@ func (s *Service) ListProfiles() ([]Profile, error) {
 	return profiles, nil
 }
 
-func (s *Service) ListEvents(limit int) ([]MemoryEvent, error) {
+// ListEvents returns up to limit memory events, newest first or, for
+// OrderImportance, most important first.
+func (s *Service) ListEvents(limit int, order string) ([]MemoryEvent, error) {
 	if limit <= 0 {
 		limit = 20
 	}
+	orderBy := "created_at_ms DESC"
+	if order == OrderImportance {
+		orderBy = "importance DESC, created_at_ms DESC"
+	}
 	rows, err := s.db.Query(
-		"SELECT event_type, summary, created_at_ms, importance FROM memory_events ORDER BY created_at_ms DESC LIMIT ?",
-		limit,
+		"SELECT event_type, summary, created_at_ms, importance FROM memory_events WHERE user_id = ? ORDER BY "+orderBy+" LIMIT ?",
+		s.userID, limit,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("list memory events: %w", err)
@@ -176,33 +590,209 @@ func (s *Service) ListEvents(limit int) ([]MemoryEvent, error) {
 	return events, nil
 }
 
+// Snapshot is the portable form of learned memory used for export/import.
+type Snapshot struct {
+	Profiles []Profile     `json:"profiles"`
+	Events   []MemoryEvent `json:"events"`
+}
+
+type ImportResult struct {
+	ProfilesImported int `json:"profiles_imported"`
+	ProfilesSkipped  int `json:"profiles_skipped"`
+	EventsImported   int `json:"events_imported"`
+	EventsSkipped    int `json:"events_skipped"`
+}
+
+func (s *Service) Export() (Snapshot, error) {
+	profiles, err := s.ListProfiles()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	rows, err := s.db.Query("SELECT event_type, summary, created_at_ms, importance FROM memory_events WHERE user_id = ? ORDER BY created_at_ms ASC, id ASC", s.userID)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("export memory events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []MemoryEvent{}
+	for rows.Next() {
+		var event MemoryEvent
+		if err := rows.Scan(&event.EventType, &event.Summary, &event.CreatedAtMs, &event.Importance); err != nil {
+			return Snapshot{}, fmt.Errorf("scan memory event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return Snapshot{}, fmt.Errorf("memory event rows: %w", err)
+	}
+	if profiles == nil {
+		profiles = []Profile{}
+	}
+	return Snapshot{Profiles: profiles, Events: events}, nil
+}
+
+// ValidateSnapshot rejects entries that cannot be imported as-is.
+func ValidateSnapshot(snapshot Snapshot) error {
+	for i, profile := range snapshot.Profiles {
+		if strings.TrimSpace(profile.Key) == "" {
+			return fmt.Errorf("profiles[%d]: key required", i)
+		}
+		if profile.Confidence < 0 || profile.Confidence > 1 {
+			return fmt.Errorf("profiles[%d]: confidence must be within [0,1]", i)
+		}
+	}
+	for i, event := range snapshot.Events {
+		if strings.TrimSpace(event.EventType) == "" || strings.TrimSpace(event.Summary) == "" {
+			return fmt.Errorf("events[%d]: event_type and summary required", i)
+		}
+		if event.Importance < 0 || event.Importance > 1 {
+			return fmt.Errorf("events[%d]: importance must be within [0,1]", i)
+		}
+	}
+	return nil
+}
+
+// Import merges a snapshot into memory. Profiles are merged by key keeping the
+// most recently updated value; events identical to existing ones are skipped.
+func (s *Service) Import(snapshot Snapshot) (ImportResult, error) {
+	var result ImportResult
+	if err := ValidateSnapshot(snapshot); err != nil {
+		return result, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("begin import: %w", err)
+	}
+	nowMs := time.Now().UnixMilli()
+	for _, profile := range snapshot.Profiles {
+		updatedAt := profile.UpdatedAt
+		if updatedAt <= 0 {
+			updatedAt = nowMs
+		}
+		res, err := tx.Exec(
+			`INSERT INTO profiles (user_id, key, value, confidence, updated_at_ms)
+			 VALUES (?, ?, ?, ?, ?)
+			 ON CONFLICT(user_id, key) DO UPDATE SET value=excluded.value, confidence=excluded.confidence, updated_at_ms=excluded.updated_at_ms, decayed_at_ms=NULL
+			 WHERE excluded.updated_at_ms > profiles.updated_at_ms`,
+			s.userID, profile.Key, profile.Value, profile.Confidence, updatedAt,
+		)
+		if err != nil {
+			_ = tx.Rollback()
+			return result, fmt.Errorf("import profile %s: %w", profile.Key, err)
+		}
+		if affected, _ := res.RowsAffected(); affected > 0 {
+			result.ProfilesImported++
+		} else {
+			result.ProfilesSkipped++
+		}
+	}
+	for _, event := range snapshot.Events {
+		createdAt := event.CreatedAtMs
+		if createdAt <= 0 {
+			createdAt = nowMs
+		}
+		res, err := tx.Exec(
+			`INSERT INTO memory_events (user_id, event_type, summary, created_at_ms, importance)
+			 SELECT ?, ?, ?, ?, ?
+			 WHERE NOT EXISTS (
+			   SELECT 1 FROM memory_events WHERE user_id = ? AND event_type = ? AND summary = ? AND created_at_ms = ?
+			 )`,
+			s.userID, event.EventType, event.Summary, createdAt, event.Importance,
+			s.userID, event.EventType, event.Summary, createdAt,
+		)
+		if err != nil {
+			_ = tx.Rollback()
+			return result, fmt.Errorf("import memory event: %w", err)
+		}
+		if affected, _ := res.RowsAffected(); affected > 0 {
+			result.EventsImported++
+		} else {
+			result.EventsSkipped++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("commit import: %w", err)
+	}
+	return result, nil
+}
+
 func (s *Service) Reset() error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("begin reset: %w", err)
 	}
-	if _, err := tx.Exec("DELETE FROM profiles"); err != nil {
+	if _, err := tx.Exec("DELETE FROM profiles WHERE user_id = ?", s.userID); err != nil {
 		_ = tx.Rollback()
 		return fmt.Errorf("clear profiles: %w", err)
 	}
-	if _, err := tx.Exec("DELETE FROM memory_events"); err != nil {
+	if _, err := tx.Exec("DELETE FROM memory_events WHERE user_id = ?", s.userID); err != nil {
 		_ = tx.Rollback()
 		return fmt.Errorf("clear memory_events: %w", err)
 	}
+	if _, err := tx.Exec("DELETE FROM profile_suppressions WHERE user_id = ?", s.userID); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("clear profile_suppressions: %w", err)
+	}
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("commit reset: %w", err)
 	}
 	return nil
 }
 
-// ProcessFeedback analyzes user feedback and updates memory
-func (s *Service) ProcessFeedback(requestID, feedback string) error {
+// profileUpdate is one profile write a feedback leads to.
+type profileUpdate struct {
+	key        string
+	value      string
+	confidence float64
+}
+
+// feedbackEffect is what a feedback does to memory: profile writes in the
+// order they are applied, and the memory event recorded for it.
+type feedbackEffect struct {
+	updates    []profileUpdate
+	eventType  string
+	summary    string
+	importance float64
+}
+
+// ProcessFeedback analyzes user feedback and updates memory. The decision
+// lookup, profile upserts and memory event are applied in one transaction, so
+// overlapping feedback cannot interleave its writes.
+func (s *Service) ProcessFeedback(requestID, feedback string, reasonCode models.FeedbackReason, rating int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin feedback: %w", err)
+	}
+	effect, err := s.feedbackEffect(tx, requestID, feedback, reasonCode, rating)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	for _, update := range effect.updates {
+		if err := s.setProfile(tx, update.key, update.value, update.confidence); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("set profile %s: %w", update.key, err)
+		}
+	}
+	if err := s.addEvent(tx, effect.eventType, effect.summary, effect.importance); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("insert feedback event: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit feedback: %w", err)
+	}
+	return nil
+}
+
+// feedbackEffect computes what ProcessFeedback would write for a feedback on
+// requestID without writing anything.
+func (s *Service) feedbackEffect(q querier, requestID, feedback string, reasonCode models.FeedbackReason, rating int) (feedbackEffect, error) {
 	// 1. Get the original action from event_logs
 	var finalActionJSON string
 	var contextJSON string
-	err := s.db.QueryRow("SELECT final_action_json, context_json FROM event_logs WHERE request_id = ?", requestID).Scan(&finalActionJSON, &contextJSON)
+	err := q.QueryRow("SELECT final_action_json, context_json FROM event_logs WHERE user_id = ? AND request_id = ?", s.userID, requestID).Scan(&finalActionJSON, &contextJSON)
 	if err != nil {
-		return fmt.Errorf("find event log: %w", err)
+		return feedbackEffect{}, fmt.Errorf("find event log: %w", err)
 	}
 
 	actionType := "UNKNOWN"
@@ -224,47 +814,228 @@ func (s *Service) ProcessFeedback(requestID, feedback string) error {
 	}
 
 	feedbackType, feedbackText := normalizeFeedback(feedback)
-	positive := feedbackType == "LIKE" || feedbackType == "ADOPTED" || feedbackType == "OPEN_PANEL"
+	// WANTED_MORE only applies to silent decisions, so it never touches
+	// action acceptance; it raises budget and time-of-day tolerance.
+	positive := feedbackType == "LIKE" || feedbackType == "ADOPTED" || feedbackType == "OPEN_PANEL" || feedbackType == "WANTED_MORE"
 	negative := feedbackType == "DISLIKE" || feedbackType == "IGNORED" || feedbackType == "CLOSED"
+	// A rating is the finer signal, so it decides the direction and how hard
+	// the coarse learning pushes; 3 is neutral and learns nothing.
+	strength := 1.0
+	if rating != 0 {
+		positive, negative = rating > 3, rating < 3
+		strength = ratingStrength(rating)
+	}
 
 	// 3. Create a memory event
-	eventType := "feedback"
+	var effect feedbackEffect
+	effect.eventType = "feedback"
 	if feedbackType == "IGNORED" || feedbackType == "CLOSED" || feedbackType == "OPEN_PANEL" {
-		eventType = "implicit_feedback"
+		effect.eventType = "implicit_feedback"
+	}
+	effect.summary = fmt.Sprintf("Feedback '%s' for action '%s'", feedbackType, actionType)
+	if reasonCode != "" {
+		effect.summary = effect.summary + fmt.Sprintf(" (reason %s)", reasonCode)
+	}
+	if rating != 0 {
+		effect.summary = effect.summary + fmt.Sprintf(" (rating %d/5)", rating)
 	}
-	summary := fmt.Sprintf("Feedback '%s' for action '%s'", feedbackType, actionType)
 	if feedbackText != "" {
-		summary = summary + ": " + feedbackText
+		effect.summary = effect.summary + ": " + feedbackText
 	}
+	effect.importance = feedbackImportance(feedbackType, reasonCode, rating)
+	set := func(key, value string, confidence float64) {
+		effect.updates = append(effect.updates, profileUpdate{key: key, value: value, confidence: confidence})
+	}
+	negativeWeight, positiveWeight := s.learnWeights(q)
+	negativeWeight, positiveWeight = negativeWeight*strength, positiveWeight*strength
+	negativeConf := func(base float64) float64 { return scaleWeight(base, negativeWeight, DefaultNegativeWeight) }
+	positiveConf := func(base float64) float64 { return scaleWeight(base, positiveWeight, DefaultPositiveWeight) }
 
 	// 4. Update profiles for acceptance and frequency
 	if actionType != "UNKNOWN" && actionType != "DO_NOT_DISTURB" {
 		if negative {
-			_ = s.SetProfile("accepts_action_"+strings.ToLower(actionType), "false", 0.7)
+			set("accepts_action_"+strings.ToLower(actionType), "false", negativeWeight)
 		} else if positive {
-			_ = s.SetProfile("accepts_action_"+strings.ToLower(actionType), "true", 0.6)
+			set("accepts_action_"+strings.ToLower(actionType), "true", positiveWeight)
 		}
 	}
 	if negative {
-		_ = s.SetProfile("preferred_intervention_budget", "low", 0.6)
+		set("preferred_intervention_budget", "low", negativeConf(0.6))
 	} else if positive {
-		_ = s.SetProfile("preferred_intervention_budget", "high", 0.5)
+		set("preferred_intervention_budget", "high", positiveConf(0.5))
 	}
 
 	// 5. Learn time-of-day tolerance if we have context timestamp
 	var ctx models.Context
+	var buckets []TimeBucket
 	if err := json.Unmarshal([]byte(contextJSON), &ctx); err == nil && ctx.Timestamp > 0 {
-		hour := time.UnixMilli(ctx.Timestamp).Hour()
-		if hour >= 22 || hour < 7 {
+		hour := time.UnixMilli(ctx.Timestamp).In(s.location(q)).Hour()
+		for _, bucket := range s.timeBuckets {
+			if !bucket.Contains(hour) {
+				continue
+			}
+			buckets = append(buckets, bucket)
 			if negative {
-				_ = s.SetProfile("tolerance_night_intervention", "low", 0.7)
+				set(bucket.ProfileKey, "low", negativeConf(0.7))
 			} else if positive {
-				_ = s.SetProfile("tolerance_night_intervention", "high", 0.5)
+				set(bucket.ProfileKey, "high", positiveConf(0.5))
 			}
 		}
 	}
 
-	return s.AddEvent(eventType, summary, 0.5)
+	// 6. Structured reasons override the coarse positive/negative learning
+	effect.updates = append(effect.updates, feedbackReasonUpdates(reasonCode, actionType, buckets)...)
+
+	return effect, nil
+}
+
+// feedbackImportance scores a feedback event for GetRecentEvents. Explicit
+// feedback outranks passive signals and negative outranks positive; a reason
+// code or an extreme rating makes it more telling still.
+func feedbackImportance(feedbackType string, reasonCode models.FeedbackReason, rating int) float64 {
+	importance := 0.5
+	switch feedbackType {
+	case "DISLIKE":
+		importance = 0.8
+	case "ADOPTED", "WANTED_MORE":
+		importance = 0.7
+	case "LIKE":
+		importance = 0.6
+	case "CLOSED":
+		importance = 0.5
+	case "IGNORED", "OPEN_PANEL":
+		importance = 0.3
+	}
+	if reasonCode != "" {
+		importance += 0.1
+	}
+	if rating == 1 || rating == 5 {
+		importance += 0.1
+	}
+	return math.Min(math.Round(importance*10)/10, 1)
+}
+
+// ratingStrength scales the learning weights for a 1-5 rating: the extremes
+// learn at full weight, 2 and 4 at half.
+func ratingStrength(rating int) float64 {
+	switch rating {
+	case 2, 4:
+		return 0.5
+	case 3:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// feedbackReasonUpdates returns the targeted profile adjustments for a reason code.
+func feedbackReasonUpdates(reasonCode models.FeedbackReason, actionType string, buckets []TimeBucket) []profileUpdate {
+	actionKnown := actionType != "UNKNOWN" && actionType != "DO_NOT_DISTURB"
+	var updates []profileUpdate
+	switch reasonCode {
+	case models.ReasonTooFrequent:
+		updates = append(updates, profileUpdate{"preferred_intervention_budget", "low", 0.9})
+	case models.ReasonWrongTime:
+		for _, bucket := range buckets {
+			updates = append(updates, profileUpdate{bucket.ProfileKey, "low", 0.8})
+		}
+	case models.ReasonNotRelevant:
+		if actionKnown {
+			updates = append(updates, profileUpdate{"accepts_action_" + strings.ToLower(actionType), "false", 0.8})
+		}
+	case models.ReasonHelpful:
+		if actionKnown {
+			updates = append(updates, profileUpdate{"accepts_action_" + strings.ToLower(actionType), "true", 0.8})
+		}
+	}
+	return updates
+}
+
+// ProfileDelta is the change a feedback would make to one profile. Old* are
+// empty when the profile does not exist yet; a suppressed key keeps its old
+// state because SetProfile skips it.
+type ProfileDelta struct {
+	Key                    string  `json:"key"`
+	OldValue               string  `json:"old_value,omitempty"`
+	OldConfidence          float64 `json:"old_confidence,omitempty"`
+	OldEffectiveConfidence float64 `json:"old_effective_confidence,omitempty"`
+	NewValue               string  `json:"new_value"`
+	NewConfidence          float64 `json:"new_confidence"`
+	Created                bool    `json:"created"`
+	Changed                bool    `json:"changed"`
+	Suppressed             bool    `json:"suppressed,omitempty"`
+}
+
+// FeedbackPreview is the dry-run result of PreviewFeedback.
+type FeedbackPreview struct {
+	RequestID  string         `json:"request_id"`
+	Profiles   []ProfileDelta `json:"profiles"`
+	EventType  string         `json:"event_type"`
+	Summary    string         `json:"summary"`
+	Importance float64        `json:"importance"`
+}
+
+// PreviewFeedback reports what ProcessFeedback would do for the same
+// arguments without writing anything. A key written several times is reported
+// once with the value that ends up stored.
+func (s *Service) PreviewFeedback(requestID, feedback string, reasonCode models.FeedbackReason, rating int) (FeedbackPreview, error) {
+	effect, err := s.feedbackEffect(s.db, requestID, feedback, reasonCode, rating)
+	if err != nil {
+		return FeedbackPreview{}, err
+	}
+	profiles, err := s.ListProfiles()
+	if err != nil {
+		return FeedbackPreview{}, err
+	}
+	current := make(map[string]Profile, len(profiles))
+	for _, profile := range profiles {
+		current[profile.Key] = profile
+	}
+
+	preview := FeedbackPreview{
+		RequestID:  requestID,
+		Profiles:   []ProfileDelta{},
+		EventType:  effect.eventType,
+		Summary:    effect.summary,
+		Importance: effect.importance,
+	}
+	index := map[string]int{}
+	for _, update := range effect.updates {
+		i, seen := index[update.key]
+		if !seen {
+			old, exists := current[update.key]
+			suppressed, err := s.isSuppressed(s.db, update.key)
+			if err != nil {
+				return FeedbackPreview{}, err
+			}
+			preview.Profiles = append(preview.Profiles, ProfileDelta{
+				Key:                    update.key,
+				OldValue:               old.Value,
+				OldConfidence:          old.Confidence,
+				OldEffectiveConfidence: old.EffectiveConfidence,
+				Created:                !exists,
+				Suppressed:             suppressed,
+			})
+			i = len(preview.Profiles) - 1
+			index[update.key] = i
+		}
+		delta := &preview.Profiles[i]
+		if delta.Suppressed {
+			continue
+		}
+		delta.NewValue = update.value
+		delta.NewConfidence = update.confidence
+	}
+	for i := range preview.Profiles {
+		delta := &preview.Profiles[i]
+		if delta.Suppressed {
+			delta.NewValue, delta.NewConfidence = delta.OldValue, delta.OldConfidence
+			delta.Created = false
+			continue
+		}
+		delta.Changed = delta.Created || delta.NewValue != delta.OldValue || delta.NewConfidence != delta.OldConfidence
+	}
+	return preview, nil
 }
 
 func normalizeFeedback(raw string) (string, string) {
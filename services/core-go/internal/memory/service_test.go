@@ -0,0 +1,364 @@
+package memory
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"always/core/internal/db"
+	"always/core/internal/models"
+	"always/core/internal/settings"
+)
+
+func newTestService(t *testing.T) (*Service, *db.Store) {
+	t.Helper()
+	store, err := db.Open(filepath.Join(t.TempDir(), "always.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.DB().Close() })
+	return NewService(store.DB(), slog.New(slog.DiscardHandler)), store
+}
+
+// logDecision stores a decision with actionType for feedback to refer to.
+func logDecision(t *testing.T, store *db.Store, requestID string, actionType models.ActionType, at time.Time) {
+	t.Helper()
+	action := models.Action{ActionType: actionType, Message: "m", Confidence: 0.9, RiskLevel: models.RiskLow}
+	err := store.InsertDecision(models.DecisionLogEntry{
+		RequestID:   requestID,
+		Context:     models.Context{Mode: models.ModeActive, Timestamp: at.UnixMilli()},
+		RawAction:   action,
+		FinalAction: action,
+	})
+	if err != nil {
+		t.Fatalf("insert decision %s: %v", requestID, err)
+	}
+}
+
+func profileMap(t *testing.T, s *Service) map[string]Profile {
+	t.Helper()
+	profiles, err := s.ListProfiles()
+	if err != nil {
+		t.Fatalf("list profiles: %v", err)
+	}
+	byKey := map[string]Profile{}
+	for _, profile := range profiles {
+		byKey[profile.Key] = profile
+	}
+	return byKey
+}
+
+func TestProcessFeedbackConcurrent(t *testing.T) {
+	s, store := newTestService(t)
+	const n = 24
+	at := time.Now()
+	for i := 0; i < n; i++ {
+		logDecision(t, store, fmt.Sprintf("req-%d", i), models.ActionEncourage, at)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n+2)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			feedback := models.FeedbackLike
+			if i%2 == 1 {
+				feedback = models.FeedbackDislike
+			}
+			errs <- s.ProcessFeedback(fmt.Sprintf("req-%d", i), string(feedback), "", 0)
+		}()
+	}
+	// Other write transactions run alongside the feedback.
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := s.Compact()
+		errs <- err
+	}()
+	go func() {
+		defer wg.Done()
+		_, _, err := s.Forget("preferred_intervention_budget", 0)
+		errs <- err
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent write failed: %v", err)
+		}
+	}
+
+	events, err := s.ListEvents(100, OrderRecent)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	feedbackEvents := 0
+	for _, event := range events {
+		if event.EventType == "feedback" {
+			feedbackEvents++
+		}
+	}
+	if feedbackEvents != n {
+		t.Errorf("feedback events = %d, want %d", feedbackEvents, n)
+	}
+	profile, ok := profileMap(t, s)["accepts_action_encourage"]
+	if !ok {
+		t.Fatal("accepts_action_encourage not learned")
+	}
+	if profile.Confidence != DefaultNegativeWeight && profile.Confidence != DefaultPositiveWeight {
+		t.Errorf("accepts_action_encourage confidence = %v, want one feedback's weight", profile.Confidence)
+	}
+}
+
+func TestSettingsFallBackToDefaults(t *testing.T) {
+	s, store := newTestService(t)
+	negative, positive := s.LearnWeights()
+	if negative != DefaultNegativeWeight || positive != DefaultPositiveWeight {
+		t.Errorf("unset LearnWeights = %v, %v; want defaults", negative, positive)
+	}
+	if got := s.HalfLifeDays(); got != defaultHalfLifeDays {
+		t.Errorf("unset HalfLifeDays = %v, want %v", got, defaultHalfLifeDays)
+	}
+
+	for key, value := range map[string]string{
+		settings.NegativeWeight: "1.5",
+		settings.PositiveWeight: "-0.2",
+		settings.HalfLifeDays:   "0",
+		settings.Timezone:       "Mars/Olympus_Mons",
+	} {
+		if err := store.UpsertSetting(key, value); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	negative, positive = s.LearnWeights()
+	if negative != DefaultNegativeWeight || positive != DefaultPositiveWeight {
+		t.Errorf("out-of-range LearnWeights = %v, %v; want defaults", negative, positive)
+	}
+	if got := s.HalfLifeDays(); got != defaultHalfLifeDays {
+		t.Errorf("zero HalfLifeDays = %v, want %v", got, defaultHalfLifeDays)
+	}
+	if got := s.Location(); got != time.Local {
+		t.Errorf("unknown timezone Location = %v, want Local", got)
+	}
+}
+
+func TestFeedbackLearnsWithConfiguredWeight(t *testing.T) {
+	s, store := newTestService(t)
+	if err := store.UpsertSetting(settings.PositiveWeight, "0.3"); err != nil {
+		t.Fatalf("set weight: %v", err)
+	}
+	if err := store.UpsertSetting(settings.Timezone, "Asia/Shanghai"); err != nil {
+		t.Fatalf("set timezone: %v", err)
+	}
+	logDecision(t, store, "req-1", models.ActionEncourage, time.Now())
+	if err := s.ProcessFeedback("req-1", string(models.FeedbackLike), "", 0); err != nil {
+		t.Fatalf("feedback: %v", err)
+	}
+	profile, ok := profileMap(t, s)["accepts_action_encourage"]
+	if !ok {
+		t.Fatal("accepts_action_encourage not learned")
+	}
+	if profile.Confidence != 0.3 {
+		t.Errorf("confidence = %v, want the configured 0.3", profile.Confidence)
+	}
+	if got := s.Location().String(); got != "Asia/Shanghai" {
+		t.Errorf("Location = %s, want Asia/Shanghai", got)
+	}
+}
+
+func TestCompactWritesBackDecay(t *testing.T) {
+	s, store := newTestService(t)
+	day := 24 * time.Hour
+	now := time.Now()
+	for _, p := range []struct {
+		userID     string
+		key        string
+		confidence float64
+		age        time.Duration
+	}{
+		{models.DefaultUserID, "fresh", 0.8, 0},
+		{models.DefaultUserID, "one_half_life", 0.8, 21 * day},
+		{models.DefaultUserID, "faded", 0.3, 42 * day},
+		{"alice", "one_half_life", 0.8, 21 * day},
+	} {
+		_, err := store.DB().Exec(
+			"INSERT INTO profiles (user_id, key, value, confidence, updated_at_ms) VALUES (?, ?, 'v', ?, ?)",
+			p.userID, p.key, p.confidence, now.Add(-p.age).UnixMilli(),
+		)
+		if err != nil {
+			t.Fatalf("insert %s/%s: %v", p.userID, p.key, err)
+		}
+	}
+
+	result, err := s.Compact()
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if result.Updated != 2 || result.Dropped != 1 {
+		t.Errorf("Compact = %+v, want 2 updated and 1 dropped", result)
+	}
+	assertConfidences := func(s *Service, want map[string]float64) {
+		t.Helper()
+		profiles := profileMap(t, s)
+		if len(profiles) != len(want) {
+			t.Errorf("profiles = %v, want keys of %v", profiles, want)
+		}
+		for key, confidence := range want {
+			if got := profiles[key].Confidence; math.Abs(got-confidence) > 0.001 {
+				t.Errorf("%s stored confidence = %v, want %v", key, got, confidence)
+			}
+		}
+	}
+	assertConfidences(s, map[string]float64{"fresh": 0.8, "one_half_life": 0.4})
+	assertConfidences(s.ForUser("alice"), map[string]float64{"one_half_life": 0.8})
+
+	// Decay is measured from the last write-back, so compacting again right
+	// away leaves the stored values as they are.
+	if _, err := s.Compact(); err != nil {
+		t.Fatalf("second compact: %v", err)
+	}
+	assertConfidences(s, map[string]float64{"fresh": 0.8, "one_half_life": 0.4})
+}
+
+func TestFeedbackUsesConfiguredWeights(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   map[string]string
+		feedback   models.FeedbackType
+		wantAccept float64
+		wantBudget float64
+	}{
+		{"default dislike", nil, models.FeedbackDislike, DefaultNegativeWeight, 0.6},
+		{"default like", nil, models.FeedbackLike, DefaultPositiveWeight, 0.5},
+		{"configured dislike", map[string]string{settings.NegativeWeight: "0.35"}, models.FeedbackDislike, 0.35, 0.3},
+		{"configured like", map[string]string{settings.PositiveWeight: "0.3"}, models.FeedbackLike, 0.3, 0.25},
+		{"like ignores the negative weight", map[string]string{settings.NegativeWeight: "0.1"}, models.FeedbackLike, DefaultPositiveWeight, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestService(t)
+			for key, value := range tt.settings {
+				if err := store.UpsertSetting(key, value); err != nil {
+					t.Fatalf("set %s: %v", key, err)
+				}
+			}
+			logDecision(t, store, "req-1", models.ActionEncourage, time.Now())
+			if err := s.ProcessFeedback("req-1", string(tt.feedback), "", 0); err != nil {
+				t.Fatalf("feedback: %v", err)
+			}
+			profiles := profileMap(t, s)
+			if got := profiles["accepts_action_encourage"].Confidence; got != tt.wantAccept {
+				t.Errorf("accepts_action_encourage confidence = %v, want %v", got, tt.wantAccept)
+			}
+			if got := profiles["preferred_intervention_budget"].Confidence; got != tt.wantBudget {
+				t.Errorf("preferred_intervention_budget confidence = %v, want %v", got, tt.wantBudget)
+			}
+		})
+	}
+}
+
+func TestFeedbackImportance(t *testing.T) {
+	tests := []struct {
+		feedback models.FeedbackType
+		reason   models.FeedbackReason
+		rating   int
+		want     float64
+	}{
+		{models.FeedbackDislike, "", 0, 0.8},
+		{models.FeedbackAdopted, "", 0, 0.7},
+		{models.FeedbackLike, "", 0, 0.6},
+		{models.FeedbackClosed, "", 0, 0.5},
+		{models.FeedbackIgnored, "", 0, 0.3},
+		{models.FeedbackDislike, models.ReasonTooFrequent, 0, 0.9},
+		{models.FeedbackDislike, models.ReasonTooFrequent, 1, 1},
+		{models.FeedbackLike, "", 4, 0.6},
+		{models.FeedbackLike, "", 5, 0.7},
+	}
+	for _, tt := range tests {
+		if got := feedbackImportance(string(tt.feedback), tt.reason, tt.rating); got != tt.want {
+			t.Errorf("feedbackImportance(%s, %q, %d) = %v, want %v", tt.feedback, tt.reason, tt.rating, got, tt.want)
+		}
+	}
+}
+
+func TestEventOrdering(t *testing.T) {
+	s, store := newTestService(t)
+	base := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC).UnixMilli()
+	events := []struct {
+		summary    string
+		importance float64
+	}{
+		{"oldest, disliked", 0.8},
+		{"ignored", 0.3},
+		{"liked", 0.6},
+		{"newest, ignored", 0.3},
+	}
+	for i, event := range events {
+		_, err := s.db.Exec(
+			"INSERT INTO memory_events (user_id, event_type, summary, created_at_ms, importance) VALUES (?, ?, ?, ?, ?)",
+			s.userID, "feedback", event.summary, base+int64(i)*60_000, event.importance,
+		)
+		if err != nil {
+			t.Fatalf("insert event: %v", err)
+		}
+	}
+
+	summaries := func(order string) []string {
+		t.Helper()
+		listed, err := s.ListEvents(3, order)
+		if err != nil {
+			t.Fatalf("list events: %v", err)
+		}
+		var got []string
+		for _, event := range listed {
+			got = append(got, event.Summary)
+		}
+		return got
+	}
+	if got, want := summaries(OrderRecent), []string{"newest, ignored", "liked", "ignored"}; !slices.Equal(got, want) {
+		t.Errorf("ListEvents by recency = %q, want %q", got, want)
+	}
+	if got, want := summaries(OrderImportance), []string{"oldest, disliked", "liked", "newest, ignored"}; !slices.Equal(got, want) {
+		t.Errorf("ListEvents by importance = %q, want %q", got, want)
+	}
+
+	if got, want := s.GetRecentEvents(2), "- newest, ignored\n- liked"; got != want {
+		t.Errorf("GetRecentEvents by default = %q, want %q", got, want)
+	}
+	if err := store.UpsertSetting(settings.EventOrder, OrderImportance); err != nil {
+		t.Fatalf("set event order: %v", err)
+	}
+	if got, want := s.GetRecentEvents(2), "- oldest, disliked\n- liked"; got != want {
+		t.Errorf("GetRecentEvents by importance = %q, want %q", got, want)
+	}
+}
+
+func TestFeedbackEventsRecordImportance(t *testing.T) {
+	s, store := newTestService(t)
+	at := time.Now()
+	logDecision(t, store, "req-dislike", models.ActionEncourage, at)
+	logDecision(t, store, "req-ignore", models.ActionEncourage, at)
+	if err := s.ProcessFeedback("req-dislike", string(models.FeedbackDislike), "", 0); err != nil {
+		t.Fatalf("dislike: %v", err)
+	}
+	if err := s.ProcessFeedback("req-ignore", string(models.FeedbackIgnored), "", 0); err != nil {
+		t.Fatalf("ignore: %v", err)
+	}
+
+	events, err := s.ListEvents(10, OrderImportance)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 || events[0].Importance <= events[1].Importance {
+		t.Fatalf("events = %+v, want the dislike ranked above the ignore", events)
+	}
+	if events[0].Importance != 0.8 || events[1].Importance != 0.3 {
+		t.Errorf("importances = %v, %v, want 0.8, 0.3", events[0].Importance, events[1].Importance)
+	}
+}
@@ -1,6 +1,13 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// DefaultUserID owns rows written without an explicit X-User-ID, including
+// everything stored before per-user namespaces existed.
+const DefaultUserID = "default"
 
 type Mode string
 
@@ -19,6 +26,17 @@ const (
 	FeedbackIgnored FeedbackType = "IGNORED"
 	FeedbackClosed  FeedbackType = "CLOSED"
 	FeedbackOpen    FeedbackType = "OPEN_PANEL"
+	// FeedbackWantedMore says a silent decision should have been a nudge.
+	FeedbackWantedMore FeedbackType = "WANTED_MORE"
+)
+
+type FeedbackReason string
+
+const (
+	ReasonTooFrequent FeedbackReason = "too_frequent"
+	ReasonWrongTime   FeedbackReason = "wrong_time"
+	ReasonNotRelevant FeedbackReason = "not_relevant"
+	ReasonHelpful     FeedbackReason = "helpful"
 )
 
 type RiskLevel string
@@ -67,6 +85,9 @@ type Action struct {
 	RiskLevel  RiskLevel  `json:"risk_level"`
 	Reason     string     `json:"reason,omitempty"`
 	State      string     `json:"state,omitempty"`
+	// Urgent asks the gateway to let the action through an active cooldown;
+	// see urgent_cooldown_bypass.
+	Urgent bool `json:"urgent,omitempty"`
 }
 
 type DecisionRequest struct {
@@ -78,25 +99,105 @@ type GatewayDecision struct {
 	Decision             GatewayDecisionType `json:"decision"`
 	Reason               string              `json:"reason"`
 	OverriddenActionType ActionType          `json:"overridden_action_type,omitempty"`
+	RiskPolicy           string              `json:"risk_policy,omitempty"`
+	CostApplied          float64             `json:"cost_applied,omitempty"`
 }
 
 type DecisionResponse struct {
-	RequestID       string          `json:"request_id"`
-	Context         Context         `json:"context"`
-	Action          Action          `json:"action"`
-	PolicyVersion   string          `json:"policy_version"`
-	ModelVersion    string          `json:"model_version"`
-	LatencyMs       int64           `json:"latency_ms"`
-	CreatedAt       time.Time       `json:"created_at,omitempty"`
-	CreatedAtMs     int64           `json:"created_at_ms"`
-	GatewayDecision GatewayDecision `json:"gateway_decision"`
+	RequestID        string           `json:"request_id"`
+	Context          Context          `json:"context"`
+	Action           Action           `json:"action"`
+	PolicyVersion    string           `json:"policy_version"`
+	ModelVersion     string           `json:"model_version"`
+	LatencyMs        int64            `json:"latency_ms"`
+	LatencyBreakdown LatencyBreakdown `json:"latency_breakdown"`
+	CreatedAt        time.Time        `json:"created_at,omitempty"`
+	CreatedAtMs      int64            `json:"created_at_ms"`
+	GatewayDecision  GatewayDecision  `json:"gateway_decision"`
+	// AutoSuggestionsRemaining is set on auto-suggestions while
+	// max_auto_suggestions_per_day is configured.
+	AutoSuggestionsRemaining *int `json:"auto_suggestions_remaining,omitempty"`
+	// AutoDiagnostic explains why an auto request was held back.
+	AutoDiagnostic *AutoDiagnostic `json:"auto_diagnostic,omitempty"`
+}
+
+// DecisionDebugResponse is what POST /v1/decision/debug returns: the context
+// as enriched for the model and, when the backend renders it in-process, the
+// prompt built from it.
+type DecisionDebugResponse struct {
+	Context         Context `json:"context"`
+	Prompt          string  `json:"prompt,omitempty"`
+	PromptAvailable bool    `json:"prompt_available"`
+}
+
+// GateCheck is the outcome of one gate an auto-suggestion must pass.
+type GateCheck struct {
+	Gate    string         `json:"gate"`
+	Passed  bool           `json:"passed"`
+	Reason  string         `json:"reason,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// AutoDiagnostic lists every gate checked for an auto request; Reason is
+// the first gate that failed.
+type AutoDiagnostic struct {
+	Allowed bool        `json:"allowed"`
+	Reason  string      `json:"reason"`
+	Gates   []GateCheck `json:"gates"`
+}
+
+// SelfTestCheck is the outcome of one component of the self-test.
+type SelfTestCheck struct {
+	Component  string         `json:"component"`
+	Passed     bool           `json:"passed"`
+	DurationMs int64          `json:"duration_ms"`
+	Error      string         `json:"error,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+// SelfTestReport is the result of running the decision pipeline against a
+// canned context; Passed is set only when every component passed.
+type SelfTestReport struct {
+	Passed     bool            `json:"passed"`
+	DurationMs int64           `json:"duration_ms"`
+	Checks     []SelfTestCheck `json:"checks"`
+}
+
+type LatencyBreakdown struct {
+	EnrichMs  int64 `json:"enrich_ms"`
+	AIMs      int64 `json:"ai_ms"`
+	GatewayMs int64 `json:"gateway_ms"`
+	PersistMs int64 `json:"persist_ms"`
+	TotalMs   int64 `json:"total_ms"`
 }
 
 type FeedbackRequest struct {
-	RequestID    string       `json:"request_id"`
-	Feedback     FeedbackType `json:"feedback"`
-	FeedbackText string       `json:"feedback_text,omitempty"`
-	Context      Context      `json:"context,omitempty"` // Context for generating reply
+	RequestID    string         `json:"request_id"`
+	Feedback     FeedbackType   `json:"feedback"`
+	FeedbackText string         `json:"feedback_text,omitempty"`
+	ReasonCode   FeedbackReason `json:"reason_code,omitempty"`
+	// Rating optionally grades the feedback from 1 (strongly negative) to 5
+	// (strongly positive); 0 means no rating.
+	Rating  int     `json:"rating,omitempty"`
+	Context Context `json:"context,omitempty"` // Context for generating reply
+}
+
+type TagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// AckRequest reports when the user dismissed a suggestion. A zero
+// DismissedAtMs means now.
+type AckRequest struct {
+	DismissedAtMs int64 `json:"dismissed_at_ms"`
+}
+
+// DecisionAck is a recorded dismissal. LatencyMs is the time from the
+// decision's created_at_ms to the dismissal.
+type DecisionAck struct {
+	RequestID     string `json:"request_id"`
+	DismissedAtMs int64  `json:"dismissed_at_ms"`
+	LatencyMs     int64  `json:"latency_ms"`
 }
 
 type DecisionLogEntry struct {
@@ -129,6 +230,28 @@ type EventLog struct {
 	ActionJSON      string          `json:"action_json,omitempty"`
 }
 
+// FeedbackEntry is one row of feedback_logs for a decision.
+type FeedbackEntry struct {
+	Feedback    string `json:"feedback"`
+	Rating      int    `json:"rating,omitempty"`
+	CreatedAtMs int64  `json:"created_at_ms"`
+}
+
+// ImplicitFeedbackEvent is one implicit feedback signal recorded for a decision.
+type ImplicitFeedbackEvent struct {
+	FeedbackType string `json:"feedback_type"`
+	FeedbackText string `json:"feedback_text,omitempty"`
+	ReasonCode   string `json:"reason_code,omitempty"`
+	CreatedAtMs  int64  `json:"created_at_ms"`
+}
+
+// EnrichedLog is a decision together with all feedback recorded against it.
+type EnrichedLog struct {
+	EventLog
+	Feedback       []FeedbackEntry         `json:"feedback"`
+	ImplicitEvents []ImplicitFeedbackEvent `json:"implicit_events"`
+}
+
 type ExportRecord struct {
 	RequestID       string          `json:"request_id"`
 	Context         Context         `json:"context"`
@@ -153,6 +276,11 @@ type SettingRequest struct {
 	Value string `json:"value"`
 }
 
+type ForgetRequest struct {
+	Key             string `json:"key"`
+	SuppressMinutes *int   `json:"suppress_minutes,omitempty"`
+}
+
 type BudgetUsage struct {
 	DailyUsed  float64 `json:"daily_used"`
 	DailyDay   string  `json:"daily_day"`
@@ -160,6 +288,16 @@ type BudgetUsage struct {
 	HourlyHour string  `json:"hourly_hour"`
 }
 
+// BudgetSnapshot is the gateway's per-mode budget at TsMs, after recovery,
+// alongside the hourly and daily cap usage.
+type BudgetSnapshot struct {
+	TsMs       int64            `json:"ts_ms"`
+	Budgets    map[Mode]float64 `json:"budgets"`
+	MaxBudgets map[Mode]float64 `json:"max_budgets"`
+	HourlyUsed float64          `json:"hourly_used"`
+	DailyUsed  float64          `json:"daily_used"`
+}
+
 type FocusStateSnapshot struct {
 	TsMs         int64   `json:"ts_ms"`
 	FocusState   string  `json:"focus_state"`
@@ -184,6 +322,33 @@ type FocusEvent struct {
 	PID         int    `json:"pid,omitempty"`
 	DurationMs  int64  `json:"duration_ms"`
 	WindowTitle string `json:"window_title,omitempty"`
+	// SessionSeq numbers the event among the day's focus sessions, from 1 at
+	// local midnight. It is only set where the listing derives it.
+	SessionSeq int `json:"session_seq,omitempty"`
+}
+
+type FocusEventRequest struct {
+	TsMs        int64  `json:"ts_ms"`
+	AppName     string `json:"app_name"`
+	BundleID    string `json:"bundle_id"`
+	PID         int    `json:"pid"`
+	WindowTitle string `json:"window_title"`
+	// LastInputMs is when the client last saw keyboard or mouse input.
+	LastInputMs int64 `json:"last_input_ms,omitempty"`
+}
+
+// FocusEventBatchRequest carries snapshots a tracker buffered while offline,
+// oldest first.
+type FocusEventBatchRequest struct {
+	Events []FocusEventRequest `json:"events"`
+}
+
+type FocusEventFilter struct {
+	AppName  string
+	BundleID string
+	SinceMs  int64
+	UntilMs  int64
+	Limit    int
 }
 
 type FocusCurrent struct {
@@ -193,4 +358,162 @@ type FocusCurrent struct {
 	PID          int     `json:"pid,omitempty"`
 	WindowTitle  string  `json:"window_title,omitempty"`
 	FocusMinutes float64 `json:"focus_minutes"`
+	FocusScore   int     `json:"focus_score"`
+}
+
+// FocusPurgeResult counts the focus rows removed by a privacy purge.
+type FocusPurgeResult struct {
+	BeforeMs         int64 `json:"before_ms,omitempty"`
+	EventsDeleted    int64 `json:"events_deleted"`
+	SnapshotsDeleted int64 `json:"snapshots_deleted"`
+}
+
+// FocusStatus reports how the focus monitor is collecting data right now.
+type FocusStatus struct {
+	Enabled                 bool    `json:"enabled"`
+	Polling                 bool    `json:"polling"`
+	IngestEnabled           bool    `json:"ingest_enabled"`
+	PollIntervalMs          int64   `json:"poll_interval_ms"`
+	EffectivePollIntervalMs int64   `json:"effective_poll_interval_ms"`
+	OnBattery               bool    `json:"on_battery"`
+	BatteryBackoff          bool    `json:"battery_backoff_enabled"`
+	BatteryMultiplier       float64 `json:"battery_poll_multiplier"`
+	RedactWindowTitles      bool    `json:"redact_window_titles"`
+}
+
+type FocusStateReading struct {
+	State            string        `json:"state"`
+	Source           string        `json:"source"`
+	FocusScore       int           `json:"focus_score"`
+	SwitchCount      int           `json:"switch_count"`
+	TitleSwitchCount int           `json:"title_switch_count"`
+	NoProgress       bool          `json:"no_progress"`
+	NoProgressMs     int64         `json:"no_progress_ms"`
+	FocusMinutes     float64       `json:"focus_minutes"`
+	Current          *FocusCurrent `json:"current,omitempty"`
+}
+
+type AppUsage struct {
+	AppName      string  `json:"app_name"`
+	FocusMinutes float64 `json:"focus_minutes"`
+}
+
+type DailySummary struct {
+	Date                string         `json:"date"`
+	FocusMinutes        float64        `json:"focus_minutes"`
+	TopApps             []AppUsage     `json:"top_apps"`
+	InterventionsByType map[string]int `json:"interventions_by_type"`
+	Interventions       int            `json:"interventions"`
+	RatedCount          int            `json:"rated_count"`
+	AcceptedCount       int            `json:"accepted_count"`
+	AcceptanceRate      float64        `json:"acceptance_rate"`
+	FocusTransitions    map[string]int `json:"focus_transitions"`
+}
+
+// DismissStats summarises how quickly suggestions of one action type were
+// dismissed.
+type DismissStats struct {
+	ActionType   ActionType `json:"action_type"`
+	Count        int        `json:"count"`
+	AvgLatencyMs float64    `json:"avg_latency_ms"`
+	MinLatencyMs int64      `json:"min_latency_ms"`
+	MaxLatencyMs int64      `json:"max_latency_ms"`
+}
+
+// VariantStats summarises the decisions made under one A/B policy variant.
+// Variant is empty for decisions that ran without one.
+type VariantStats struct {
+	Variant        string  `json:"variant"`
+	Decisions      int     `json:"decisions"`
+	Interventions  int     `json:"interventions"`
+	RatedCount     int     `json:"rated_count"`
+	AcceptedCount  int     `json:"accepted_count"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+}
+
+// policyVariantSeparator joins a policy version and the A/B variant it ran
+// under in the stored policy_version, e.g. "policy_v0@b".
+const policyVariantSeparator = "@"
+
+// WithPolicyVariant tags policyVersion with variant; an empty variant leaves
+// it unchanged.
+func WithPolicyVariant(policyVersion, variant string) string {
+	if variant == "" {
+		return policyVersion
+	}
+	return policyVersion + policyVariantSeparator + variant
+}
+
+// PolicyVariantOf returns the variant a stored policy version was tagged
+// with, or "" when it has none.
+func PolicyVariantOf(policyVersion string) string {
+	_, variant, _ := strings.Cut(policyVersion, policyVariantSeparator)
+	return variant
+}
+
+type FocusAnalytics struct {
+	SinceMs               int64        `json:"since_ms"`
+	UntilMs               int64        `json:"until_ms"`
+	FocusedMinutes        float64      `json:"focused_minutes"`
+	DistractedMinutes     float64      `json:"distracted_minutes"`
+	NoProgressMinutes     float64      `json:"no_progress_minutes"`
+	SessionCount          int          `json:"session_count"`
+	AvgSessionMinutes     float64      `json:"avg_session_minutes"`
+	LongestSessionMinutes float64      `json:"longest_session_minutes"`
+	TopApps               []AppUsage   `json:"top_apps"`
+	Sessions              []FocusEvent `json:"sessions"`
+}
+
+type WeekStats struct {
+	Week           string  `json:"week"`
+	SinceMs        int64   `json:"since_ms"`
+	UntilMs        int64   `json:"until_ms"`
+	FocusMinutes   float64 `json:"focus_minutes"`
+	Interventions  int     `json:"interventions"`
+	RatedCount     int     `json:"rated_count"`
+	AcceptedCount  int     `json:"accepted_count"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+	FocusedRatio   float64 `json:"focused_ratio"`
+}
+
+// Bucket intervals accepted by time-bucketed stats.
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// FeedbackTally counts feedback events and how many of them were positive.
+type FeedbackTally struct {
+	Total    int `json:"total"`
+	Accepted int `json:"accepted"`
+}
+
+type FeedbackBucket struct {
+	Start                 string                   `json:"start"`
+	SinceMs               int64                    `json:"since_ms"`
+	UntilMs               int64                    `json:"until_ms"`
+	Total                 int                      `json:"total"`
+	Counts                map[string]int           `json:"counts"`
+	ByAction              map[string]FeedbackTally `json:"by_action"`
+	AcceptanceRate        float64                  `json:"acceptance_rate"`
+	RollingAcceptanceRate float64                  `json:"rolling_acceptance_rate"`
+}
+
+type FeedbackStats struct {
+	Interval      string           `json:"interval"`
+	SinceMs       int64            `json:"since_ms"`
+	UntilMs       int64            `json:"until_ms"`
+	RollingWindow int              `json:"rolling_window"`
+	Buckets       []FeedbackBucket `json:"buckets"`
+}
+
+type WeeklyTrend struct {
+	Current                 WeekStats `json:"current"`
+	Previous                WeekStats `json:"previous"`
+	Partial                 bool      `json:"partial"`
+	ElapsedDays             float64   `json:"elapsed_days"`
+	FocusMinutesChangePct   *float64  `json:"focus_minutes_change_pct"`
+	InterventionsChangePct  *float64  `json:"interventions_change_pct"`
+	AcceptanceRateChangePct *float64  `json:"acceptance_rate_change_pct"`
 }
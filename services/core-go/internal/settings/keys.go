@@ -0,0 +1,96 @@
+package settings
+
+// Keys of the user_settings table. Every package that reads or validates a
+// setting refers to it through these constants.
+
+// Quiet and active hours.
+const (
+	QuietHours  = "quiet_hours"
+	ActiveHours = "active_hours"
+	Timezone    = "timezone"
+)
+
+// Gateway budgets, caps, risk limits and costs.
+const (
+	InterventionBudget  = "intervention_budget"
+	BudgetSilent        = "budget_silent"
+	BudgetLight         = "budget_light"
+	BudgetActive        = "budget_active"
+	DailyBudgetCap      = "daily_budget_cap"
+	HourlyBudgetCap     = "hourly_budget_cap"
+	CooldownSeconds     = "cooldown_seconds"
+	AdaptiveCooldown    = "adaptive_cooldown_enabled"
+	AdaptiveCooldownMax = "adaptive_cooldown_max"
+	UrgentBypass        = "urgent_cooldown_bypass"
+	AllowHighRisk       = "allow_high_risk"
+	MaxRiskSilent       = "max_risk_silent"
+	MaxRiskLight        = "max_risk_light"
+	MaxRiskActive       = "max_risk_active"
+	DisabledActions     = "disabled_actions"
+	CostRest            = "cost_rest"
+	CostEncourage       = "cost_encourage"
+	CostTask            = "cost_task"
+	CostReframe         = "cost_reframe"
+	RepeatRest          = "repeat_interval_rest"
+	RepeatEncourage     = "repeat_interval_encourage"
+	RepeatTask          = "repeat_interval_task"
+	RepeatReframe       = "repeat_interval_reframe"
+)
+
+// Focus tracking.
+const (
+	FocusMonitorEnabled  = "focus_monitor_enabled"
+	FocusIngestEnabled   = "focus_ingest_enabled"
+	FocusProvider        = "focus_provider"
+	TitleSwitchThreshold = "title_switch_threshold"
+	FocusScoreWeights    = "focus_score_weights"
+	FocusPollMs          = "focus_poll_ms"
+	BatteryBackoff       = "battery_backoff_enabled"
+	BatteryPollFactor    = "battery_poll_multiplier"
+	RedactWindowTitles   = "redact_window_titles"
+	FocusAppBlocklist    = "focus_app_blocklist"
+	FocusAppAllowlist    = "focus_app_allowlist"
+	NoProgressInputReset = "no_progress_input_reset"
+	MeetingApps          = "focus_meeting_apps"
+)
+
+// AI and decision behaviour.
+const (
+	OllamaModel       = "ollama_model"
+	AIBackend         = "ai_backend"
+	AgentEnabled      = "agent_enabled"
+	RuleOnlyMode      = "rule_only_mode"
+	StrictSignals     = "strict_signals"
+	AutoMode          = "auto_mode"
+	AutoJitterPercent = "auto_suggestion_jitter_pct"
+	MaxAutoPerDay     = "max_auto_suggestions_per_day"
+	DebugPrompts      = "debug_prompts_enabled"
+	PolicyVariants    = "policy_variants"
+	BreakReminder     = "break_reminder_enabled"
+	BreakAfterMinutes = "break_after_minutes"
+	DefaultMode       = "default_mode"
+	WarmupSeconds     = "warmup_seconds"
+)
+
+// Operator templates for deterministic action messages.
+const (
+	MessageRestTemplate       = "message_rest_template"
+	MessageQuietHoursTemplate = "message_quiet_hours_template"
+	MessageAutoGuardTemplate  = "message_auto_guard_template"
+	MessageOverrideTemplate   = "message_override_template"
+)
+
+// Memory.
+const (
+	HalfLifeDays   = "profile_half_life_days"
+	NegativeWeight = "learn_negative_weight"
+	PositiveWeight = "learn_positive_weight"
+	FocusContext   = "memory_focus_context_enabled"
+	EventOrder     = "memory_event_order"
+)
+
+// Internal bookkeeping written by the service itself, not by clients.
+const (
+	LastAutoSuggestMs = "last_auto_suggestion_ms"
+	NextAutoSuggestMs = "next_auto_suggestion_ms"
+)
@@ -0,0 +1,131 @@
+// Package settings provides typed access to the user_settings table. Values
+// are validated when written (see httpapi's normalizeSettingValue), so the
+// getters here only fall back to the caller's default when a key is unset or
+// holds something they cannot parse.
+package settings
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Store is the part of db.Store the getters read from.
+type Store interface {
+	GetSetting(key string) (string, bool, error)
+}
+
+// Reader wraps a Store with typed getters. A Reader over a nil Store returns
+// every default.
+type Reader struct {
+	store Store
+}
+
+func New(store Store) Reader {
+	return Reader{store: store}
+}
+
+// GetString returns the trimmed value of key, or def when it is unset, empty
+// or "none", the value clients send to clear a setting.
+func (r Reader) GetString(key, def string) (string, error) {
+	if r.store == nil {
+		return def, nil
+	}
+	value, ok, err := r.store.GetSetting(key)
+	if err != nil {
+		return def, err
+	}
+	value = strings.TrimSpace(value)
+	if !ok || value == "" || value == "none" {
+		return def, nil
+	}
+	return value, nil
+}
+
+// GetRaw is GetString for settings where "none" means something other than
+// unset, such as switching a feature off: it is returned as is.
+func (r Reader) GetRaw(key, def string) (string, error) {
+	if r.store == nil {
+		return def, nil
+	}
+	value, ok, err := r.store.GetSetting(key)
+	if err != nil {
+		return def, err
+	}
+	value = strings.TrimSpace(value)
+	if !ok || value == "" {
+		return def, nil
+	}
+	return value, nil
+}
+
+// GetFloat parses key as a non-negative float; every numeric setting is a
+// budget, cap or cost, so a negative value counts as malformed.
+func (r Reader) GetFloat(key string, def float64) (float64, error) {
+	raw, err := r.GetString(key, "")
+	if err != nil || raw == "" {
+		return def, err
+	}
+	parsed, err := strconv.ParseFloat(raw, 64)
+	if err != nil || parsed < 0 {
+		return def, nil
+	}
+	return parsed, nil
+}
+
+// GetInt parses key as a non-negative integer.
+func (r Reader) GetInt(key string, def int) (int, error) {
+	raw, err := r.GetString(key, "")
+	if err != nil || raw == "" {
+		return def, err
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed < 0 {
+		return def, nil
+	}
+	return parsed, nil
+}
+
+// GetInt64 parses key as a non-negative 64-bit integer, such as a Unix
+// millisecond timestamp.
+func (r Reader) GetInt64(key string, def int64) (int64, error) {
+	raw, err := r.GetString(key, "")
+	if err != nil || raw == "" {
+		return def, err
+	}
+	parsed, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || parsed < 0 {
+		return def, nil
+	}
+	return parsed, nil
+}
+
+// GetBool accepts the "true"/"false" values normalizeSettingValue stores.
+func (r Reader) GetBool(key string, def bool) (bool, error) {
+	raw, err := r.GetString(key, "")
+	if err != nil || raw == "" {
+		return def, err
+	}
+	switch strings.ToLower(raw) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return def, nil
+	}
+}
+
+// GetDuration reads key as a non-negative integer count of unit, e.g.
+// cooldown_seconds with unit time.Second.
+func (r Reader) GetDuration(key string, unit time.Duration, def time.Duration) (time.Duration, error) {
+	raw, err := r.GetString(key, "")
+	if err != nil || raw == "" {
+		return def, err
+	}
+	parsed, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || parsed < 0 {
+		return def, nil
+	}
+	return time.Duration(parsed) * unit, nil
+}
@@ -0,0 +1,175 @@
+package settings
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type mapStore map[string]string
+
+func (m mapStore) GetSetting(key string) (string, bool, error) {
+	value, ok := m[key]
+	return value, ok, nil
+}
+
+type failingStore struct{}
+
+func (failingStore) GetSetting(string) (string, bool, error) {
+	return "", false, errors.New("disk on fire")
+}
+
+func TestGetString(t *testing.T) {
+	r := New(mapStore{"set": "  value ", "empty": " ", "cleared": "none"})
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"set", "value"},
+		{"empty", "def"},
+		{"cleared", "def"},
+		{"unset", "def"},
+	}
+	for _, tt := range tests {
+		if got, err := r.GetString(tt.key, "def"); err != nil || got != tt.want {
+			t.Errorf("GetString(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetRaw(t *testing.T) {
+	r := New(mapStore{"set": "  value ", "empty": " ", "cleared": "none"})
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"set", "value"},
+		{"empty", "def"},
+		{"cleared", "none"},
+		{"unset", "def"},
+	}
+	for _, tt := range tests {
+		if got, err := r.GetRaw(tt.key, "def"); err != nil || got != tt.want {
+			t.Errorf("GetRaw(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetFloat(t *testing.T) {
+	r := New(mapStore{"float": "2.5", "int": "3", "negative": "-1", "word": "lots", "zero": "0"})
+	tests := []struct {
+		key  string
+		want float64
+	}{
+		{"float", 2.5},
+		{"int", 3},
+		{"zero", 0},
+		{"negative", 7},
+		{"word", 7},
+		{"unset", 7},
+	}
+	for _, tt := range tests {
+		if got, err := r.GetFloat(tt.key, 7); err != nil || got != tt.want {
+			t.Errorf("GetFloat(%q) = %v, %v; want %v", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetInt(t *testing.T) {
+	r := New(mapStore{"int": "12", "float": "1.5", "negative": "-3", "padded": " 4 "})
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"int", 12},
+		{"padded", 4},
+		{"float", 9},
+		{"negative", 9},
+		{"unset", 9},
+	}
+	for _, tt := range tests {
+		if got, err := r.GetInt(tt.key, 9); err != nil || got != tt.want {
+			t.Errorf("GetInt(%q) = %v, %v; want %v", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetInt64(t *testing.T) {
+	r := New(mapStore{"ms": "1760000000000", "negative": "-3", "word": "soon"})
+	tests := []struct {
+		key  string
+		want int64
+	}{
+		{"ms", 1_760_000_000_000},
+		{"negative", 9},
+		{"word", 9},
+		{"unset", 9},
+	}
+	for _, tt := range tests {
+		if got, err := r.GetInt64(tt.key, 9); err != nil || got != tt.want {
+			t.Errorf("GetInt64(%q) = %v, %v; want %v", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetBool(t *testing.T) {
+	r := New(mapStore{"upper": "TRUE", "false": "false", "yes": "yes", "one": "1"})
+	tests := []struct {
+		key  string
+		def  bool
+		want bool
+	}{
+		{"upper", false, true},
+		{"false", true, false},
+		{"yes", false, false},
+		{"one", true, true},
+		{"unset", true, true},
+	}
+	for _, tt := range tests {
+		if got, err := r.GetBool(tt.key, tt.def); err != nil || got != tt.want {
+			t.Errorf("GetBool(%q, %v) = %v, %v; want %v", tt.key, tt.def, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetDuration(t *testing.T) {
+	r := New(mapStore{"seconds": "90", "float": "1.5", "negative": "-5"})
+	tests := []struct {
+		key  string
+		want time.Duration
+	}{
+		{"seconds", 90 * time.Second},
+		{"float", time.Minute},
+		{"negative", time.Minute},
+		{"unset", time.Minute},
+	}
+	for _, tt := range tests {
+		if got, err := r.GetDuration(tt.key, time.Second, time.Minute); err != nil || got != tt.want {
+			t.Errorf("GetDuration(%q) = %v, %v; want %v", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestReaderDefaults(t *testing.T) {
+	for name, r := range map[string]Reader{"nil store": New(nil), "failing store": New(failingStore{})} {
+		wantErr := name == "failing store"
+		if got, err := r.GetFloat("k", 1.5); got != 1.5 || (err != nil) != wantErr {
+			t.Errorf("%s: GetFloat = %v, %v", name, got, err)
+		}
+		if got, err := r.GetInt("k", 2); got != 2 || (err != nil) != wantErr {
+			t.Errorf("%s: GetInt = %v, %v", name, got, err)
+		}
+		if got, err := r.GetInt64("k", 3); got != 3 || (err != nil) != wantErr {
+			t.Errorf("%s: GetInt64 = %v, %v", name, got, err)
+		}
+		if got, err := r.GetRaw("k", "def"); got != "def" || (err != nil) != wantErr {
+			t.Errorf("%s: GetRaw = %q, %v", name, got, err)
+		}
+		if got, err := r.GetBool("k", true); !got || (err != nil) != wantErr {
+			t.Errorf("%s: GetBool = %v, %v", name, got, err)
+		}
+		if got, err := r.GetDuration("k", time.Second, time.Hour); got != time.Hour || (err != nil) != wantErr {
+			t.Errorf("%s: GetDuration = %v, %v", name, got, err)
+		}
+	}
+}
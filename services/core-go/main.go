@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,14 +19,27 @@ import (
 	"always/core/internal/focus"
 	"always/core/internal/httpapi"
 	"always/core/internal/memory"
+	"always/core/internal/settings"
+	"always/core/internal/tracing"
 )
 
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel()}))
 
 	port := getenv("CORE_PORT", "52123")
 	aiURL := getenv("AI_URL", "http://127.0.0.1:8788")
 	dbPath := getenv("DB_PATH", "./data/always.db")
+	runtime := httpapi.RuntimeConfig{}
+	runtime.Set("CORE_PORT", port)
+	runtime.Set("AI_URL", aiURL)
+	runtime.Set("DB_PATH", dbPath)
+	runtime.Set("LOG_LEVEL", logLevel().String())
+
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		logger.Error("tracing init failed", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	store, err := db.Open(dbPath)
 	if err != nil {
@@ -30,20 +47,32 @@ func main() {
 		os.Exit(1)
 	}
 
-	aiClient := ai.NewClient(aiURL)
-	focusMonitor := focus.NewMonitor(store, logger, focusInterval())
+	aiBackend, err := newAIBackend(store, aiURL, runtime)
+	if err != nil {
+		logger.Error("ai backend init failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+	pollInterval := focusInterval()
+	runtime.Set("FOCUS_POLL_MS", pollInterval.Milliseconds())
+	focusMonitor := focus.NewMonitor(store, logger, pollInterval)
 	focusMonitor.Start()
 
 	startedAt := time.Now()
 	memoryService := memory.NewService(store.DB(), logger)
-	handler := httpapi.NewHandler(store, aiClient, focusMonitor, memoryService, startedAt, logger)
-
+	go runDailySummaryJob(store, memoryService, logger)
+	go runMemoryCompactionJob(store, memoryService, logger)
 	server := &http.Server{
 		Addr:         ":" + port,
-		Handler:      handler.Router(),
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		ReadTimeout:  envDuration("READ_TIMEOUT_MS", 5*time.Second),
+		WriteTimeout: envDuration("WRITE_TIMEOUT_MS", 30*time.Second),
+		IdleTimeout:  envDuration("IDLE_TIMEOUT_MS", 60*time.Second),
 	}
+	runtime.Set("READ_TIMEOUT_MS", server.ReadTimeout.Milliseconds())
+	runtime.Set("WRITE_TIMEOUT_MS", server.WriteTimeout.Milliseconds())
+	runtime.Set("IDLE_TIMEOUT_MS", server.IdleTimeout.Milliseconds())
+	handler := httpapi.NewHandler(store, aiBackend, focusMonitor, memoryService, startedAt, logger, runtime)
+	server.Handler = handler.Router()
+	go handler.RunBreakReminders(time.Minute)
 
 	shutdownCh := make(chan os.Signal, 1)
 	signal.Notify(shutdownCh, os.Interrupt, syscall.SIGTERM)
@@ -55,6 +84,9 @@ func main() {
 		if err := server.Shutdown(ctx); err != nil {
 			logger.Error("graceful shutdown failed", slog.Any("error", err))
 		}
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("tracing shutdown failed", slog.Any("error", err))
+		}
 	}()
 
 	logger.Info("core service listening", slog.String("addr", server.Addr))
@@ -64,6 +96,110 @@ func main() {
 	}
 }
 
+// runDailySummaryJob records yesterday's rollup for every user once it is
+// missing, checking hourly so a restart around midnight still produces it.
+func runDailySummaryJob(store *db.Store, memoryService *memory.Service, logger *slog.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		forEachUser(store, logger, func(userID string) {
+			recordDailySummary(store.ForUser(userID), memoryService.ForUser(userID), logger.With(slog.String("user_id", userID)))
+		})
+		<-ticker.C
+	}
+}
+
+func recordDailySummary(store *db.Store, memoryService *memory.Service, logger *slog.Logger) {
+	// Days run midnight to midnight on the user's clock, not the server's.
+	yesterday := time.Now().In(memoryService.Location()).AddDate(0, 0, -1)
+	date := yesterday.Format("2006-01-02")
+	if _, ok, err := memoryService.GetDailySummary(date); err != nil {
+		logger.Error("daily summary lookup failed", slog.Any("error", err))
+	} else if !ok {
+		summary, err := store.DailySummary(yesterday)
+		if err != nil {
+			logger.Error("daily summary aggregation failed", slog.Any("error", err))
+		} else if err := memoryService.RecordDailySummary(summary); err != nil {
+			logger.Error("daily summary record failed", slog.Any("error", err))
+		} else {
+			logger.Info("daily summary recorded", slog.String("date", date))
+		}
+	}
+}
+
+// runMemoryCompactionJob periodically persists decayed profile confidences
+// for every user.
+func runMemoryCompactionJob(store *db.Store, memoryService *memory.Service, logger *slog.Logger) {
+	ticker := time.NewTicker(6 * time.Hour)
+	defer ticker.Stop()
+	for {
+		forEachUser(store, logger, func(userID string) {
+			result, err := memoryService.ForUser(userID).Compact()
+			if err != nil {
+				logger.Error("memory compaction failed", slog.String("user_id", userID), slog.Any("error", err))
+			} else {
+				logger.Info("memory compacted", slog.String("user_id", userID), slog.Int("updated", result.Updated), slog.Int("dropped", result.Dropped))
+			}
+		})
+		<-ticker.C
+	}
+}
+
+// forEachUser runs fn for every user known to store. When the users cannot
+// be listed only the default user is visited.
+func forEachUser(store *db.Store, logger *slog.Logger, fn func(userID string)) {
+	userIDs, err := store.UserIDs()
+	if err != nil {
+		logger.Error("list users failed", slog.Any("error", err))
+		userIDs = []string{store.UserID()}
+	}
+	for _, userID := range userIDs {
+		fn(userID)
+	}
+}
+
+// newAIBackend picks the backend from AI_BACKEND, falling back to the
+// ai_backend setting so the choice can be made from the settings UI. The
+// setting is only read at startup.
+func newAIBackend(store *db.Store, aiURL string, runtime httpapi.RuntimeConfig) (ai.AIBackend, error) {
+	backend := os.Getenv("AI_BACKEND")
+	if backend == "" {
+		value, err := settings.New(store).GetString(settings.AIBackend, "")
+		if err != nil {
+			return nil, err
+		}
+		backend = value
+	}
+	cfg := ai.Config{
+		Backend: backend,
+		BaseURL: aiURL,
+		APIKey:  os.Getenv("AI_API_KEY"),
+		Model:   os.Getenv("AI_MODEL"),
+		// Empty is strict; NewBackend rejects unknown levels.
+		Validation: os.Getenv("AI_RESPONSE_VALIDATION"),
+	}
+	if raw := strings.TrimSpace(os.Getenv("AI_PROMPT_VARIANTS")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg.PromptVariants); err != nil {
+			return nil, fmt.Errorf("parse AI_PROMPT_VARIANTS: %w", err)
+		}
+	}
+	variantNames := make([]string, 0, len(cfg.PromptVariants))
+	for name := range cfg.PromptVariants {
+		variantNames = append(variantNames, name)
+	}
+	sort.Strings(variantNames)
+	runtime.Set("AI_PROMPT_VARIANTS", variantNames)
+	if ai.NormalizeBackend(backend) == ai.BackendOpenAI {
+		cfg.BaseURL = getenv("OPENAI_BASE_URL", "http://127.0.0.1:11434/v1")
+		runtime.Set("OPENAI_BASE_URL", cfg.BaseURL)
+	}
+	runtime.Set("AI_BACKEND", ai.NormalizeBackend(backend))
+	runtime.Set("AI_MODEL", cfg.Model)
+	runtime.Set("AI_API_KEY", cfg.APIKey)
+	runtime.Set("AI_RESPONSE_VALIDATION", ai.NormalizeValidation(cfg.Validation))
+	return ai.NewBackend(cfg)
+}
+
 func getenv(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -71,6 +207,28 @@ func getenv(key, fallback string) string {
 	return fallback
 }
 
+// envDuration reads a millisecond duration from key. Zero is allowed and
+// disables the corresponding server timeout.
+func envDuration(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed < 0 {
+		return fallback
+	}
+	return time.Duration(parsed) * time.Millisecond
+}
+
+func logLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(getenv("LOG_LEVEL", "info"))); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
 func focusInterval() time.Duration {
 	if raw := os.Getenv("FOCUS_POLL_MS"); raw != "" {
 		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {